@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/agent"
+)
+
+// runAgent serves job assignments from the pipeline daemon until interrupted.
+// The agent never opens the pipeline database.
+func runAgent(args []string) error {
+	fs := flag.NewFlagSet("agent", flag.ExitOnError)
+	listen := fs.String("listen", ":7300", "Address to listen on")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:    *listen,
+		Handler: agent.NewServer().Handler(),
+		// Running jobs stop when their request context is cancelled
+		BaseContext: func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Printf("Agent listening on %s\n", *listen)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("agent server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Running jobs were signalled through their request contexts; give them
+	// time to record the cancellation and send their final events
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("failed to shut down agent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,817 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/items"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/legacy"
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"github.com/cuivienor/media-pipeline/internal/organize"
+)
+
+// ctl runs the scripting subcommands: the TUI's actions without the UI.
+// Every subcommand takes -json to print machine-readable output.
+type ctl struct {
+	cfg      *config.Config
+	database *db.DB
+	repo     db.Repository
+	out      io.Writer
+	json     bool
+}
+
+// ctlCommands maps each scripting subcommand to its implementation
+var ctlCommands = map[string]func(*ctl, context.Context, []string) error{
+	"items":         (*ctl).listItems,
+	"show":          (*ctl).showItem,
+	"history":       (*ctl).history,
+	"jobs":          (*ctl).listJobs,
+	"job":           (*ctl).showJob,
+	"add-item":      (*ctl).addItem,
+	"add-season":    (*ctl).addSeason,
+	"import":        (*ctl).importManifest,
+	"import-legacy": (*ctl).importLegacy,
+	"start":         (*ctl).start,
+	"rips-done":     (*ctl).ripsDone,
+	"organize-done": (*ctl).organizeDone,
+	"retry":         (*ctl).retry,
+	"cancel":        (*ctl).cancel,
+	"log":           (*ctl).log,
+	"backup":        (*ctl).backup,
+	"db-export":     (*ctl).dbExport,
+	"db-import":     (*ctl).dbImport,
+}
+
+// runCtl runs a scripting subcommand against the pipeline database
+func runCtl(name string, args []string) error {
+	cfg, err := config.LoadFromMediaBase()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := db.Open(cfg.DatabasePath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := &ctl{cfg: cfg, database: database, repo: db.NewSQLiteRepository(database).WithActor("cli"), out: os.Stdout}
+	if err := ctlCommands[name](c, ctx, args); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return nil
+}
+
+// flags returns a flag set for a subcommand with the shared -json flag
+func (c *ctl) flags(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.BoolVar(&c.json, "json", false, "Print JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: media-pipeline %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses flags, which may come before or after the positional
+// arguments, and checks the number of positional arguments
+func parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != want {
+		fs.Usage()
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", fs.Name(), want, len(positional))
+	}
+	return positional, nil
+}
+
+// print writes v as JSON, or calls text to write it for humans
+func (c *ctl) print(v interface{}, text func(w io.Writer)) error {
+	if c.json {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	text(w)
+	return w.Flush()
+}
+
+// findItem looks an item up by ID, safe name or name, with its seasons
+func (c *ctl) findItem(ctx context.Context, ref string) (*model.MediaItem, error) {
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		// Only the ID lookup loads the item's stage and status
+		named, err := c.repo.GetMediaItemBySafeName(ctx, items.SafeName(ref), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get item %s: %w", ref, err)
+		}
+		if named == nil {
+			return nil, fmt.Errorf("no item %q", ref)
+		}
+		id = named.ID
+	}
+
+	item, err := c.repo.GetMediaItem(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item %s: %w", ref, err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("no item %q", ref)
+	}
+
+	if item.Type == model.MediaTypeTV {
+		seasons, err := c.repo.ListSeasonsForItem(ctx, item.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list seasons: %w", err)
+		}
+		item.Seasons = seasons
+	}
+	return item, nil
+}
+
+// findSeason returns the season with the given number, nil for 0
+func findSeason(item *model.MediaItem, number int) (*model.Season, error) {
+	if number == 0 {
+		return nil, nil
+	}
+	if item.Type != model.MediaTypeTV {
+		return nil, fmt.Errorf("%s is a movie and has no seasons", item.Name)
+	}
+	for i := range item.Seasons {
+		if item.Seasons[i].Number == number {
+			return &item.Seasons[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no season %d", item.Name, number)
+}
+
+// findJob looks a job up by ID
+func (c *ctl) findJob(ctx context.Context, ref string) (*model.Job, error) {
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job ID %q", ref)
+	}
+	job, err := c.repo.GetJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %d: %w", id, err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("no job %d", id)
+	}
+	return job, nil
+}
+
+// listItems prints every item
+func (c *ctl) listItems(ctx context.Context, args []string) error {
+	fs := c.flags("items", "[-active] [-json]")
+	active := fs.Bool("active", false, "Only items still in the pipeline")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	list, err := c.repo.ListMediaItems(ctx, db.ListOptions{ActiveOnly: *active})
+	if err != nil {
+		return err
+	}
+
+	out := make([]itemJSON, len(list))
+	for i := range list {
+		item, err := c.findItem(ctx, strconv.FormatInt(list[i].ID, 10))
+		if err != nil {
+			return err
+		}
+		out[i] = newItemJSON(item)
+	}
+
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tTYPE\tNAME\tSTAGE\tSTATUS\tAUTOPILOT")
+		for _, item := range out {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", item.ID, item.Type, item.Name, item.stageLabel(), item.statusLabel(), onOff(item.Autopilot))
+		}
+	})
+}
+
+// showItem prints an item with its seasons and jobs
+func (c *ctl) showItem(ctx context.Context, args []string) error {
+	fs := c.flags("show", "<item> [-json]")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.findItem(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	history, err := c.repo.ListJobsForMedia(ctx, item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	out := newItemJSON(item)
+	for i := range history {
+		out.Jobs = append(out.Jobs, newJobJSON(&history[i]))
+	}
+
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Item:\t%d %s (%s)\n", out.ID, out.Name, out.Type)
+		fmt.Fprintf(w, "Safe name:\t%s\n", out.SafeName)
+		if id := item.DatabaseID(); id != 0 {
+			fmt.Fprintf(w, "Database ID:\t%d\n", id)
+		}
+		fmt.Fprintf(w, "Status:\t%s\n", out.Status)
+		fmt.Fprintf(w, "Autopilot:\t%s\n", onOff(out.Autopilot))
+		if item.Type == model.MediaTypeMovie {
+			fmt.Fprintf(w, "Stage:\t%s %s\n", out.Stage, out.StageStatus)
+		}
+		for _, s := range out.Seasons {
+			fmt.Fprintf(w, "Season %d:\t%s %s (autopilot %s)\n", s.Number, s.Stage, s.StageStatus, onOff(s.Autopilot))
+		}
+		fmt.Fprintln(w)
+		writeJobTable(w, out.Jobs)
+	})
+}
+
+// history prints the recorded stage and status changes of an item, its
+// seasons and its jobs
+func (c *ctl) history(ctx context.Context, args []string) error {
+	fs := c.flags("history", "<item> [-json]")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.findItem(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	transitions, err := c.repo.ListTransitions(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+
+	out := make([]transitionJSON, 0, len(transitions))
+	for i := range transitions {
+		out = append(out, newTransitionJSON(&transitions[i]))
+	}
+	return c.print(out, func(w io.Writer) {
+		writeTransitionTable(w, out)
+	})
+}
+
+// listJobs prints jobs: by default the queued and running ones
+func (c *ctl) listJobs(ctx context.Context, args []string) error {
+	fs := c.flags("jobs", "[-item <item>] [-status <status>] [-stage <stage>] [-worker <host>] [-since <duration>] [-json]")
+	itemRef := fs.String("item", "", "Only jobs of this item (ID or name)")
+	status := fs.String("status", "", "Only jobs with this status (pending, in_progress, completed, failed, cancelled)")
+	stage := fs.String("stage", "", "Only jobs of this stage (rip, organize, remux, transcode, publish)")
+	worker := fs.String("worker", "", "Only jobs run on this worker host")
+	since := fs.Duration("since", 0, "Only jobs created within this long, such as 24h")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	var filter db.JobFilter
+	if *itemRef != "" {
+		item, err := c.findItem(ctx, *itemRef)
+		if err != nil {
+			return err
+		}
+		filter.MediaItemIDs = []int64{item.ID}
+	}
+	if *stage != "" {
+		s, err := model.ParseStage(*stage)
+		if err != nil {
+			return err
+		}
+		filter.Stages = []model.Stage{s}
+	}
+	filter.WorkerID = *worker
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+	switch {
+	case *status != "":
+		filter.Statuses = []model.JobStatus{model.JobStatus(*status)}
+	case *itemRef == "" && *stage == "" && *worker == "" && *since == 0:
+		filter.Statuses = []model.JobStatus{model.JobStatusInProgress, model.JobStatusPending}
+	}
+
+	page, err := c.repo.ListJobs(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+	list := page.Jobs
+
+	out := make([]jobJSON, len(list))
+	for i := range list {
+		out[i] = newJobJSON(&list[i])
+	}
+	return c.print(out, func(w io.Writer) { writeJobTable(w, out) })
+}
+
+// showJob prints a single job
+func (c *ctl) showJob(ctx context.Context, args []string) error {
+	fs := c.flags("job", "<job-id> [-json]")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	job, err := c.findJob(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+
+	out := newJobJSON(job)
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Job:\t%d\n", out.ID)
+		fmt.Fprintf(w, "Item:\t%d\n", out.ItemID)
+		if out.SeasonID != nil {
+			fmt.Fprintf(w, "Season ID:\t%d\n", *out.SeasonID)
+		}
+		fmt.Fprintf(w, "Stage:\t%s\n", out.Stage)
+		if out.Disc != nil {
+			fmt.Fprintf(w, "Disc:\t%d\n", *out.Disc)
+		}
+		fmt.Fprintf(w, "Status:\t%s\n", out.Status)
+		fmt.Fprintf(w, "Attempt:\t%d (run %d)\n", out.Attempt, out.RunID)
+		fmt.Fprintf(w, "Priority:\t%d\n", out.Priority)
+		if out.Worker != "" {
+			fmt.Fprintf(w, "Worker:\t%s (pid %d)\n", out.Worker, out.PID)
+		}
+		if out.Status == model.JobStatusInProgress {
+			fmt.Fprintf(w, "Progress:\t%d%%\n", out.Progress)
+		}
+		if out.InputDir != "" {
+			fmt.Fprintf(w, "Input:\t%s\n", out.InputDir)
+		}
+		if out.OutputDir != "" {
+			fmt.Fprintf(w, "Output:\t%s\n", out.OutputDir)
+		}
+		if out.Error != "" {
+			fmt.Fprintf(w, "Error:\t%s (%s)\n", out.Error, out.FailureKind)
+		}
+		if out.NotBefore != nil {
+			fmt.Fprintf(w, "Not before:\t%s\n", out.NotBefore.Local().Format(time.DateTime))
+		}
+		if out.StartedAt != nil {
+			fmt.Fprintf(w, "Started:\t%s\n", out.StartedAt.Local().Format(time.DateTime))
+		}
+		if out.CompletedAt != nil {
+			fmt.Fprintf(w, "Completed:\t%s\n", out.CompletedAt.Local().Format(time.DateTime))
+		}
+	})
+}
+
+// addItem creates a movie or TV show, as the TUI's new item form does
+func (c *ctl) addItem(ctx context.Context, args []string) error {
+	fs := c.flags("add-item", "-type movie|tv -name <name> [-id <tmdb/tvdb id>] [-seasons 1-3] [-json]")
+	mediaType := fs.String("type", "movie", "movie or tv")
+	name := fs.String("name", "", "Display name")
+	dbID := fs.Int("id", 0, "TMDB ID for movies, TVDB ID for TV shows")
+	seasonList := fs.String("seasons", "", "TV seasons to create: 1-5 or 1,2,3")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+	var seasons []int
+	switch model.MediaType(*mediaType) {
+	case model.MediaTypeMovie:
+		if *seasonList != "" {
+			return fmt.Errorf("-seasons is only for TV shows")
+		}
+	case model.MediaTypeTV:
+		if *seasonList == "" {
+			return fmt.Errorf("-seasons is required for TV shows (e.g., '1-5' or '1,2,3')")
+		}
+		parsed, err := items.ParseSeasons(*seasonList)
+		if err != nil {
+			return err
+		}
+		seasons = parsed
+	default:
+		return fmt.Errorf("invalid -type %q: want movie or tv", *mediaType)
+	}
+
+	item, err := items.Create(ctx, c.repo, model.MediaType(*mediaType), *name, *dbID, seasons)
+	if err != nil {
+		return err
+	}
+
+	out := newItemJSON(item)
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Created item %d: %s\n", out.ID, out.Name)
+	})
+}
+
+// addSeason adds the next season to a TV show
+func (c *ctl) addSeason(ctx context.Context, args []string) error {
+	fs := c.flags("add-season", "<item> [-json]")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.findItem(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	if item.Type != model.MediaTypeTV {
+		return fmt.Errorf("%s is a movie and has no seasons", item.Name)
+	}
+	season, err := items.AddSeason(ctx, c.repo, item)
+	if err != nil {
+		return err
+	}
+
+	out := newSeasonJSON(season)
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Added season %d to %s\n", out.Number, item.Name)
+	})
+}
+
+// importManifest creates the items of a CSV or YAML manifest that do not
+// exist yet, and reports the rest as duplicates
+func (c *ctl) importManifest(ctx context.Context, args []string) error {
+	fs := c.flags("import", "<manifest.csv|manifest.yaml> [-dry-run] [-json]")
+	dryRun := fs.Bool("dry-run", false, "Report what would be created without writing anything")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	entries, err := items.ReadManifest(pos[0])
+	if err != nil {
+		return err
+	}
+	results, err := items.Import(ctx, c.repo, entries, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	out := importJSON{DryRun: *dryRun, Results: make([]importResultJSON, 0, len(results))}
+	conflicts := 0
+	for _, r := range results {
+		out.Results = append(out.Results, newImportResultJSON(r))
+		if r.Action == items.ImportConflict {
+			conflicts++
+		}
+	}
+	if err := c.print(out, func(w io.Writer) { writeImportTable(w, out) }); err != nil {
+		return err
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("%d entries conflict with existing items", conflicts)
+	}
+	return nil
+}
+
+// importLegacy records the state directories left by the old bash scripts
+// as jobs, creating their items and seasons
+func (c *ctl) importLegacy(ctx context.Context, args []string) error {
+	fs := c.flags("import-legacy", "[-staging <dir>] [-dry-run] [-json]")
+	staging := fs.String("staging", c.cfg.StagingBase, "Staging tree to look for .rip, .remux, .transcode and .filebot directories in")
+	dryRun := fs.Bool("dry-run", false, "Report what would be imported without writing anything")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *staging == "" {
+		return fmt.Errorf("-staging is required when staging_base is not configured")
+	}
+
+	states, scanErrs := legacy.Scan(*staging)
+	results, err := legacy.Import(ctx, c.repo, states, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	out := legacyImportJSON{DryRun: *dryRun, Results: make([]legacyResultJSON, 0, len(results))}
+	for _, r := range results {
+		out.Results = append(out.Results, newLegacyResultJSON(r))
+	}
+	for _, e := range scanErrs {
+		out.Errors = append(out.Errors, e.Error())
+	}
+	if err := c.print(out, func(w io.Writer) { writeLegacyImportTable(w, out) }); err != nil {
+		return err
+	}
+	if len(scanErrs) > 0 {
+		return fmt.Errorf("%d state directories could not be read", len(scanErrs))
+	}
+	return nil
+}
+
+// start queues a stage, as the TUI's start actions do
+func (c *ctl) start(ctx context.Context, args []string) error {
+	fs := c.flags("start", "<item> <stage> [-season N] [-json]")
+	seasonNum := fs.Int("season", 0, "Season of a TV show")
+	pos, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.findItem(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	stage, err := model.ParseStage(pos[1])
+	if err != nil {
+		return err
+	}
+	season, err := findSeason(item, *seasonNum)
+	if err != nil {
+		return err
+	}
+
+	job, err := jobs.Start(ctx, c.repo, item, season, stage)
+	if err != nil {
+		return err
+	}
+
+	out := newJobJSON(job)
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Queued %s job %d\n", job.Stage, job.ID)
+	})
+}
+
+// ripsDone marks a TV season's discs as all ripped
+func (c *ctl) ripsDone(ctx context.Context, args []string) error {
+	fs := c.flags("rips-done", "<item> -season N [-json]")
+	seasonNum := fs.Int("season", 0, "Season whose discs are all ripped")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.findItem(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	if *seasonNum == 0 {
+		return fmt.Errorf("-season is required")
+	}
+	season, err := findSeason(item, *seasonNum)
+	if err != nil {
+		return err
+	}
+	if err := jobs.MarkRipsDone(ctx, c.repo, item, season); err != nil {
+		return err
+	}
+
+	season.CurrentStage, season.StageStatus = model.StageRip, model.StatusCompleted
+	out := newSeasonJSON(season)
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Ripping done for %s season %d\n", item.Name, season.Number)
+	})
+}
+
+// organizeDone validates the organized rip output and marks organize done,
+// as the TUI's organize view does
+func (c *ctl) organizeDone(ctx context.Context, args []string) error {
+	fs := c.flags("organize-done", "<item> [-season N] [-json]")
+	seasonNum := fs.Int("season", 0, "Season of a TV show")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.findItem(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	if item.Type == model.MediaTypeTV && *seasonNum == 0 {
+		return fmt.Errorf("%s is a TV show: pass -season", item.Name)
+	}
+	season, err := findSeason(item, *seasonNum)
+	if err != nil {
+		return err
+	}
+
+	path, discPaths, err := jobs.RipOutput(ctx, c.repo, item, season)
+	if err != nil {
+		return err
+	}
+	validator := &organize.Validator{}
+	result := validator.Validate(item.Type == model.MediaTypeTV, path, discPaths)
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if !result.Valid {
+		return fmt.Errorf("%s is not organized yet:\n  %s", path, strings.Join(result.Errors, "\n  "))
+	}
+
+	job, err := jobs.CompleteOrganize(ctx, c.repo, item, season, path)
+	if err != nil {
+		return err
+	}
+
+	out := newJobJSON(job)
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Organize done for %s (%s)\n", item.Name, path)
+	})
+}
+
+// retry queues another attempt of a failed or cancelled job
+func (c *ctl) retry(ctx context.Context, args []string) error {
+	fs := c.flags("retry", "<job-id> [-json]")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	job, err := c.findJob(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	next, err := jobs.RetryNow(ctx, c.repo, job)
+	if err != nil {
+		return err
+	}
+
+	out := newJobJSON(next)
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Queued attempt %d of job %d as job %d\n", next.Attempt, job.ID, next.ID)
+	})
+}
+
+// cancel cancels a queued job or stops a running one
+func (c *ctl) cancel(ctx context.Context, args []string) error {
+	fs := c.flags("cancel", "<job-id> [-json]")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	job, err := c.findJob(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	if err := jobs.Cancel(ctx, c.repo, c.cfg, job); err != nil {
+		return err
+	}
+
+	out := newJobJSON(job)
+	return c.print(out, func(w io.Writer) {
+		if job.Status == model.JobStatusCancelled {
+			fmt.Fprintf(w, "Cancelled job %d\n", job.ID)
+		} else {
+			fmt.Fprintf(w, "Asked job %d to stop\n", job.ID)
+		}
+	})
+}
+
+// logPollInterval is how often log -f checks for new lines
+const logPollInterval = time.Second
+
+// log prints the end of a job's log, and with -f follows it until the job
+// finishes. JSON output is one object per line.
+func (c *ctl) log(ctx context.Context, args []string) error {
+	fs := c.flags("log", "<job-id> [-n lines] [-f] [-tool name] [-json]")
+	lines := fs.Int("n", 20, "Number of lines to print from the end (0 for all)")
+	follow := fs.Bool("f", false, "Keep printing lines until the job finishes")
+	tool := fs.String("tool", "", "Print a tool's raw output instead, e.g. makemkv or mkvmerge")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	job, err := c.findJob(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	path := job.LogPath
+	if path == "" {
+		path = c.cfg.JobLogPath(job.ID)
+	}
+	if *tool != "" {
+		if path, err = c.toolLogPath(ctx, job.ID, *tool); err != nil {
+			return err
+		}
+	}
+
+	offset, err := c.printLog(path, 0, *lines)
+	if err != nil && !(*follow && errors.Is(err, os.ErrNotExist)) {
+		return err
+	}
+	if !*follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for {
+		current, err := c.repo.GetJob(ctx, job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reload job: %w", err)
+		}
+		// Read once more after the job finishes, for its last lines
+		done := current == nil || !current.IsActive()
+		if offset, err = c.printLog(path, offset, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// toolLogPath returns the path of a job's tool log
+func (c *ctl) toolLogPath(ctx context.Context, jobID int64, tool string) (string, error) {
+	toolLogs, err := c.repo.ListToolLogs(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, toolLog := range toolLogs {
+		if toolLog.Tool == tool {
+			return toolLog.Path, nil
+		}
+		names = append(names, toolLog.Tool)
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("job %d has no tool logs", jobID)
+	}
+	return "", fmt.Errorf("job %d has no %s log (has %s)", jobID, tool, strings.Join(names, ", "))
+}
+
+// printLog prints the log from offset on, only the last tail lines if tail
+// is set, and returns the offset to continue from
+func (c *ctl) printLog(path string, offset int64, tail int) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return offset, fmt.Errorf("failed to open job log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, fmt.Errorf("failed to read job log: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return offset, fmt.Errorf("failed to read job log: %w", err)
+	}
+
+	// Leave a partly written last line for the next read
+	end := strings.LastIndexByte(string(data), '\n') + 1
+	text := strings.Split(string(data[:end]), "\n")
+	text = text[:len(text)-1]
+	if tail > 0 && len(text) > tail {
+		text = text[len(text)-tail:]
+	}
+
+	for _, line := range text {
+		if c.json {
+			if err := json.NewEncoder(c.out).Encode(logLineJSON{Line: line}); err != nil {
+				return offset, err
+			}
+		} else {
+			fmt.Fprintln(c.out, line)
+		}
+	}
+	return offset + int64(end), nil
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
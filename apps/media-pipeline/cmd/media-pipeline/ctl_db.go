@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/backup"
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/diskspace"
+)
+
+// defaultBackupKeep is how many snapshots backup keeps unless told otherwise
+const defaultBackupKeep = 7
+
+type backupJSON struct {
+	Path    string   `json:"path"`
+	Size    int64    `json:"size"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+type migrationJSON struct {
+	Version    string `json:"version"`
+	AppliedAt  string `json:"applied_at,omitempty"`
+	Reversible bool   `json:"reversible"`
+	Known      bool   `json:"known"`
+}
+
+type migrationRunJSON struct {
+	Versions []string `json:"versions"`
+	Backup   string   `json:"backup,omitempty"`
+}
+
+type dbImportJSON struct {
+	Schema         string `json:"schema"`
+	MediaItems     int    `json:"media_items"`
+	Seasons        int    `json:"seasons"`
+	Jobs           int    `json:"jobs"`
+	TranscodeFiles int    `json:"transcode_files"`
+}
+
+// backup snapshots the database while workers may be writing to it, and
+// prunes old snapshots
+func (c *ctl) backup(ctx context.Context, args []string) error {
+	fs := c.flags("backup", "[-dir <dir>] [-keep N] [-json]")
+	dir := fs.String("dir", filepath.Join(c.cfg.DataDir(), "backups"), "Directory to write snapshots to")
+	keep := fs.Int("keep", defaultBackupKeep, "Snapshots to keep, newest first (0 keeps all)")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	path, err := backup.Snapshot(ctx, c.database, *dir, time.Now())
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat backup: %w", err)
+	}
+	removed, err := backup.Prune(*dir, *keep)
+	if err != nil {
+		return err
+	}
+
+	out := backupJSON{Path: path, Size: info.Size(), Removed: removed}
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Backed up to %s (%s)\n", out.Path, diskspace.FormatBytes(out.Size))
+		for _, path := range out.Removed {
+			fmt.Fprintf(w, "Removed %s\n", path)
+		}
+	})
+}
+
+// dbExport writes the items, seasons, jobs and transcode files as JSON
+func (c *ctl) dbExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("db-export", flag.ContinueOnError)
+	output := fs.String("o", "", "File to write to (default stdout)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: media-pipeline db-export [-o <file>]\n")
+		fs.PrintDefaults()
+	}
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	export, err := c.database.Export(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := c.out
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create export: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// dbImport loads a db-export file into a database with no items yet
+func (c *ctl) dbImport(ctx context.Context, args []string) error {
+	fs := c.flags("db-import", "<export.json> [-json]")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(pos[0])
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+	export, err := db.ReadExport(f)
+	if err != nil {
+		return err
+	}
+	if err := c.database.Import(ctx, export); err != nil {
+		return err
+	}
+
+	out := dbImportJSON{
+		Schema:         export.Schema,
+		MediaItems:     len(export.MediaItems),
+		Seasons:        len(export.Seasons),
+		Jobs:           len(export.Jobs),
+		TranscodeFiles: len(export.TranscodeFiles),
+	}
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "Imported %d items, %d seasons, %d jobs and %d transcode files\n",
+			out.MediaItems, out.Seasons, out.Jobs, out.TranscodeFiles)
+	})
+}
+
+// migrateCommands are the subcommands of db migrate
+var migrateCommands = map[string]func(*ctl, context.Context, []string) error{
+	"status": (*ctl).migrateStatus,
+	"up":     (*ctl).migrateUp,
+	"down":   (*ctl).migrateDown,
+}
+
+// runDB runs db migrate status|up|down. Unlike the other subcommands it
+// opens the database without applying pending migrations.
+func runDB(args []string) error {
+	if len(args) < 2 || args[0] != "migrate" || migrateCommands[args[1]] == nil {
+		return fmt.Errorf("usage: media-pipeline db migrate status|up|down [-steps N] [-json]")
+	}
+
+	cfg, err := config.LoadFromMediaBase()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	database, err := db.OpenUnmigrated(cfg.DatabasePath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := &ctl{cfg: cfg, database: database, repo: db.NewSQLiteRepository(database).WithActor("cli"), out: os.Stdout}
+	if err := migrateCommands[args[1]](c, ctx, args[2:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return nil
+}
+
+// migrateStatus lists the migrations and whether each is applied
+func (c *ctl) migrateStatus(ctx context.Context, args []string) error {
+	fs := c.flags("db migrate status", "[-json]")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	states, err := c.database.Migrations(ctx)
+	if err != nil {
+		return err
+	}
+	out := make([]migrationJSON, 0, len(states))
+	for _, s := range states {
+		out = append(out, migrationJSON{Version: s.Version, AppliedAt: s.AppliedAt, Reversible: s.Reversible, Known: s.Known})
+	}
+	return c.print(out, func(w io.Writer) {
+		fmt.Fprintln(w, "VERSION\tAPPLIED\tREVERSIBLE")
+		for _, m := range out {
+			applied := m.AppliedAt
+			switch {
+			case !m.Known:
+				applied += " (unknown to this version)"
+			case applied == "":
+				applied = "pending"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", m.Version, applied, reversible(m.Reversible))
+		}
+	})
+}
+
+// migrateUp applies the pending migrations
+func (c *ctl) migrateUp(ctx context.Context, args []string) error {
+	fs := c.flags("db migrate up", "[-json]")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	run, err := c.database.MigrateUp(ctx)
+	// What ran before a failure, and the backup to restore, still matter
+	if run != nil && (err == nil || run.Backup != "") {
+		if printErr := c.printMigrationRun(run, "Applied"); err == nil {
+			err = printErr
+		}
+	}
+	return err
+}
+
+// migrateDown rolls back the newest applied migrations
+func (c *ctl) migrateDown(ctx context.Context, args []string) error {
+	fs := c.flags("db migrate down", "[-steps N] [-json]")
+	steps := fs.Int("steps", 1, "Migrations to roll back, newest first")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return fmt.Errorf("-steps must be at least 1")
+	}
+	run, err := c.database.MigrateDown(ctx, *steps)
+	// What ran before a failure, and the backup to restore, still matter
+	if run != nil && (err == nil || run.Backup != "") {
+		if printErr := c.printMigrationRun(run, "Rolled back"); err == nil {
+			err = printErr
+		}
+	}
+	return err
+}
+
+func (c *ctl) printMigrationRun(run *db.MigrationRun, verb string) error {
+	out := migrationRunJSON{Versions: run.Versions, Backup: run.Backup}
+	if out.Versions == nil {
+		out.Versions = []string{}
+	}
+	return c.print(out, func(w io.Writer) {
+		if out.Backup != "" {
+			fmt.Fprintf(w, "Backed up to %s\n", out.Backup)
+		}
+		if len(out.Versions) == 0 {
+			fmt.Fprintln(w, "Nothing to do")
+		}
+		for _, version := range out.Versions {
+			fmt.Fprintf(w, "%s %s\n", verb, version)
+		}
+	})
+}
+
+func reversible(ok bool) string {
+	if ok {
+		return "yes"
+	}
+	return "no"
+}
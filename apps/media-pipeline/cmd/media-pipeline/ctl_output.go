@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/items"
+	"github.com/cuivienor/media-pipeline/internal/legacy"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// The JSON shapes printed by the scripting subcommands. They are kept apart
+// from the model so scripts keep working when the model changes.
+
+type itemJSON struct {
+	ID          int64            `json:"id"`
+	Type        model.MediaType  `json:"type"`
+	Name        string           `json:"name"`
+	SafeName    string           `json:"safe_name"`
+	Year        *int             `json:"year,omitempty"`
+	TmdbID      *int             `json:"tmdb_id,omitempty"`
+	TvdbID      *int             `json:"tvdb_id,omitempty"`
+	Status      model.ItemStatus `json:"status"`
+	Stage       string           `json:"stage,omitempty"`        // Movies only
+	StageStatus model.Status     `json:"stage_status,omitempty"` // Movies only
+	Autopilot   bool             `json:"autopilot"`
+	Seasons     []seasonJSON     `json:"seasons,omitempty"`
+	Jobs        []jobJSON        `json:"jobs,omitempty"` // Only from show
+}
+
+type seasonJSON struct {
+	ID          int64        `json:"id"`
+	Number      int          `json:"number"`
+	Stage       string       `json:"stage"`
+	StageStatus model.Status `json:"stage_status"`
+	Autopilot   bool         `json:"autopilot"`
+	Discs       int          `json:"discs,omitempty"`
+}
+
+type jobJSON struct {
+	ID          int64             `json:"id"`
+	ItemID      int64             `json:"item_id"`
+	SeasonID    *int64            `json:"season_id,omitempty"`
+	Stage       string            `json:"stage"`
+	Disc        *int              `json:"disc,omitempty"`
+	Status      model.JobStatus   `json:"status"`
+	Progress    int               `json:"progress"`
+	Priority    int               `json:"priority"`
+	Attempt     int               `json:"attempt"`
+	RunID       int64             `json:"run_id"`
+	Worker      string            `json:"worker,omitempty"`
+	PID         int               `json:"pid,omitempty"`
+	InputDir    string            `json:"input_dir,omitempty"`
+	OutputDir   string            `json:"output_dir,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	FailureKind model.FailureKind `json:"failure_kind,omitempty"`
+	NotBefore   *time.Time        `json:"not_before,omitempty"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type transitionJSON struct {
+	ID       int64                `json:"id"`
+	Kind     model.TransitionKind `json:"kind"`
+	ItemID   int64                `json:"item_id"`
+	SeasonID *int64               `json:"season_id,omitempty"`
+	JobID    *int64               `json:"job_id,omitempty"`
+	From     string               `json:"from"` // stage/status
+	To       string               `json:"to"`
+	Actor    string               `json:"actor"`
+	At       time.Time            `json:"at"`
+}
+
+type logLineJSON struct {
+	Line string `json:"line"`
+}
+
+type importJSON struct {
+	DryRun  bool               `json:"dry_run"`
+	Results []importResultJSON `json:"results"`
+}
+
+type importResultJSON struct {
+	Line    int                `json:"line"`
+	Type    model.MediaType    `json:"type"`
+	Name    string             `json:"name"`
+	Action  items.ImportAction `json:"action"`
+	ItemID  int64              `json:"item_id,omitempty"`
+	Seasons []int              `json:"seasons,omitempty"`
+	Reason  string             `json:"reason,omitempty"`
+}
+
+type legacyImportJSON struct {
+	DryRun  bool               `json:"dry_run"`
+	Results []legacyResultJSON `json:"results"`
+	Errors  []string           `json:"errors,omitempty"` // State directories that could not be read
+}
+
+type legacyResultJSON struct {
+	Dir         string          `json:"dir"`
+	Stage       string          `json:"stage"`
+	Status      model.JobStatus `json:"status"`
+	Type        model.MediaType `json:"type"`
+	Name        string          `json:"name"`
+	Season      *int            `json:"season,omitempty"`
+	Disc        *int            `json:"disc,omitempty"`
+	Action      legacy.Action   `json:"action"`
+	ItemID      int64           `json:"item_id,omitempty"`
+	JobID       int64           `json:"job_id,omitempty"`
+	CreatedItem bool            `json:"created_item"`
+	Reason      string          `json:"reason,omitempty"`
+}
+
+func newItemJSON(item *model.MediaItem) itemJSON {
+	out := itemJSON{
+		ID:        item.ID,
+		Type:      item.Type,
+		Name:      item.Name,
+		SafeName:  item.SafeName,
+		Year:      item.Year,
+		TmdbID:    item.TmdbID,
+		TvdbID:    item.TvdbID,
+		Status:    item.ItemStatus,
+		Autopilot: item.Autopilot,
+	}
+	if item.Type == model.MediaTypeMovie {
+		out.Stage = item.CurrentStage.String()
+		out.StageStatus = item.StageStatus
+	}
+	for i := range item.Seasons {
+		out.Seasons = append(out.Seasons, newSeasonJSON(&item.Seasons[i]))
+	}
+	return out
+}
+
+func newSeasonJSON(season *model.Season) seasonJSON {
+	return seasonJSON{
+		ID:          season.ID,
+		Number:      season.Number,
+		Stage:       season.CurrentStage.String(),
+		StageStatus: season.StageStatus,
+		Autopilot:   season.Autopilot,
+		Discs:       season.DiscCount,
+	}
+}
+
+func newImportResultJSON(r items.ImportResult) importResultJSON {
+	return importResultJSON{
+		Line:    r.Entry.Line,
+		Type:    r.Entry.Type,
+		Name:    r.Entry.Name,
+		Action:  r.Action,
+		ItemID:  r.ItemID,
+		Seasons: r.Seasons,
+		Reason:  r.Reason,
+	}
+}
+
+func newJobJSON(job *model.Job) jobJSON {
+	runID := job.RunID
+	if runID == 0 {
+		runID = job.ID
+	}
+	return jobJSON{
+		ID:          job.ID,
+		ItemID:      job.MediaItemID,
+		SeasonID:    job.SeasonID,
+		Stage:       job.Stage.String(),
+		Disc:        job.Disc,
+		Status:      job.Status,
+		Progress:    job.Progress,
+		Priority:    job.Priority,
+		Attempt:     job.Attempt,
+		RunID:       runID,
+		Worker:      job.WorkerID,
+		PID:         job.PID,
+		InputDir:    job.InputDir,
+		OutputDir:   job.OutputDir,
+		Error:       job.ErrorMessage,
+		FailureKind: job.FailureKind,
+		NotBefore:   job.NotBefore,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+		CreatedAt:   job.CreatedAt,
+	}
+}
+
+func newTransitionJSON(t *model.Transition) transitionJSON {
+	return transitionJSON{
+		ID:       t.ID,
+		Kind:     t.Kind,
+		ItemID:   t.MediaItemID,
+		SeasonID: t.SeasonID,
+		JobID:    t.JobID,
+		From:     fmt.Sprintf("%s/%s", t.FromStage, t.FromStatus),
+		To:       fmt.Sprintf("%s/%s", t.ToStage, t.ToStatus),
+		Actor:    t.Actor,
+		At:       t.CreatedAt,
+	}
+}
+
+func newLegacyResultJSON(r legacy.Result) legacyResultJSON {
+	return legacyResultJSON{
+		Dir:         r.State.Path,
+		Stage:       r.State.Stage.String(),
+		Status:      r.State.Status,
+		Type:        r.State.Type,
+		Name:        r.State.Name,
+		Season:      r.State.Season,
+		Disc:        r.State.Disc,
+		Action:      r.Action,
+		ItemID:      r.ItemID,
+		JobID:       r.JobID,
+		CreatedItem: r.CreatedItem,
+		Reason:      r.Reason,
+	}
+}
+
+// stageLabel describes where a movie is, or how many seasons a show has
+func (i itemJSON) stageLabel() string {
+	if i.Type == model.MediaTypeTV {
+		return fmt.Sprintf("%d season(s)", len(i.Seasons))
+	}
+	return i.Stage
+}
+
+// statusLabel is the movie's stage status, or the show's item status
+func (i itemJSON) statusLabel() string {
+	if i.Type == model.MediaTypeTV {
+		return string(i.Status)
+	}
+	return string(i.StageStatus)
+}
+
+// writeJobTable writes one line per job
+func writeJobTable(w io.Writer, list []jobJSON) {
+	fmt.Fprintln(w, "JOB\tITEM\tSTAGE\tDISC\tSTATUS\tATTEMPT\tWORKER\tERROR")
+	for _, job := range list {
+		disc := "-"
+		if job.Disc != nil {
+			disc = fmt.Sprint(*job.Disc)
+		}
+		status := string(job.Status)
+		if job.Status == model.JobStatusInProgress {
+			status = fmt.Sprintf("%s %d%%", status, job.Progress)
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%d\t%s\t%s\n", job.ID, job.ItemID, job.Stage, disc, status, job.Attempt, job.Worker, job.Error)
+	}
+}
+
+// writeTransitionTable writes one line per transition
+func writeTransitionTable(w io.Writer, list []transitionJSON) {
+	fmt.Fprintln(w, "TIME\tKIND\tSEASON\tJOB\tFROM\tTO\tACTOR")
+	for _, t := range list {
+		season, job := "-", "-"
+		if t.SeasonID != nil {
+			season = fmt.Sprint(*t.SeasonID)
+		}
+		if t.JobID != nil {
+			job = fmt.Sprint(*t.JobID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.At.Local().Format(time.DateTime), t.Kind, season, job, t.From, t.To, t.Actor)
+	}
+}
+
+// writeImportTable writes one line per manifest entry and a summary
+func writeImportTable(w io.Writer, out importJSON) {
+	fmt.Fprintln(w, "LINE\tACTION\tTYPE\tNAME\tSEASONS\tNOTE")
+	counts := make(map[items.ImportAction]int)
+	for _, r := range out.Results {
+		seasons := "-"
+		if len(r.Seasons) > 0 {
+			seasons = strings.Trim(fmt.Sprint(r.Seasons), "[]")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", r.Line, r.Action, r.Type, r.Name, seasons, r.Reason)
+		counts[r.Action]++
+	}
+
+	verb := "Imported"
+	if out.DryRun {
+		verb = "Dry run, nothing written"
+	}
+	fmt.Fprintf(w, "\n%s: %d created, %d with new seasons, %d duplicates, %d conflicts\n", verb,
+		counts[items.ImportCreated], counts[items.ImportSeasonsAdded], counts[items.ImportDuplicate], counts[items.ImportConflict])
+}
+
+// writeLegacyImportTable writes one line per state directory, the ones that
+// could not be read, and a summary
+func writeLegacyImportTable(w io.Writer, out legacyImportJSON) {
+	fmt.Fprintln(w, "ACTION\tSTAGE\tSTATUS\tNAME\tSEASON\tDISC\tDIR")
+	counts := make(map[legacy.Action]int)
+	for _, r := range out.Results {
+		season, disc := "-", "-"
+		if r.Season != nil {
+			season = fmt.Sprint(*r.Season)
+		}
+		if r.Disc != nil {
+			disc = fmt.Sprint(*r.Disc)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Action, r.Stage, r.Status, r.Name, season, disc, r.Dir)
+		counts[r.Action]++
+	}
+	for _, e := range out.Errors {
+		fmt.Fprintf(w, "error\t\t\t\t\t\t%s\n", e)
+	}
+
+	verb := "Imported"
+	if out.DryRun {
+		verb = "Dry run, nothing written"
+	}
+	fmt.Fprintf(w, "\n%s: %d jobs imported, %d already recorded, %d conflicts, %d unreadable\n", verb,
+		counts[legacy.ActionImported], counts[legacy.ActionExists], counts[legacy.ActionConflict], len(out.Errors))
+}
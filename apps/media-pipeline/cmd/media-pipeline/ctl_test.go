@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func newTestCtl(t *testing.T) *ctl {
+	t.Helper()
+	t.Setenv("MEDIA_BASE", t.TempDir())
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return &ctl{cfg: &config.Config{}, database: database, repo: db.NewSQLiteRepository(database).WithActor("cli")}
+}
+
+// run runs a subcommand and returns what it printed
+func (c *ctl) run(t *testing.T, name string, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	c.out = &out
+	c.json = false
+	if err := ctlCommands[name](c, context.Background(), args); err != nil {
+		t.Fatalf("%s %v error = %v", name, args, err)
+	}
+	return out.String()
+}
+
+// runJSON runs a subcommand with -json and decodes its output into v
+func (c *ctl) runJSON(t *testing.T, v interface{}, name string, args ...string) {
+	t.Helper()
+	out := c.run(t, name, append(args, "-json")...)
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		t.Fatalf("%s output is not JSON: %v\n%s", name, err, out)
+	}
+}
+
+func TestCtl_MovieWorkflow(t *testing.T) {
+	c := newTestCtl(t)
+
+	var item itemJSON
+	c.runJSON(t, &item, "add-item", "-type", "movie", "-name", "The Matrix", "-id", "603")
+	if item.SafeName != "The_Matrix" || item.TmdbID == nil || *item.TmdbID != 603 {
+		t.Fatalf("add-item = %+v, want The_Matrix with TMDB 603", item)
+	}
+
+	// Items are found by name as well as ID; flags may follow the arguments
+	var job jobJSON
+	c.runJSON(t, &job, "start", "The Matrix", "rip")
+	if job.Stage != "rip" || job.Status != model.JobStatusPending {
+		t.Fatalf("start = %+v, want pending rip", job)
+	}
+
+	var queued []jobJSON
+	c.runJSON(t, &queued, "jobs")
+	if len(queued) != 1 || queued[0].ID != job.ID {
+		t.Fatalf("jobs = %+v, want the rip job", queued)
+	}
+
+	c.runJSON(t, &job, "cancel", jobRef(job))
+	if job.Status != model.JobStatusCancelled {
+		t.Fatalf("cancel = %+v, want cancelled", job)
+	}
+
+	var retried jobJSON
+	c.runJSON(t, &retried, "retry", jobRef(job))
+	if retried.Status != model.JobStatusPending || retried.Attempt != 2 || retried.RunID != job.ID {
+		t.Fatalf("retry = %+v, want pending attempt 2 of run %d", retried, job.ID)
+	}
+
+	var ripJobs []jobJSON
+	c.runJSON(t, &ripJobs, "jobs", "-stage", "rip")
+	if len(ripJobs) != 2 || ripJobs[0].Status != model.JobStatusCancelled || ripJobs[1].ID != retried.ID {
+		t.Errorf("jobs -stage rip = %+v, want the cancelled job and its retry", ripJobs)
+	}
+
+	var shown itemJSON
+	c.runJSON(t, &shown, "show", "The_Matrix")
+	if len(shown.Jobs) != 2 || shown.Stage != "rip" || shown.StageStatus != model.StatusInProgress {
+		t.Errorf("show = %+v, want rip in progress with 2 jobs", shown)
+	}
+
+	var history []transitionJSON
+	c.runJSON(t, &history, "history", "The Matrix")
+	if len(history) == 0 || history[0].Kind != model.TransitionItem || history[0].From != "rip/pending" || history[0].To != "rip/in_progress" {
+		t.Fatalf("history = %+v, want the item starting rip first", history)
+	}
+	for _, tr := range history {
+		if tr.Actor != "cli" {
+			t.Errorf("transition %d actor = %q, want cli", tr.ID, tr.Actor)
+		}
+	}
+
+	if out := c.run(t, "items"); !strings.Contains(out, "The Matrix") {
+		t.Errorf("items output = %q, want The Matrix listed", out)
+	}
+}
+
+func TestCtl_SeasonWorkflow(t *testing.T) {
+	c := newTestCtl(t)
+
+	var item itemJSON
+	c.runJSON(t, &item, "add-item", "-type", "tv", "-name", "Breaking Bad", "-seasons", "1-2")
+	if len(item.Seasons) != 2 {
+		t.Fatalf("add-item seasons = %+v, want 2", item.Seasons)
+	}
+
+	var season seasonJSON
+	c.runJSON(t, &season, "add-season", "Breaking Bad")
+	if season.Number != 3 {
+		t.Errorf("add-season number = %d, want 3", season.Number)
+	}
+
+	for want := 1; want <= 2; want++ {
+		var job jobJSON
+		c.runJSON(t, &job, "start", "-season", "2", "Breaking Bad", "rip")
+		if job.Disc == nil || *job.Disc != want {
+			t.Errorf("start disc = %v, want %d", job.Disc, want)
+		}
+	}
+
+	var out bytes.Buffer
+	c.out = &out
+	if err := c.start(context.Background(), []string{"Breaking Bad", "rip"}); err == nil {
+		t.Error("start without -season succeeded, want error")
+	}
+	if err := c.start(context.Background(), []string{"Breaking Bad", "rip", "-season", "9"}); err == nil {
+		t.Error("start with a missing season succeeded, want error")
+	}
+}
+
+func TestCtl_Log(t *testing.T) {
+	c := newTestCtl(t)
+	ctx := context.Background()
+
+	var item itemJSON
+	c.runJSON(t, &item, "add-item", "-name", "The Matrix")
+	var job jobJSON
+	c.runJSON(t, &job, "start", "The Matrix", "rip")
+	if _, err := c.repo.FailJob(ctx, job.ID, model.FailureKindStage, "no disc"); err != nil {
+		t.Fatalf("FailJob() error = %v", err)
+	}
+
+	path := c.cfg.JobLogPath(job.ID)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("one\ntwo\nthree\npartial"), 0644)
+
+	if got := c.run(t, "log", jobRef(job), "-n", "2"); got != "two\nthree\n" {
+		t.Errorf("log -n 2 = %q, want the last two complete lines", got)
+	}
+	// Follow stops once the job has finished
+	if got := c.run(t, "log", jobRef(job), "-n", "0", "-f"); got != "one\ntwo\nthree\n" {
+		t.Errorf("log -f = %q, want every complete line", got)
+	}
+
+	toolPath := c.cfg.ToolLogPath(job.ID, "makemkv")
+	os.WriteFile(toolPath, []byte("MSG:5010,0,0,\"Failed to open disc\"\n"), 0644)
+	if err := c.repo.AddToolLog(ctx, &model.ToolLog{JobID: job.ID, Tool: "makemkv", Path: toolPath}); err != nil {
+		t.Fatalf("AddToolLog() error = %v", err)
+	}
+	if got := c.run(t, "log", jobRef(job), "-tool", "makemkv"); !strings.Contains(got, "Failed to open disc") {
+		t.Errorf("log -tool makemkv = %q, want makemkv's output", got)
+	}
+	if err := c.log(ctx, []string{jobRef(job), "-tool", "ffmpeg"}); err == nil {
+		t.Error("log -tool ffmpeg succeeded for a job without an ffmpeg log")
+	}
+}
+
+func TestCtl_Import(t *testing.T) {
+	c := newTestCtl(t)
+
+	manifest := filepath.Join(t.TempDir(), "shelf.csv")
+	os.WriteFile(manifest, []byte("type,name,year,tmdb_id,tvdb_id,seasons,discs\n"+
+		"movie,The Matrix,1999,603,,,\n"+
+		"tv,Breaking Bad,2008,,81189,1-2,3\n"), 0644)
+
+	var dry importJSON
+	c.runJSON(t, &dry, "import", manifest, "-dry-run")
+	if !dry.DryRun || len(dry.Results) != 2 || dry.Results[0].Action != "created" {
+		t.Fatalf("import -dry-run = %+v, want two items to create", dry)
+	}
+	var list []itemJSON
+	c.runJSON(t, &list, "items")
+	if len(list) != 0 {
+		t.Fatalf("import -dry-run created %d items", len(list))
+	}
+
+	if got := c.run(t, "import", manifest); !strings.Contains(got, "2 created, 0 with new seasons, 0 duplicates") {
+		t.Errorf("import summary = %q, want 2 created", got)
+	}
+	var item itemJSON
+	c.runJSON(t, &item, "show", "Breaking Bad")
+	if item.Year == nil || *item.Year != 2008 || len(item.Seasons) != 2 || item.Seasons[1].Discs != 3 {
+		t.Errorf("show = %+v, want 2008 with two seasons of 3 discs", item)
+	}
+
+	// Importing again is a no-op
+	if got := c.run(t, "import", manifest); !strings.Contains(got, "0 created, 0 with new seasons, 2 duplicates") {
+		t.Errorf("import again summary = %q, want 2 duplicates", got)
+	}
+}
+
+func TestCtl_DatabaseExportImport(t *testing.T) {
+	c := newTestCtl(t)
+	c.run(t, "add-item", "-type", "movie", "-name", "The Matrix", "-id", "603")
+	c.run(t, "start", "The Matrix", "rip")
+
+	dir := t.TempDir()
+	var snapshot backupJSON
+	c.runJSON(t, &snapshot, "backup", "-dir", dir, "-keep", "1")
+	if filepath.Dir(snapshot.Path) != dir || snapshot.Size == 0 {
+		t.Errorf("backup = %+v, want a snapshot in %s", snapshot, dir)
+	}
+
+	export := filepath.Join(dir, "export.json")
+	c.run(t, "db-export", "-o", export)
+
+	restored := newTestCtl(t)
+	var counts dbImportJSON
+	restored.runJSON(t, &counts, "db-import", export)
+	if counts.MediaItems != 1 || counts.Jobs != 1 {
+		t.Errorf("db-import = %+v, want one item and one job", counts)
+	}
+	var jobs []jobJSON
+	restored.runJSON(t, &jobs, "jobs")
+	if len(jobs) != 1 || jobs[0].Stage != "rip" {
+		t.Errorf("jobs after db-import = %+v, want the rip job", jobs)
+	}
+
+	// A database with items refuses a second import
+	if err := ctlCommands["db-import"](restored, context.Background(), []string{export}); err == nil {
+		t.Error("db-import into a used database error = nil, want an error")
+	}
+}
+
+func TestCtl_Migrate(t *testing.T) {
+	c := newTestCtl(t)
+	migrate := func(name string, v interface{}, args ...string) {
+		t.Helper()
+		var out bytes.Buffer
+		c.out, c.json = &out, false
+		if err := migrateCommands[name](c, context.Background(), append(args, "-json")); err != nil {
+			t.Fatalf("db migrate %s error = %v", name, err)
+		}
+		if err := json.Unmarshal(out.Bytes(), v); err != nil {
+			t.Fatalf("db migrate %s output is not JSON: %v\n%s", name, err, out.String())
+		}
+	}
+
+	_, latest, err := c.database.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
+	}
+
+	var down migrationRunJSON
+	migrate("down", &down, "-steps", "1")
+	if len(down.Versions) != 1 || down.Versions[0] != latest {
+		t.Fatalf("db migrate down = %+v, want %s rolled back", down, latest)
+	}
+
+	var status []migrationJSON
+	migrate("status", &status)
+	last := status[len(status)-1]
+	if last.Version != latest || last.AppliedAt != "" || !last.Reversible {
+		t.Errorf("db migrate status last = %+v, want %s pending and reversible", last, latest)
+	}
+
+	var up migrationRunJSON
+	migrate("up", &up)
+	if len(up.Versions) != 1 || up.Versions[0] != latest {
+		t.Errorf("db migrate up = %+v, want %s applied", up, latest)
+	}
+}
+
+func jobRef(job jobJSON) string {
+	return strconv.FormatInt(job.ID, 10)
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 
@@ -12,6 +11,38 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "serve":
+			run = runServe
+		case "worker":
+			run = runWorker
+		case "agent":
+			run = runAgent
+		case "doctor":
+			run = runDoctor
+		case "db":
+			run = runDB
+		default:
+			if _, ok := ctlCommands[os.Args[1]]; ok {
+				name := os.Args[1]
+				run = func(args []string) error { return runCtl(name, args) }
+			}
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	runTUI()
+}
+
+func runTUI() {
 	// Load configuration
 	cfg, err := config.LoadFromMediaBase()
 	if err != nil {
@@ -29,13 +60,7 @@ func main() {
 	defer database.Close()
 
 	// Create repository
-	repo := db.NewSQLiteRepository(database)
-
-	// Run data migration for existing TV shows
-	if err := repo.MigrateToItemCentric(context.Background()); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: data migration failed: %v\n", err)
-		// Continue anyway - this isn't fatal
-	}
+	repo := db.NewSQLiteRepository(database).WithActor("tui")
 
 	// Create the app
 	app := tui.NewApp(cfg, repo)
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cuivienor/media-pipeline/internal/agent"
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/daemon"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/logging"
+	"github.com/cuivienor/media-pipeline/internal/queue"
+	"github.com/cuivienor/media-pipeline/internal/workers"
+)
+
+// runServe runs the pipeline daemon until interrupted
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	interval := fs.Duration("interval", daemon.DefaultPollInterval, "How often to poll for pending jobs")
+	fs.Parse(args)
+
+	cfg, err := config.LoadFromMediaBase()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := db.Open(cfg.DatabasePath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	repo := db.NewSQLiteRepository(database).WithActor(jobs.LocalWorkerID())
+
+	logger := logging.New(logging.Options{
+		Stdout:   os.Stdout,
+		MinLevel: logging.LevelInfo,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The daemon host is a worker too: stages without a dispatch target run here
+	stopHeartbeat := workers.NewHeartbeat(repo, "").Start(ctx)
+	defer stopHeartbeat()
+
+	var launcher daemon.Launcher = daemon.NewExecLauncher(cfg)
+	if cfg.Agent.Enabled {
+		launcher = agent.NewLauncher(repo, cfg, launcher, logger)
+	}
+
+	d := daemon.New(repo, launcher, logger, daemon.Options{
+		PollInterval: *interval,
+		Limits:       queue.LimitsFromConfig(cfg),
+		Windows:      queue.WindowsFromConfig(cfg),
+		Retry:        jobs.RetryPoliciesFromConfig(cfg),
+		Router:       workers.NewRouter(repo, cfg),
+	})
+	return d.Run(ctx)
+}
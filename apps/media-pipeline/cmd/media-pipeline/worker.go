@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/workers"
+)
+
+// runWorker heartbeats this host into the worker registry until interrupted.
+// Run it on every dispatch target so the daemon knows the host is up; with
+// -target, stages can also be rerouted to this host.
+func runWorker(args []string) error {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	target := fs.String("target", "", "How the daemon reaches this host: SSH target (user@host) or agent address")
+	fs.Parse(args)
+
+	cfg, err := config.LoadFromMediaBase()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := db.Open(cfg.DatabasePath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	repo := db.NewSQLiteRepository(database).WithActor(jobs.LocalWorkerID())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	heartbeat := workers.NewHeartbeat(repo, *target)
+	names := make([]string, len(heartbeat.Capabilities()))
+	for i, c := range heartbeat.Capabilities() {
+		names[i] = string(c)
+	}
+	fmt.Printf("Worker %s reporting capabilities: %s\n", jobs.LocalWorkerID(), strings.Join(names, ", "))
+
+	stopHeartbeat := heartbeat.Start(ctx)
+	<-ctx.Done()
+	stopHeartbeat()
+	return nil
+}
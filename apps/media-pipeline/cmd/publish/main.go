@@ -5,37 +5,50 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/cuivienor/media-pipeline/internal/config"
 	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/diskspace"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
 	"github.com/cuivienor/media-pipeline/internal/logging"
 	"github.com/cuivienor/media-pipeline/internal/model"
 	"github.com/cuivienor/media-pipeline/internal/publish"
+	"github.com/cuivienor/media-pipeline/internal/workers"
 )
 
 func main() {
 	var jobID int64
 	var dbPath string
+	var inputDir string
 
 	flag.Int64Var(&jobID, "job-id", 0, "Job ID to execute")
 	flag.StringVar(&dbPath, "db", "", "Path to database")
+	flag.StringVar(&inputDir, "input-dir", "", "Input directory (default: output of the last completed transcode job)")
 	flag.Parse()
 
 	if jobID == 0 || dbPath == "" {
-		fmt.Fprintln(os.Stderr, "Usage: publish -job-id <id> -db <path>")
+		fmt.Fprintln(os.Stderr, "Usage: publish -job-id <id> -db <path> [-input-dir <dir>]")
 		os.Exit(1)
 	}
 
-	if err := run(jobID, dbPath); err != nil {
+	if err := run(jobID, dbPath, inputDir); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run(jobID int64, dbPath string) error {
+func run(jobID int64, dbPath string, inputDir string) error {
 	ctx := context.Background()
 
+	// runCtx is cancelled on SIGINT/SIGTERM, e.g. when the job is cancelled
+	// from the TUI. Database writes keep using ctx so the outcome is still
+	// recorded after cancellation.
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Open database
 	database, err := db.Open(dbPath)
 	if err != nil {
@@ -43,14 +56,21 @@ func run(jobID int64, dbPath string) error {
 	}
 	defer database.Close()
 
-	repo := db.NewSQLiteRepository(database)
+	repo := db.NewSQLiteRepository(database).WithActor(jobs.LocalWorkerID())
 
-	// Helper to mark job as failed
-	markFailed := func(errMsg string) {
-		if updateErr := repo.UpdateJobStatus(ctx, jobID, model.JobStatusFailed, errMsg); updateErr != nil {
+	// Helper to mark job as failed, or cancelled if we were signalled to stop
+	markFailedAs := func(kind model.FailureKind, errMsg string) {
+		var updateErr error
+		if runCtx.Err() != nil {
+			updateErr = repo.UpdateJobStatus(ctx, jobID, model.JobStatusCancelled, "cancelled")
+		} else {
+			_, updateErr = repo.FailJob(ctx, jobID, kind, errMsg)
+		}
+		if updateErr != nil {
 			fmt.Fprintf(os.Stderr, "Failed to update job status: %v\n", updateErr)
 		}
 	}
+	markFailed := func(errMsg string) { markFailedAs(model.FailureKindStage, errMsg) }
 
 	// Get job
 	job, err := repo.GetJob(ctx, jobID)
@@ -85,67 +105,89 @@ func run(jobID int64, dbPath string) error {
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
 	logPath := cfg.JobLogPath(jobID)
-	logger, err := logging.NewForJob(logPath, true, nil)
+	logger, err := logging.NewForJob(logPath, true, jobs.LogEvents(ctx, repo, jobID))
 	if err != nil {
 		markFailed(err.Error())
 		return fmt.Errorf("failed to create logger: %w", err)
 	}
 	defer logger.Close()
 
-	logger.Info("Starting publish: type=%s name=%q dbID=%d", item.Type, item.Name, item.DatabaseID())
+	logger.Event(logging.LevelInfo, fmt.Sprintf("Starting publish: type=%s name=%q dbID=%d", item.Type, item.Name, item.DatabaseID()))
 
-	// Find input directory from transcode job
-	inputDir, err := findTranscodeOutput(ctx, repo, job)
-	if err != nil {
-		logger.Error("Failed to find input: %v", err)
-		markFailed(err.Error())
-		return fmt.Errorf("failed to find input: %w", err)
+	// Find input directory from the transcode job unless it was given
+	if inputDir == "" {
+		inputDir, err = jobs.InputDir(ctx, repo, job)
+		if err != nil {
+			logger.Error("Failed to find input: %v", err)
+			markFailed(err.Error())
+			return fmt.Errorf("failed to find input: %w", err)
+		}
 	}
 
 	logger.Info("Input directory: %s", inputDir)
 
 	// Update job to in_progress
 	job.Status = model.JobStatusInProgress
+	if job.WorkerID == "" {
+		// Not claimed by the daemon, which records where it dispatched the job
+		job.WorkerID = jobs.LocalWorkerID()
+	}
+	job.PID = os.Getpid()
 	job.InputDir = inputDir
 	now := time.Now()
 	job.StartedAt = &now
-	if err := repo.UpdateJob(ctx, job); err != nil {
+	if err := repo.StartJob(ctx, job.ID, job.WorkerID, job.PID, job.InputDir, job.OutputDir); err != nil {
 		markFailed(err.Error())
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
 
+	// Report this host as busy with the job until the stage exits
+	stopHeartbeat := workers.NewHeartbeat(repo, "").Start(ctx)
+	defer stopHeartbeat()
+
+	// Refuse to start if the library can't take a copy of the input
+	libraryDir := cfg.LibraryMoviesPath()
+	if item.Type == model.MediaTypeTV {
+		libraryDir = cfg.LibraryTVPath()
+	}
+	estimate, err := diskspace.Estimate(inputDir, 1)
+	if err == nil {
+		err = diskspace.Check(libraryDir, estimate)
+	}
+	if err != nil {
+		logger.Error("Preflight failed: %v", err)
+		markFailedAs(diskspace.FailureKind(err), err.Error())
+		return err
+	}
+
 	// Create publisher
 	opts := publish.PublishOptions{
 		LibraryMovies: cfg.LibraryMoviesPath(),
 		LibraryTV:     cfg.LibraryTVPath(),
 	}
 	publisher := publish.NewPublisher(repo, logger, opts)
+	publisher.SetToolLogs(jobs.ToolLogs(ctx, repo, cfg, jobID))
 
 	// Execute publish
-	result, err := publisher.Publish(ctx, item, inputDir)
+	result, err := publisher.Publish(runCtx, item, inputDir)
 	if err != nil {
 		logger.Error("Publish failed: %v", err)
 		markFailed(err.Error())
 		return err
 	}
 
-	logger.Info("Published to: %s", result.LibraryPath)
+	logger.Event(logging.LevelInfo, fmt.Sprintf("Published to: %s", result.LibraryPath))
 	logger.Info("Main files: %d, Extras: %d", result.MainFiles, result.ExtrasFiles)
 
 	// Update job output directory
 	job.OutputDir = result.LibraryPath
-	if err := repo.UpdateJob(ctx, job); err != nil {
+	if err := repo.SetJobOutputDir(ctx, job.ID, job.OutputDir); err != nil {
 		logger.Error("Failed to update job output: %v", err)
 	}
 
-	// Mark job as complete
-	if err := repo.UpdateJobStatus(ctx, jobID, model.JobStatusCompleted, ""); err != nil {
-		return fmt.Errorf("failed to update job status: %w", err)
-	}
-
-	// Update media item stage
-	if err := repo.UpdateMediaItemStage(ctx, item.ID, model.StagePublish, model.StatusCompleted); err != nil {
-		return fmt.Errorf("failed to update item stage: %w", err)
+	// Mark job as complete, and its item or season with it
+	if err := jobs.Finish(ctx, repo, job, model.JobStatusCompleted, ""); err != nil {
+		return err
 	}
 
 	// Update item status to completed
@@ -153,26 +195,6 @@ func run(jobID int64, dbPath string) error {
 		logger.Error("Failed to update item status: %v", err)
 	}
 
-	logger.Info("Publish finished successfully")
+	logger.Event(logging.LevelInfo, "Publish finished successfully")
 	return nil
 }
-
-// findTranscodeOutput finds the output directory from the transcode stage
-func findTranscodeOutput(ctx context.Context, repo db.Repository, job *model.Job) (string, error) {
-	jobs, err := repo.ListJobsForMedia(ctx, job.MediaItemID)
-	if err != nil {
-		return "", err
-	}
-
-	// Find the most recent completed transcode job
-	for i := len(jobs) - 1; i >= 0; i-- {
-		j := jobs[i]
-		if j.Stage == model.StageTranscode && j.Status == model.JobStatusCompleted {
-			if j.OutputDir != "" {
-				return j.OutputDir, nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("no completed transcode job found for media item %d", job.MediaItemID)
-}
@@ -5,38 +5,51 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/cuivienor/media-pipeline/internal/config"
 	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/diskspace"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
 	"github.com/cuivienor/media-pipeline/internal/logging"
 	"github.com/cuivienor/media-pipeline/internal/model"
 	"github.com/cuivienor/media-pipeline/internal/remux"
+	"github.com/cuivienor/media-pipeline/internal/workers"
 )
 
 func main() {
 	var jobID int64
 	var dbPath string
+	var inputDir string
 
 	flag.Int64Var(&jobID, "job-id", 0, "Job ID to execute")
 	flag.StringVar(&dbPath, "db", "", "Path to database")
+	flag.StringVar(&inputDir, "input-dir", "", "Input directory (default: output of the last completed organize job)")
 	flag.Parse()
 
 	if jobID == 0 || dbPath == "" {
-		fmt.Fprintln(os.Stderr, "Usage: remux -job-id <id> -db <path>")
+		fmt.Fprintln(os.Stderr, "Usage: remux -job-id <id> -db <path> [-input-dir <dir>]")
 		os.Exit(1)
 	}
 
-	if err := run(jobID, dbPath); err != nil {
+	if err := run(jobID, dbPath, inputDir); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run(jobID int64, dbPath string) error {
+func run(jobID int64, dbPath string, inputDir string) error {
 	ctx := context.Background()
 
+	// runCtx is cancelled on SIGINT/SIGTERM, e.g. when the job is cancelled
+	// from the TUI. Database writes keep using ctx so the outcome is still
+	// recorded after cancellation.
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Open database
 	database, err := db.Open(dbPath)
 	if err != nil {
@@ -44,14 +57,21 @@ func run(jobID int64, dbPath string) error {
 	}
 	defer database.Close()
 
-	repo := db.NewSQLiteRepository(database)
+	repo := db.NewSQLiteRepository(database).WithActor(jobs.LocalWorkerID())
 
-	// Helper to mark job as failed
-	markFailed := func(errMsg string) {
-		if updateErr := repo.UpdateJobStatus(ctx, jobID, model.JobStatusFailed, errMsg); updateErr != nil {
+	// Helper to mark job as failed, or cancelled if we were signalled to stop
+	markFailedAs := func(kind model.FailureKind, errMsg string) {
+		var updateErr error
+		if runCtx.Err() != nil {
+			updateErr = repo.UpdateJobStatus(ctx, jobID, model.JobStatusCancelled, "cancelled")
+		} else {
+			_, updateErr = repo.FailJob(ctx, jobID, kind, errMsg)
+		}
+		if updateErr != nil {
 			fmt.Fprintf(os.Stderr, "Failed to update job status: %v\n", updateErr)
 		}
 	}
+	markFailed := func(errMsg string) { markFailedAs(model.FailureKindStage, errMsg) }
 
 	// Get job
 	job, err := repo.GetJob(ctx, jobID)
@@ -79,21 +99,23 @@ func run(jobID int64, dbPath string) error {
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
 	logPath := cfg.JobLogPath(jobID)
-	logger, err := logging.NewForJob(logPath, true, nil)
+	logger, err := logging.NewForJob(logPath, true, jobs.LogEvents(ctx, repo, jobID))
 	if err != nil {
 		markFailed(err.Error())
 		return fmt.Errorf("failed to create logger: %w", err)
 	}
 	defer logger.Close()
 
-	logger.Info("Starting remux: type=%s name=%q", item.Type, item.Name)
+	logger.Event(logging.LevelInfo, fmt.Sprintf("Starting remux: type=%s name=%q", item.Type, item.Name))
 
-	// Find input directory from organize job
-	inputDir, err := findOrganizeOutput(ctx, repo, job)
-	if err != nil {
-		logger.Error("Failed to find input: %v", err)
-		markFailed(err.Error())
-		return fmt.Errorf("failed to find input: %w", err)
+	// Find input directory from the organize job unless it was given
+	if inputDir == "" {
+		inputDir, err = jobs.InputDir(ctx, repo, job)
+		if err != nil {
+			logger.Error("Failed to find input: %v", err)
+			markFailed(err.Error())
+			return fmt.Errorf("failed to find input: %w", err)
+		}
 	}
 
 	// Determine output directory
@@ -110,22 +132,45 @@ func run(jobID int64, dbPath string) error {
 
 	// Update job to in_progress with input/output paths
 	job.Status = model.JobStatusInProgress
+	if job.WorkerID == "" {
+		// Not claimed by the daemon, which records where it dispatched the job
+		job.WorkerID = jobs.LocalWorkerID()
+	}
+	job.PID = os.Getpid()
 	job.InputDir = inputDir
 	job.OutputDir = outputDir
 	now := time.Now()
 	job.StartedAt = &now
-	if err := repo.UpdateJob(ctx, job); err != nil {
+	if err := repo.StartJob(ctx, job.ID, job.WorkerID, job.PID, job.InputDir, job.OutputDir); err != nil {
 		markFailed(err.Error())
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
 
+	// Report this host as busy with the job until the stage exits
+	stopHeartbeat := workers.NewHeartbeat(repo, "").Start(ctx)
+	defer stopHeartbeat()
+
+	// Refuse to start if the output won't fit: remux only drops tracks, so
+	// it is at most the size of the input
+	estimate, err := diskspace.Estimate(inputDir, 1)
+	if err == nil {
+		err = diskspace.Preflight(outputDir, estimate)
+	}
+	if err != nil {
+		logger.Error("Preflight failed: %v", err)
+		markFailedAs(diskspace.FailureKind(err), err.Error())
+		return err
+	}
+	logger.Info("Estimated output size: %s", diskspace.FormatBytes(estimate))
+
 	// Create remuxer and process
 	remuxer := remux.NewRemuxer(cfg.RemuxLanguages())
+	remuxer.SetToolLogs(jobs.ToolLogs(ctx, repo, cfg, jobID))
 	isTV := item.Type == model.MediaTypeTV
 
 	logger.Info("Starting track filtering...")
 
-	results, err := remuxer.RemuxDirectory(ctx, inputDir, outputDir, isTV)
+	results, err := remuxer.RemuxDirectory(runCtx, inputDir, outputDir, isTV)
 	if err != nil {
 		logger.Error("Remux failed: %v", err)
 		markFailed(err.Error())
@@ -135,50 +180,24 @@ func run(jobID int64, dbPath string) error {
 	// Log results
 	totalRemoved := 0
 	for _, r := range results {
-		logger.Info("Processed: %s (input: %d audio, %d subs -> output: %d audio, %d subs, %d tracks removed)",
+		logger.Event(logging.LevelInfo, fmt.Sprintf("Processed: %s (input: %d audio, %d subs -> output: %d audio, %d subs, %d tracks removed)",
 			filepath.Base(r.InputPath),
 			r.InputTracks.Audio, r.InputTracks.Subtitles,
 			r.OutputTracks.Audio, r.OutputTracks.Subtitles,
-			r.TracksRemoved)
+			r.TracksRemoved))
 		totalRemoved += r.TracksRemoved
 	}
 	logger.Info("Total: %d files processed, %d tracks removed", len(results), totalRemoved)
 
-	// Mark job as complete
-	if err := repo.UpdateJobStatus(ctx, jobID, model.JobStatusCompleted, ""); err != nil {
-		return fmt.Errorf("failed to update job status: %w", err)
-	}
-
-	// Update media item stage
-	if err := repo.UpdateMediaItemStage(ctx, item.ID, model.StageRemux, model.StatusCompleted); err != nil {
-		return fmt.Errorf("failed to update item stage: %w", err)
+	// Mark job as complete, and its item or season with it
+	if err := jobs.Finish(ctx, repo, job, model.JobStatusCompleted, ""); err != nil {
+		return err
 	}
 
-	logger.Info("Remux finished successfully")
+	logger.Event(logging.LevelInfo, "Remux finished successfully")
 	return nil
 }
 
-// findOrganizeOutput finds the output directory from the organize stage
-func findOrganizeOutput(ctx context.Context, repo db.Repository, job *model.Job) (string, error) {
-	// Look for completed organize job for this media item
-	jobs, err := repo.ListJobsForMedia(ctx, job.MediaItemID)
-	if err != nil {
-		return "", err
-	}
-
-	// Find the most recent completed organize job
-	for i := len(jobs) - 1; i >= 0; i-- {
-		j := jobs[i]
-		if j.Stage == model.StageOrganize && j.Status == model.JobStatusCompleted {
-			if j.OutputDir != "" {
-				return j.OutputDir, nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("no completed organize job found for media item %d", job.MediaItemID)
-}
-
 // buildOutputPath constructs the output directory for remuxed files
 func buildOutputPath(ctx context.Context, repo db.Repository, cfg *config.Config, item *model.MediaItem, job *model.Job) (string, error) {
 	// Output goes to staging/2-remuxed/{movies,tv}/{safe_name}
@@ -5,13 +5,19 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
+	"github.com/cuivienor/media-pipeline/internal/config"
 	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/diskspace"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
 	"github.com/cuivienor/media-pipeline/internal/logging"
 	"github.com/cuivienor/media-pipeline/internal/model"
 	"github.com/cuivienor/media-pipeline/internal/ripper"
+	"github.com/cuivienor/media-pipeline/internal/workers"
 )
 
 const defaultMediaBase = "/mnt/media"
@@ -40,6 +46,12 @@ func main() {
 func run(jobID int64, dbPath string, discPath string) error {
 	ctx := context.Background()
 
+	// runCtx is cancelled on SIGINT/SIGTERM, e.g. when the job is cancelled
+	// from the TUI. Database writes keep using ctx so the outcome is still
+	// recorded after cancellation.
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Get config from environment
 	mediaBase := os.Getenv("MEDIA_BASE")
 	if mediaBase == "" {
@@ -54,14 +66,21 @@ func run(jobID int64, dbPath string, discPath string) error {
 	}
 	defer database.Close()
 
-	repo := db.NewSQLiteRepository(database)
+	repo := db.NewSQLiteRepository(database).WithActor(jobs.LocalWorkerID())
 
-	// Helper to mark job as failed
-	markFailed := func(errMsg string) {
-		if updateErr := repo.UpdateJobStatus(ctx, jobID, model.JobStatusFailed, errMsg); updateErr != nil {
+	// Helper to mark job as failed, or cancelled if we were signalled to stop
+	markFailedAs := func(kind model.FailureKind, errMsg string) {
+		var updateErr error
+		if runCtx.Err() != nil {
+			updateErr = repo.UpdateJobStatus(ctx, jobID, model.JobStatusCancelled, "cancelled")
+		} else {
+			_, updateErr = repo.FailJob(ctx, jobID, kind, errMsg)
+		}
+		if updateErr != nil {
 			fmt.Fprintf(os.Stderr, "Failed to update job status: %v\n", updateErr)
 		}
 	}
+	markFailed := func(errMsg string) { markFailedAs(model.FailureKindStage, errMsg) }
 
 	// Get job
 	job, err := repo.GetJob(ctx, jobID)
@@ -83,22 +102,23 @@ func run(jobID int64, dbPath string, discPath string) error {
 		return fmt.Errorf("failed to build rip request: %w", err)
 	}
 
-	// Set up logging
-	logDir := filepath.Join(mediaBase, "pipeline", "logs", "jobs", fmt.Sprintf("%d", jobID))
-	if err := os.MkdirAll(logDir, 0755); err != nil {
+	// Set up logging. The ripper reads no config file, but keeps its logs
+	// where the other stages do: under MEDIA_BASE.
+	cfg := &config.Config{}
+	if err := cfg.EnsureJobLogDir(jobID); err != nil {
 		markFailed(err.Error())
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
-	logPath := filepath.Join(logDir, "job.log")
+	logPath := cfg.JobLogPath(jobID)
 
-	logger, err := logging.NewForJob(logPath, true, nil)
+	logger, err := logging.NewForJob(logPath, true, jobs.LogEvents(ctx, repo, jobID))
 	if err != nil {
 		markFailed(err.Error())
 		return fmt.Errorf("failed to create logger: %w", err)
 	}
 	defer logger.Close()
 
-	logger.Info("Starting rip: type=%s name=%q", item.Type, item.Name)
+	logger.Event(logging.LevelInfo, fmt.Sprintf("Starting rip: type=%s name=%q", item.Type, item.Name))
 	if item.Type == model.MediaTypeTV {
 		logger.Info("TV show: season=%d disc=%d", req.Season, req.Disc)
 	}
@@ -110,52 +130,70 @@ func run(jobID int64, dbPath string, discPath string) error {
 
 	// Update job to in_progress
 	job.Status = model.JobStatusInProgress
+	if job.WorkerID == "" {
+		// Not claimed by the daemon, which records where it dispatched the job
+		job.WorkerID = jobs.LocalWorkerID()
+	}
+	job.PID = os.Getpid()
 	job.OutputDir = outputDir
 	now := time.Now()
 	job.StartedAt = &now
-	if err := repo.UpdateJob(ctx, job); err != nil {
+	if err := repo.StartJob(ctx, job.ID, job.WorkerID, job.PID, job.InputDir, job.OutputDir); err != nil {
 		markFailed(err.Error())
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
 
+	// Report this host as busy with the job until the stage exits
+	stopHeartbeat := workers.NewHeartbeat(repo, "").Start(ctx)
+	defer stopHeartbeat()
+
 	// Create ripper and run
 	runner := ripper.NewMakeMKVRunner(makeMKVConPath)
-	r := ripper.NewRipper(stagingBase, runner, &loggerAdapter{logger})
+	runner.SetToolLogs(jobs.ToolLogs(ctx, repo, cfg, jobID))
 
-	// Create callbacks for line logging and progress updates
-	onLine := func(line string) {
-		// Log raw MakeMKV output to the job log
-		logger.Info("[makemkv] %s", line)
+	// Refuse to start if the titles on the disc won't fit
+	info, err := runner.GetDiscInfo(runCtx, req.DiscPath)
+	if err != nil {
+		logger.Error("Failed to read disc info: %v", err)
+		markFailed(err.Error())
+		return fmt.Errorf("failed to read disc info: %w", err)
+	}
+	estimate := discSize(info)
+	if err := diskspace.Preflight(outputDir, estimate); err != nil {
+		logger.Error("Preflight failed: %v", err)
+		markFailedAs(diskspace.FailureKind(err), err.Error())
+		return err
 	}
+	logger.Info("Estimated output size: %s (%d titles)", diskspace.FormatBytes(estimate), len(info.Titles))
+	r := ripper.NewRipper(stagingBase, runner, &loggerAdapter{logger})
 
+	// Create callback for progress updates. Raw MakeMKV output goes to
+	// makemkv.log rather than the job log.
 	lastProgress := 0
 	onProgress := func(p ripper.Progress) {
 		percent := int(p.Percent)
 		// Only update on 1% increments to avoid excessive DB writes
 		if percent > lastProgress {
 			lastProgress = percent
-			repo.UpdateJobProgress(ctx, jobID, percent)
+			if err := repo.UpdateJobProgress(ctx, jobID, percent); err != nil {
+				logger.Warn("Failed to record progress: %v", err)
+			}
 		}
 	}
 
-	result, err := r.Rip(ctx, req, outputDir, onLine, onProgress)
+	result, err := r.Rip(runCtx, req, outputDir, nil, onProgress)
 	if err != nil {
 		logger.Error("Rip failed: %v", err)
 		markFailed(err.Error())
 		return err
 	}
 
-	// Mark job as complete
-	if err := repo.UpdateJobStatus(ctx, jobID, model.JobStatusCompleted, ""); err != nil {
-		return fmt.Errorf("failed to update job status: %w", err)
-	}
-
-	// Update media item stage
-	if err := repo.UpdateMediaItemStage(ctx, item.ID, model.StageRip, model.StatusCompleted); err != nil {
-		return fmt.Errorf("failed to update item stage: %w", err)
+	// Mark job as complete, and its item or season with it
+	if err := jobs.Finish(ctx, repo, job, model.JobStatusCompleted, ""); err != nil {
+		return err
 	}
 
-	logger.Info("Rip finished successfully in %s", result.Duration())
+	logger.Event(logging.LevelInfo, fmt.Sprintf("Rip finished successfully in %s", result.Duration()))
 	return nil
 }
 
@@ -196,6 +234,15 @@ func buildRipRequest(ctx context.Context, repo db.Repository, job *model.Job, it
 	return req, nil
 }
 
+// discSize returns the total size of the titles on a disc, all of which are ripped
+func discSize(info *ripper.DiscInfo) int64 {
+	var total int64
+	for _, title := range info.Titles {
+		total += title.Size
+	}
+	return total
+}
+
 // buildOutputDir constructs the output directory path
 func buildOutputDir(stagingBase string, req *ripper.RipRequest) string {
 	safeName := req.SafeName()
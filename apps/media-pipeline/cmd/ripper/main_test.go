@@ -255,3 +255,13 @@ func TestBuildOutputDir_TVShow(t *testing.T) {
 		t.Errorf("outputDir = %q, want %q", outputDir, expected)
 	}
 }
+
+func TestDiscSize(t *testing.T) {
+	info := &ripper.DiscInfo{Titles: []ripper.TitleInfo{
+		{Index: 0, Size: 30 << 30},
+		{Index: 1, Size: 2 << 30},
+	}}
+	if got, want := discSize(info), int64(32<<30); got != want {
+		t.Errorf("discSize() = %d, want %d", got, want)
+	}
+}
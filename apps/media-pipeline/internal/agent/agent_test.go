@@ -0,0 +1,370 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// helperModeEnv selects what TestHelperStage does when run as a stage binary
+const helperModeEnv = "AGENT_TEST_HELPER_STAGE"
+
+// TestHelperStage stands in for a stage binary. It is not a real test: it
+// only runs when started by a test server with helperModeEnv set.
+func TestHelperStage(t *testing.T) {
+	mode := os.Getenv(helperModeEnv)
+	if mode == "" {
+		return
+	}
+
+	var jobID int64
+	var dbPath, inputDir string
+	args := os.Args
+	for i, arg := range args {
+		if i+1 >= len(args) {
+			break
+		}
+		switch arg {
+		case "-job-id":
+			jobID, _ = strconv.ParseInt(args[i+1], 10, 64)
+		case "-db":
+			dbPath = args[i+1]
+		case "-input-dir":
+			inputDir = args[i+1]
+		}
+	}
+	if err := runHelperStage(mode, jobID, dbPath, inputDir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runHelperStage does what the stage binaries do with their database: find
+// the input, record the job, its transcode files and the item's status
+func runHelperStage(mode string, jobID int64, dbPath, inputDir string) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM)
+
+	ctx := context.Background()
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	repo := db.NewSQLiteRepository(database)
+
+	job, err := repo.GetJob(ctx, jobID)
+	if err != nil || job == nil {
+		return fmt.Errorf("job %d not found: %v", jobID, err)
+	}
+	if inputDir == "" {
+		if inputDir, err = jobs.InputDir(ctx, repo, job); err != nil {
+			repo.FailJob(ctx, jobID, model.FailureKindStage, err.Error())
+			return err
+		}
+	}
+
+	now := time.Now()
+	job.Status = model.JobStatusInProgress
+	job.PID = os.Getpid()
+	job.InputDir = inputDir
+	job.StartedAt = &now
+	if err := repo.UpdateJob(ctx, job); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s [INFO] starting job %d\n", now.Format("2006-01-02 15:04:05"), jobID)
+	fmt.Printf("%s [WARN] no subtitles found\n", now.Format("2006-01-02 15:04:05"))
+	if err := repo.UpdateJobProgress(ctx, jobID, 50); err != nil {
+		return err
+	}
+
+	if mode == "wait" {
+		<-sigs
+		return repo.UpdateJobStatus(ctx, jobID, model.JobStatusCancelled, "cancelled")
+	}
+
+	file := &model.TranscodeFile{JobID: jobID, RelativePath: "movie.mkv", Status: model.TranscodeFileStatusPending, InputSize: 100}
+	if err := repo.CreateTranscodeFile(ctx, file); err != nil {
+		return err
+	}
+	file.Status = model.TranscodeFileStatusCompleted
+	file.OutputSize = 40
+	file.Progress = 100
+	if err := repo.UpdateTranscodeFile(ctx, file); err != nil {
+		return err
+	}
+	if err := repo.UpdateMediaItemStatus(ctx, job.MediaItemID, model.ItemStatusCompleted); err != nil {
+		return err
+	}
+	return repo.UpdateJobStatus(ctx, jobID, model.JobStatusCompleted, "")
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// newTestAgent starts an agent whose stage binaries are TestHelperStage and
+// returns its host:port target
+func newTestAgent(t *testing.T, mode string) string {
+	t.Helper()
+	s := NewServer()
+	s.snapshotInterval = 20 * time.Millisecond
+	s.command = func(ctx context.Context, stage model.Stage, args []string) (*exec.Cmd, error) {
+		cmd := exec.CommandContext(ctx, os.Args[0], append([]string{"-test.run=^TestHelperStage$", "--"}, args...)...)
+		cmd.Env = append(os.Environ(), helperModeEnv+"="+mode)
+		return cmd, nil
+	}
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func newTestCoordinator(t *testing.T) (*db.SQLiteRepository, *config.Config) {
+	t.Helper()
+	base := t.TempDir()
+	t.Setenv("MEDIA_BASE", base)
+
+	database, err := db.Open(filepath.Join(base, "pipeline.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	cfg := &config.Config{Agent: config.AgentConfig{Enabled: true}}
+	return db.NewSQLiteRepository(database), cfg
+}
+
+// createTestJob creates a pending job whose input is the output of a
+// completed job for the stage before it
+func createTestJob(t *testing.T, repo db.Repository, name string, stage model.Stage) *model.Job {
+	t.Helper()
+	ctx := context.Background()
+	item := &model.MediaItem{Type: model.MediaTypeMovie, Name: name, SafeName: strings.ReplaceAll(name, " ", "_")}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	for _, prev := range []model.Stage{model.StageRemux, model.StageTranscode} {
+		if prev == stage {
+			break
+		}
+		done := &model.Job{MediaItemID: item.ID, Stage: prev, Status: model.JobStatusCompleted, OutputDir: "/staging/" + prev.String() + "/" + item.SafeName}
+		if err := repo.CreateJob(ctx, done); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+	}
+	job := &model.Job{MediaItemID: item.ID, Stage: stage, Status: model.JobStatusPending}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if err := repo.SetJobOptions(ctx, job.ID, map[string]interface{}{"mode": "test"}); err != nil {
+		t.Fatalf("SetJobOptions() error = %v", err)
+	}
+	// As the daemon passes it: claimed in the database, pending in memory
+	if claimed, err := repo.ClaimJob(ctx, job.ID, "coordinator", 0, 0); err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+	return job
+}
+
+// launch runs a job in the background; it is cancelled when the test ends
+// so a failed test cannot leave an agent handler streaming
+func launch(t *testing.T, l *Launcher, job *model.Job, target string) <-chan error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		done <- l.Launch(ctx, job, target)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-returned:
+		case <-time.After(10 * time.Second):
+			t.Errorf("Launch() of job %d did not return after cancel", job.ID)
+		}
+	})
+	return done
+}
+
+// wait returns the result of a launch
+func wait(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("Launch() did not return")
+		return nil
+	}
+}
+
+// startedRepo reports the first time a job is recorded with a PID
+type startedRepo struct {
+	db.Repository
+	once    sync.Once
+	started chan model.Job
+}
+
+func (r *startedRepo) UpdateJob(ctx context.Context, job *model.Job) error {
+	err := r.Repository.UpdateJob(ctx, job)
+	if err == nil && job.PID > 0 {
+		r.once.Do(func() { r.started <- *job })
+	}
+	return err
+}
+
+func TestLauncher_RunsJobsOnTwoAgents(t *testing.T) {
+	repo, cfg := newTestCoordinator(t)
+	launcher := NewLauncher(repo, cfg, nil, nopLogger{})
+	ctx := context.Background()
+
+	targets := []string{newTestAgent(t, "complete"), newTestAgent(t, "complete")}
+	created := []*model.Job{
+		createTestJob(t, repo, "The Matrix", model.StageTranscode),
+		createTestJob(t, repo, "Heat", model.StagePublish),
+	}
+	wantInput := []string{"/staging/remux/The_Matrix", "/staging/transcode/Heat"}
+
+	var results []<-chan error
+	for i := range created {
+		results = append(results, launch(t, launcher, created[i], targets[i]))
+	}
+
+	for i, created := range created {
+		if err := wait(t, results[i]); err != nil {
+			t.Fatalf("Launch() on %s error = %v", targets[i], err)
+		}
+
+		job, err := repo.GetJob(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+		if job.Status != model.JobStatusCompleted {
+			t.Errorf("job %d status = %s, want completed", job.ID, job.Status)
+		}
+		if job.WorkerID != targets[i] {
+			t.Errorf("job %d worker = %q, want %q", job.ID, job.WorkerID, targets[i])
+		}
+		if job.InputDir != wantInput[i] {
+			t.Errorf("job %d input = %q, want %q", job.ID, job.InputDir, wantInput[i])
+		}
+		if job.Progress != 50 {
+			t.Errorf("job %d progress = %d, want 50", job.ID, job.Progress)
+		}
+		if job.LogPath != cfg.JobLogPath(job.ID) {
+			t.Errorf("job %d log path = %q, want %q", job.ID, job.LogPath, cfg.JobLogPath(job.ID))
+		}
+
+		log, err := os.ReadFile(cfg.JobLogPath(job.ID))
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if !strings.Contains(string(log), "[INFO] starting job") || !strings.Contains(string(log), "[WARN] no subtitles found") {
+			t.Errorf("job %d log = %q, want the stage output", job.ID, log)
+		}
+
+		events, err := repo.ListLogEvents(ctx, job.ID, 10)
+		if err != nil {
+			t.Fatalf("ListLogEvents() error = %v", err)
+		}
+		if len(events) != 1 || events[0].Level != "warn" || events[0].Message != "no subtitles found" {
+			t.Errorf("job %d log events = %+v, want the warning only", job.ID, events)
+		}
+
+		files, err := repo.ListTranscodeFiles(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("ListTranscodeFiles() error = %v", err)
+		}
+		if len(files) != 1 || files[0].Status != model.TranscodeFileStatusCompleted || files[0].OutputSize != 40 {
+			t.Errorf("job %d transcode files = %+v, want the completed file", job.ID, files)
+		}
+
+		item, err := repo.GetMediaItem(ctx, job.MediaItemID)
+		if err != nil {
+			t.Fatalf("GetMediaItem() error = %v", err)
+		}
+		if item.ItemStatus != model.ItemStatusCompleted {
+			t.Errorf("item %d status = %s, want completed", item.ID, item.ItemStatus)
+		}
+	}
+}
+
+func TestLauncher_ApplyNeverRevertsToPending(t *testing.T) {
+	repo, cfg := newTestCoordinator(t)
+	launcher := NewLauncher(repo, cfg, nil, nopLogger{})
+	ctx := context.Background()
+
+	created := createTestJob(t, repo, "The Matrix", model.StageTranscode)
+	item, err := repo.GetMediaItem(ctx, created.MediaItemID)
+	if err != nil {
+		t.Fatalf("GetMediaItem() error = %v", err)
+	}
+
+	// A snapshot taken before the binary recorded itself
+	snapshot := *created
+	snapshot.Status = model.JobStatusPending
+	if err := launcher.apply(ctx, &Event{Type: EventJob, Job: &snapshot}, item, "encoder:7300", cfg.JobLogPath(created.ID)); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+
+	job, err := repo.GetJob(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != model.JobStatusInProgress {
+		t.Errorf("status = %s, want in_progress", job.Status)
+	}
+}
+
+func TestLauncher_CancelStopsAgentJob(t *testing.T) {
+	repo, cfg := newTestCoordinator(t)
+	started := &startedRepo{Repository: repo, started: make(chan model.Job, 1)}
+	launcher := NewLauncher(started, cfg, nil, nopLogger{})
+	ctx := context.Background()
+
+	target := newTestAgent(t, "wait")
+	created := createTestJob(t, repo, "The Matrix", model.StageTranscode)
+	done := launch(t, launcher, created, target)
+
+	var job model.Job
+	select {
+	case job = <-started.started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("job never started on the agent")
+	}
+
+	if err := jobs.Cancel(ctx, repo, cfg, &job); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if err := wait(t, done); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	got, err := repo.GetJob(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if got.Status != model.JobStatusCancelled {
+		t.Errorf("status = %s, want cancelled", got.Status)
+	}
+}
@@ -0,0 +1,302 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/daemon"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/logging"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// maxConflictRetries bounds how often a snapshot is applied again to a job
+// that changed while it was being applied
+const maxConflictRetries = 3
+
+// Launcher runs jobs routed to another host on that host's agent, and
+// everything else with a local launcher
+type Launcher struct {
+	repo   db.Repository
+	cfg    *config.Config
+	local  daemon.Launcher
+	logger daemon.Logger
+	client *http.Client
+
+	// mu serializes database writes from concurrent launches
+	mu sync.Mutex
+}
+
+// NewLauncher creates a launcher that dispatches remote targets to agents
+func NewLauncher(repo db.Repository, cfg *config.Config, local daemon.Launcher, logger daemon.Logger) *Launcher {
+	return &Launcher{repo: repo, cfg: cfg, local: local, logger: logger, client: http.DefaultClient}
+}
+
+// Launch runs the job on the agent at target and mirrors its progress into
+// the database until the stage binary exits. Cancelling ctx disconnects from
+// the agent, which stops the binary; the job is then recorded as cancelled.
+func (l *Launcher) Launch(ctx context.Context, job *model.Job, target string) error {
+	if target == "" {
+		return l.local.Launch(ctx, job, target)
+	}
+
+	assignment, err := l.assignment(ctx, job)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(assignment)
+	if err != nil {
+		return fmt.Errorf("failed to encode assignment: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.cfg.AgentURL(target)+"/jobs", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach agent %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("agent %s refused job: %s: %s", target, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// Record with a fresh context: the outcome has to be written even if ctx
+	// is cancelled mid-stream
+	recordCtx := context.Background()
+
+	logPath := l.cfg.JobLogPath(job.ID)
+	if err := l.cfg.EnsureJobLogDir(job.ID); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open job log: %w", err)
+	}
+	defer logFile.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("invalid event from agent %s: %w", target, err)
+		}
+
+		switch event.Type {
+		case EventJob:
+			if event.Job != nil {
+				if err := l.apply(recordCtx, &event, &assignment.Item, target, logPath); err != nil {
+					return err
+				}
+			}
+		case EventLog:
+			fmt.Fprintln(logFile, event.Line)
+			if err := l.recordLogEvent(recordCtx, job.ID, event.Line); err != nil {
+				l.logger.Error("Job %d: %v", job.ID, err)
+			}
+		case EventExit:
+			if event.Error != "" {
+				return fmt.Errorf("%s on %s: %s", job.Stage, target, event.Error)
+			}
+			return nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		return l.recordCancelled(recordCtx, job.ID, ctx.Err())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("lost connection to agent %s: %w", target, err)
+	}
+	return fmt.Errorf("agent %s closed the stream before %s exited", target, job.Stage)
+}
+
+// assignment gathers what the stage binary will read from its database
+func (l *Launcher) assignment(ctx context.Context, job *model.Job) (*Assignment, error) {
+	item, err := l.repo.GetMediaItem(ctx, job.MediaItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media item: %w", err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("media item %d not found", job.MediaItemID)
+	}
+
+	// The daemon passes the job as it was before claiming it
+	a := &Assignment{Job: *job, Item: *item}
+	a.Job.Status = model.JobStatusInProgress
+
+	if job.SeasonID != nil {
+		season, err := l.repo.GetSeason(ctx, *job.SeasonID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get season: %w", err)
+		}
+		a.Season = season
+	}
+
+	a.Options, err = l.repo.GetJobOptions(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	a.InputDir, err = jobs.InputDir(ctx, l.repo, job)
+	if err != nil {
+		return nil, err
+	}
+	a.Files, err = l.repo.ListTranscodeFiles(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// apply mirrors what the stage binary recorded in a snapshot: the job, its
+// transcode files and the media item changes since the assignment. The
+// worker is the agent target, so the job can be cancelled through it.
+func (l *Launcher) apply(ctx context.Context, event *Event, item *model.MediaItem, target, logPath string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snapshot := event.Job
+	current, err := l.applyJob(ctx, snapshot, target, logPath)
+	if err != nil {
+		return err
+	}
+	if err := l.repo.UpdateJobProgress(ctx, current.ID, snapshot.Progress); err != nil {
+		return err
+	}
+
+	if err := l.applyFiles(ctx, current.ID, event.Files); err != nil {
+		return err
+	}
+	if event.Item != nil {
+		return l.applyItem(ctx, item, event.Item)
+	}
+	return nil
+}
+
+// applyJob writes a snapshot's fields over the job, reading it again if it
+// changed in between, such as when the daemon requests a cancel
+func (l *Launcher) applyJob(ctx context.Context, snapshot *model.Job, target, logPath string) (*model.Job, error) {
+	for attempt := 1; ; attempt++ {
+		current, err := l.repo.GetJob(ctx, snapshot.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job: %w", err)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("job %d not found", snapshot.ID)
+		}
+
+		// The job is claimed, so it never goes back to pending
+		if snapshot.Status != model.JobStatusPending {
+			current.Status = snapshot.Status
+		}
+		current.WorkerID = target
+		current.PID = snapshot.PID
+		current.InputDir = snapshot.InputDir
+		current.OutputDir = snapshot.OutputDir
+		current.LogPath = logPath
+		current.ErrorMessage = snapshot.ErrorMessage
+		current.FailureKind = snapshot.FailureKind
+		current.StartedAt = snapshot.StartedAt
+		current.CompletedAt = snapshot.CompletedAt
+		err = l.repo.UpdateJob(ctx, current)
+		if errors.Is(err, db.ErrConflict) && attempt < maxConflictRetries {
+			continue
+		}
+		return current, err
+	}
+}
+
+// applyFiles mirrors transcode files, matched by relative path since the
+// agent numbers them on its own
+func (l *Launcher) applyFiles(ctx context.Context, jobID int64, files []model.TranscodeFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	existing, err := l.repo.ListTranscodeFiles(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	ids := make(map[string]int64, len(existing))
+	for _, f := range existing {
+		ids[f.RelativePath] = f.ID
+	}
+
+	for _, f := range files {
+		file := f
+		file.JobID = jobID
+		if id, ok := ids[file.RelativePath]; ok {
+			file.ID = id
+		} else if err := l.repo.CreateTranscodeFile(ctx, &file); err != nil {
+			return err
+		}
+		if err := l.repo.UpdateTranscodeFile(ctx, &file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyItem records the media item fields the binary changed since last
+// time. item holds the last known values and is updated in place.
+func (l *Launcher) applyItem(ctx context.Context, item, snapshot *model.MediaItem) error {
+	if snapshot.CurrentStage != item.CurrentStage || snapshot.StageStatus != item.StageStatus {
+		if err := l.repo.UpdateMediaItemStage(ctx, item.ID, snapshot.CurrentStage, snapshot.StageStatus); err != nil {
+			return err
+		}
+		item.CurrentStage = snapshot.CurrentStage
+		item.StageStatus = snapshot.StageStatus
+	}
+	if snapshot.ItemStatus != item.ItemStatus {
+		if err := l.repo.UpdateMediaItemStatus(ctx, item.ID, snapshot.ItemStatus); err != nil {
+			return err
+		}
+		item.ItemStatus = snapshot.ItemStatus
+	}
+	return nil
+}
+
+// recordLogEvent keeps warnings and errors from the stage as log events
+func (l *Launcher) recordLogEvent(ctx context.Context, jobID int64, line string) error {
+	level, msg, ok := logging.ParseLine(line)
+	if !ok || level < logging.LevelWarn {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.repo.CreateLogEvent(ctx, &model.LogEvent{
+		JobID:   jobID,
+		Level:   strings.ToLower(level.String()),
+		Message: msg,
+	})
+}
+
+// recordCancelled marks a job cancelled after the coordinator hung up on the agent
+func (l *Launcher) recordCancelled(ctx context.Context, jobID int64, cause error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current, err := l.repo.GetJob(ctx, jobID)
+	if err == nil && current != nil && current.IsActive() {
+		if err := l.repo.UpdateJobStatus(ctx, jobID, model.JobStatusCancelled, "cancelled"); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("disconnected from agent: %w", cause)
+}
@@ -0,0 +1,49 @@
+// Package agent runs stage binaries on remote hosts on behalf of the
+// coordinator (the pipeline daemon), so that only the coordinator opens the
+// pipeline database.
+//
+// The coordinator POSTs an Assignment to /jobs. The agent gives the stage
+// binary a scratch database seeded with the job, runs it, and streams Events
+// back as newline-delimited JSON until the binary exits. The coordinator
+// mirrors the job snapshots into the real database.
+package agent
+
+import "github.com/cuivienor/media-pipeline/internal/model"
+
+// Assignment is a job for an agent to run, with everything the stage binary
+// reads from the database. InputDir is the previous stage's output, which the
+// binary would otherwise look up in the job history.
+type Assignment struct {
+	Job      model.Job
+	Item     model.MediaItem
+	Season   *model.Season
+	Options  map[string]interface{}
+	InputDir string
+	Files    []model.TranscodeFile // Transcode files recorded by an earlier attempt
+}
+
+// EventType identifies what an Event carries
+type EventType string
+
+const (
+	EventJob  EventType = "job"  // The stage binary updated its job, files or item
+	EventLog  EventType = "log"  // A line of stage output
+	EventExit EventType = "exit" // The stage binary exited; always the last event
+)
+
+// Event is one line of the stream an agent sends while a job runs
+type Event struct {
+	Type  EventType             `json:"type"`
+	Job   *model.Job            `json:"job,omitempty"`   // EventJob: the job as the binary recorded it
+	Files []model.TranscodeFile `json:"files,omitempty"` // EventJob: the job's transcode files
+	Item  *model.MediaItem      `json:"item,omitempty"`  // EventJob: the job's media item
+	Line  string                `json:"line,omitempty"`  // EventLog
+	Error string                `json:"error,omitempty"` // EventExit: why the binary failed, empty on success
+}
+
+// Status is what an agent reports about itself
+type Status struct {
+	Hostname     string
+	Capabilities []model.Capability
+	RunningJobs  []int64
+}
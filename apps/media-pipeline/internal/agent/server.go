@@ -0,0 +1,312 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/daemon"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"github.com/cuivienor/media-pipeline/internal/workers"
+)
+
+// DefaultSnapshotInterval is how often the scratch database is checked for job updates
+const DefaultSnapshotInterval = time.Second
+
+// killGracePeriod is how long a stage process gets to exit after SIGTERM
+const killGracePeriod = 30 * time.Second
+
+// Server runs assigned jobs on this host
+type Server struct {
+	// command builds the process for a stage; replaced in tests
+	command          func(ctx context.Context, stage model.Stage, args []string) (*exec.Cmd, error)
+	snapshotInterval time.Duration
+
+	mu      sync.Mutex
+	running map[int64]*exec.Cmd
+}
+
+// NewServer creates an agent that runs the stage binaries installed next to it
+func NewServer() *Server {
+	return &Server{
+		command:          stageCommand,
+		snapshotInterval: DefaultSnapshotInterval,
+		running:          make(map[int64]*exec.Cmd),
+	}
+}
+
+// stageCommand runs the installed binary for a stage
+func stageCommand(ctx context.Context, stage model.Stage, args []string) (*exec.Cmd, error) {
+	path, err := daemon.BinaryPath(stage)
+	if err != nil {
+		return nil, err
+	}
+	return exec.CommandContext(ctx, path, args...), nil
+}
+
+// Handler returns the agent's HTTP API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.handleRun)
+	mux.HandleFunc("POST /jobs/{id}/cancel", s.handleCancel)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	return mux
+}
+
+// handleRun runs an assignment and streams its events until the binary exits.
+// If the coordinator disconnects, the binary is sent SIGTERM.
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	var a Assignment
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, fmt.Sprintf("invalid assignment: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	dir, err := os.MkdirTemp("", fmt.Sprintf("media-pipeline-job-%d-", a.Job.ID))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to create scratch directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	scratch, err := openScratch(r.Context(), filepath.Join(dir, "job.db"), &a)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer scratch.close()
+
+	args := []string{
+		"-job-id", strconv.FormatInt(a.Job.ID, 10),
+		"-db", scratch.path,
+	}
+	// The ripper reads from the disc rather than an earlier job, so it runs
+	// unchanged with just the job and its season
+	if a.InputDir != "" {
+		args = append(args, "-input-dir", a.InputDir)
+	}
+	cmd, err := s.command(r.Context(), a.Job.Stage, args)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = killGracePeriod
+
+	output, outputWriter := io.Pipe()
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+
+	if err := cmd.Start(); err != nil {
+		http.Error(w, fmt.Sprintf("failed to start %s: %v", a.Job.Stage, err), http.StatusInternalServerError)
+		return
+	}
+	s.track(a.Job.ID, cmd)
+	defer s.untrack(a.Job.ID)
+
+	stream := newStream(w)
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		// Keep draining so the process never blocks on a full pipe
+		io.Copy(io.Discard, output)
+	}()
+
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		outputWriter.Close()
+		exited <- err
+	}()
+
+	ticker := time.NewTicker(s.snapshotInterval)
+	defer ticker.Stop()
+
+	var exitErr error
+	for lines != nil || exited != nil {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				lines = nil
+				continue
+			}
+			stream.send(Event{Type: EventLog, Line: line})
+		case <-ticker.C:
+			scratch.sendIfChanged(stream)
+		case exitErr = <-exited:
+			exited = nil
+		}
+	}
+
+	scratch.sendIfChanged(stream)
+	exit := Event{Type: EventExit}
+	if exitErr != nil {
+		exit.Error = exitErr.Error()
+	}
+	stream.send(exit)
+}
+
+// handleCancel sends SIGTERM to a running job; the binary records the cancellation
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	cmd := s.running[id]
+	s.mu.Unlock()
+	if cmd == nil {
+		http.Error(w, fmt.Sprintf("job %d is not running here", id), http.StatusNotFound)
+		return
+	}
+
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		http.Error(w, fmt.Sprintf("failed to signal job %d: %v", id, err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleStatus reports this host and the jobs it is running
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := Status{
+		Hostname:     jobs.LocalWorkerID(),
+		Capabilities: workers.DetectCapabilities(),
+	}
+	s.mu.Lock()
+	for id := range s.running {
+		status.RunningJobs = append(status.RunningJobs, id)
+	}
+	s.mu.Unlock()
+	sort.Slice(status.RunningJobs, func(i, j int) bool { return status.RunningJobs[i] < status.RunningJobs[j] })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) track(id int64, cmd *exec.Cmd) {
+	s.mu.Lock()
+	s.running[id] = cmd
+	s.mu.Unlock()
+}
+
+func (s *Server) untrack(id int64) {
+	s.mu.Lock()
+	delete(s.running, id)
+	s.mu.Unlock()
+}
+
+// stream writes events as newline-delimited JSON, flushing each one
+type stream struct {
+	enc     *json.Encoder
+	flusher http.Flusher
+}
+
+func newStream(w http.ResponseWriter) *stream {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	return &stream{enc: json.NewEncoder(w), flusher: flusher}
+}
+
+// send writes an event. Write errors mean the coordinator went away, which
+// the request context already reports, so they are ignored here.
+func (s *stream) send(e Event) {
+	s.enc.Encode(e)
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+// scratchDB is the private database a stage binary runs against
+type scratchDB struct {
+	path     string
+	database *db.DB
+	repo     *db.SQLiteRepository
+	jobID    int64
+	itemID   int64
+	last     []byte
+}
+
+// openScratch creates a database at path holding the assigned job, its owner
+// and the transcode files of earlier attempts
+func openScratch(ctx context.Context, path string, a *Assignment) (*scratchDB, error) {
+	database, err := db.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scratch database: %w", err)
+	}
+	repo := db.NewSQLiteRepository(database)
+	if err := seedScratch(ctx, repo, a); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to seed scratch database: %w", err)
+	}
+	return &scratchDB{path: path, database: database, repo: repo, jobID: a.Job.ID, itemID: a.Item.ID}, nil
+}
+
+func seedScratch(ctx context.Context, repo *db.SQLiteRepository, a *Assignment) error {
+	if err := repo.SeedJob(ctx, &a.Item, a.Season, &a.Job, a.Options); err != nil {
+		return err
+	}
+	for _, f := range a.Files {
+		file := f
+		if err := repo.CreateTranscodeFile(ctx, &file); err != nil {
+			return err
+		}
+		if err := repo.UpdateTranscodeFile(ctx, &file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendIfChanged sends the job, its transcode files and its media item if
+// the binary changed any of them since the last call
+func (s *scratchDB) sendIfChanged(out *stream) {
+	ctx := context.Background()
+	job, err := s.repo.GetJob(ctx, s.jobID)
+	if err != nil || job == nil {
+		return
+	}
+	files, err := s.repo.ListTranscodeFiles(ctx, s.jobID)
+	if err != nil {
+		return
+	}
+	item, err := s.repo.GetMediaItem(ctx, s.itemID)
+	if err != nil || item == nil {
+		return
+	}
+
+	event := Event{Type: EventJob, Job: job, Files: files, Item: item}
+	data, err := json.Marshal(event)
+	if err != nil || string(data) == string(s.last) {
+		return
+	}
+	s.last = data
+	out.send(event)
+}
+
+func (s *scratchDB) close() {
+	s.database.Close()
+}
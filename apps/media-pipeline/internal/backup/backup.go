@@ -0,0 +1,64 @@
+// Package backup takes timestamped snapshots of the pipeline database and
+// prunes old ones.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+)
+
+// timeFormat names snapshots so they sort oldest first
+const timeFormat = "20060102-150405"
+
+// Snapshot writes a consistent copy of the database to dir, named after the
+// time it was taken, and returns its path
+func Snapshot(ctx context.Context, database *db.DB, dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("pipeline-%s.db", now.UTC().Format(timeFormat)))
+	if err := database.Backup(ctx, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List returns the snapshots in dir, oldest first
+func List(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "pipeline-*.db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Prune deletes all but the newest keep snapshots in dir and returns the
+// paths it deleted. keep <= 0 keeps everything.
+func Prune(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	paths, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) <= keep {
+		return nil, nil
+	}
+
+	var removed []string
+	for _, path := range paths[:len(paths)-keep] {
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
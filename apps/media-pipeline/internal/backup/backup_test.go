@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+)
+
+func TestSnapshotAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	database, err := db.Open(filepath.Join(dir, "pipeline.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+
+	backups := filepath.Join(dir, "backups")
+	start := time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+	var taken []string
+	for i := 0; i < 4; i++ {
+		path, err := Snapshot(context.Background(), database, backups, start.AddDate(0, 0, i))
+		if err != nil {
+			t.Fatalf("Snapshot() error = %v", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("snapshot %s not written: %v", path, err)
+		}
+		taken = append(taken, path)
+	}
+	if got := filepath.Base(taken[0]); got != "pipeline-20261001-030000.db" {
+		t.Errorf("Snapshot() name = %s, want pipeline-20261001-030000.db", got)
+	}
+
+	removed, err := Prune(backups, 2)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(removed) != 2 || removed[0] != taken[0] || removed[1] != taken[1] {
+		t.Errorf("Prune() removed %v, want the two oldest", removed)
+	}
+	left, _ := List(backups)
+	if len(left) != 2 || left[0] != taken[2] || left[1] != taken[3] {
+		t.Errorf("List() after prune = %v, want the two newest", left)
+	}
+
+	if removed, _ := Prune(backups, 0); len(removed) != 0 {
+		t.Errorf("Prune(0) removed %v, want nothing", removed)
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,6 +14,7 @@ const (
 	defaultMediaBase = "/mnt/media"
 	pipelineDirName  = "pipeline"
 	configFileName   = "config.yaml"
+	defaultAgentPort = 7300
 )
 
 // RemuxConfig holds remux-specific configuration
@@ -19,6 +22,19 @@ type RemuxConfig struct {
 	Languages []string `yaml:"languages"`
 }
 
+// AgentConfig configures dispatch through agents instead of SSH
+type AgentConfig struct {
+	Enabled bool `yaml:"enabled"` // Dispatch targets are agents (host or host:port)
+	Port    int  `yaml:"port"`    // Port for targets without one (default 7300)
+}
+
+// RetryConfig holds the automatic retry policy for a stage
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"` // Total attempts including the first (1 = never retry)
+	Backoff     time.Duration `yaml:"backoff"`      // Delay before the first retry, doubled for each one after
+	Retryable   []string      `yaml:"retryable"`    // Failure kinds worth retrying
+}
+
 // TranscodeConfig holds transcode-specific configuration
 type TranscodeConfig struct {
 	CRF      int    `yaml:"crf"`       // Quality (0-51, default 20)
@@ -29,11 +45,15 @@ type TranscodeConfig struct {
 
 // Config holds application configuration
 type Config struct {
-	StagingBase string            `yaml:"staging_base"` // Staging directory
-	LibraryBase string            `yaml:"library_base"` // Library directory
-	Dispatch    map[string]string `yaml:"dispatch"`     // SSH targets per stage
-	Remux       RemuxConfig       `yaml:"remux"`        // Remux configuration
-	Transcode   TranscodeConfig   `yaml:"transcode"`    // Transcode configuration
+	StagingBase string                 `yaml:"staging_base"` // Staging directory
+	LibraryBase string                 `yaml:"library_base"` // Library directory
+	Dispatch    map[string]string      `yaml:"dispatch"`     // SSH targets per stage
+	Concurrency map[string]int         `yaml:"concurrency"`  // Max running jobs per stage (0 = unlimited)
+	Retry       map[string]RetryConfig `yaml:"retry"`        // Automatic retry policy per stage
+	Windows     map[string]Window      `yaml:"windows"`      // Daily hours each stage may run in (unset = any time)
+	Agent       AgentConfig            `yaml:"agent"`        // Agent dispatch configuration
+	Remux       RemuxConfig            `yaml:"remux"`        // Remux configuration
+	Transcode   TranscodeConfig        `yaml:"transcode"`    // Transcode configuration
 
 	// Derived from environment, not stored in YAML
 	mediaBase string
@@ -93,6 +113,67 @@ func (c *Config) IsLocal(stage string) bool {
 	return c.DispatchTarget(stage) == ""
 }
 
+// AgentURL returns the base URL of the agent at a dispatch target
+func (c *Config) AgentURL(target string) string {
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		port := c.Agent.Port
+		if port == 0 {
+			port = defaultAgentPort
+		}
+		target = net.JoinHostPort(target, fmt.Sprint(port))
+	}
+	return "http://" + target
+}
+
+// defaultConcurrency limits stages that contend for a single resource on a
+// host: the disc drive for rip, the CPU for transcode
+var defaultConcurrency = map[string]int{
+	"rip":       1,
+	"transcode": 1,
+}
+
+// ConcurrencyLimit returns how many jobs of a stage may run at once on each
+// worker host, 0 meaning unlimited. A host rips from a single drive, so the
+// rip limit is also per drive. Defaults to 1 for rip and transcode, unlimited
+// otherwise.
+func (c *Config) ConcurrencyLimit(stage string) int {
+	if limit, ok := c.Concurrency[stage]; ok {
+		if limit < 0 {
+			return 0
+		}
+		return limit
+	}
+	return defaultConcurrency[stage]
+}
+
+// StageWindow returns the daily window a stage may run in, and false if the
+// stage may run at any time
+func (c *Config) StageWindow(stage string) (Window, bool) {
+	w, ok := c.Windows[stage]
+	return w, ok
+}
+
+// RetryPolicy returns the retry policy for a stage, with unset fields defaulted:
+// 3 attempts (1 for rip, which needs the disc in the drive), 1m backoff, and
+// retrying only failures where the stage itself never got to report an error,
+// or refused to start for lack of disk space.
+func (c *Config) RetryPolicy(stage string) RetryConfig {
+	policy := c.Retry[stage]
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+		if stage == "rip" {
+			policy.MaxAttempts = 1
+		}
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = time.Minute
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = []string{"launch", "crash", "vanished", "space"}
+	}
+	return policy
+}
+
 // RemuxLanguages returns the list of languages to keep during remux
 // Defaults to ["eng"] if not configured
 func (c *Config) RemuxLanguages() []string {
@@ -185,17 +266,23 @@ func LoadDefault() (*Config, error) {
 
 // LoadFromMediaBase loads config from $MEDIA_BASE/pipeline/config.yaml
 func LoadFromMediaBase() (*Config, error) {
-	mediaBase := os.Getenv("MEDIA_BASE")
-	if mediaBase == "" {
-		mediaBase = defaultMediaBase
-	}
-
-	configPath := filepath.Join(mediaBase, pipelineDirName, configFileName)
-	cfg, err := Load(configPath)
+	cfg, err := Load(MediaBaseConfigPath())
 	if err != nil {
 		return nil, err
 	}
 
-	cfg.mediaBase = mediaBase
+	cfg.mediaBase = mediaBaseFromEnv()
 	return cfg, nil
 }
+
+// MediaBaseConfigPath returns the path LoadFromMediaBase reads the config from
+func MediaBaseConfigPath() string {
+	return filepath.Join(mediaBaseFromEnv(), pipelineDirName, configFileName)
+}
+
+func mediaBaseFromEnv() string {
+	if base := os.Getenv("MEDIA_BASE"); base != "" {
+		return base
+	}
+	return defaultMediaBase
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoad_FromFile(t *testing.T) {
@@ -135,6 +136,168 @@ func TestConfig_IsLocal(t *testing.T) {
 	}
 }
 
+func TestConfig_ConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   map[string]int
+		stage string
+		want  int
+	}{
+		{"rip defaults to one drive", nil, "rip", 1},
+		{"transcode defaults to one", nil, "transcode", 1},
+		{"publish defaults to unlimited", nil, "publish", 0},
+		{"configured limit", map[string]int{"transcode": 2}, "transcode", 2},
+		{"zero lifts the default", map[string]int{"rip": 0}, "rip", 0},
+		{"negative means unlimited", map[string]int{"remux": -1}, "remux", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Concurrency: tt.cfg}
+			if got := cfg.ConcurrencyLimit(tt.stage); got != tt.want {
+				t.Errorf("ConcurrencyLimit(%s) = %d, want %d", tt.stage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_AgentURL(t *testing.T) {
+	tests := []struct {
+		port   int
+		target string
+		want   string
+	}{
+		{0, "transcoder", "http://transcoder:7300"},
+		{8000, "transcoder", "http://transcoder:8000"},
+		{8000, "localhost:7301", "http://localhost:7301"},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Agent: AgentConfig{Enabled: true, Port: tt.port}}
+		if got := cfg.AgentURL(tt.target); got != tt.want {
+			t.Errorf("AgentURL(%q) with port %d = %q, want %q", tt.target, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_RetryPolicy(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+
+	content := `
+retry:
+  transcode:
+    max_attempts: 5
+    backoff: 10m
+    retryable: [crash, stage]
+`
+	os.WriteFile(configPath, []byte(content), 0644)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	transcode := cfg.RetryPolicy("transcode")
+	if transcode.MaxAttempts != 5 || transcode.Backoff != 10*time.Minute {
+		t.Errorf("transcode policy = %+v, want 5 attempts with 10m backoff", transcode)
+	}
+	if len(transcode.Retryable) != 2 || transcode.Retryable[1] != "stage" {
+		t.Errorf("transcode Retryable = %v, want [crash stage]", transcode.Retryable)
+	}
+
+	remux := cfg.RetryPolicy("remux")
+	if remux.MaxAttempts != 3 || remux.Backoff != time.Minute || len(remux.Retryable) != 4 {
+		t.Errorf("remux policy = %+v, want defaults", remux)
+	}
+
+	if rip := cfg.RetryPolicy("rip"); rip.MaxAttempts != 1 {
+		t.Errorf("rip MaxAttempts = %d, want 1", rip.MaxAttempts)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Window
+		wantErr bool
+	}{
+		{"23:00-07:00", Window{Start: 23 * time.Hour, End: 7 * time.Hour}, false},
+		{"09:30 - 17:00", Window{Start: 9*time.Hour + 30*time.Minute, End: 17 * time.Hour}, false},
+		{"23:00", Window{}, true},
+		{"25:00-07:00", Window{}, true},
+		{"07:00-07:00", Window{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseWindow(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWindow(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseWindow(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	at := func(hour, min int) time.Time { return time.Date(2026, 1, 2, hour, min, 0, 0, time.Local) }
+	night := Window{Start: 23 * time.Hour, End: 7 * time.Hour}
+	day := Window{Start: 9 * time.Hour, End: 17 * time.Hour}
+
+	tests := []struct {
+		name     string
+		window   Window
+		now      time.Time
+		want     bool
+		nextOpen time.Time
+	}{
+		{"overnight before midnight", night, at(23, 30), true, at(23, 30)},
+		{"overnight after midnight", night, at(6, 59), true, at(6, 59)},
+		{"overnight closes at end", night, at(7, 0), false, at(23, 0)},
+		{"overnight daytime", night, at(14, 0), false, at(23, 0)},
+		{"daytime inside", day, at(9, 0), true, at(9, 0)},
+		{"daytime before", day, at(8, 0), false, at(9, 0)},
+		{"daytime after", day, at(18, 0), false, at(9, 0).AddDate(0, 0, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.now); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.now.Format("15:04"), got, tt.want)
+			}
+			if got := tt.window.NextOpen(tt.now); !got.Equal(tt.nextOpen) {
+				t.Errorf("NextOpen(%s) = %v, want %v", tt.now.Format("15:04"), got, tt.nextOpen)
+			}
+		})
+	}
+}
+
+func TestLoad_Windows(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	os.WriteFile(configPath, []byte("windows:\n  transcode: 23:00-07:00\n"), 0644)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	w, ok := cfg.StageWindow("transcode")
+	if !ok || w.String() != "23:00-07:00" {
+		t.Errorf("StageWindow(transcode) = %v, %v; want 23:00-07:00", w, ok)
+	}
+	if _, ok := cfg.StageWindow("remux"); ok {
+		t.Error("StageWindow(remux) set, want any time")
+	}
+
+	os.WriteFile(configPath, []byte("windows:\n  transcode: overnight\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("Load() with an invalid window succeeded, want error")
+	}
+}
+
 func TestLoad_FileNotFound(t *testing.T) {
 	_, err := Load("/nonexistent/path/config.yaml")
 	if err == nil {
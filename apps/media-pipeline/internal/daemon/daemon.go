@@ -0,0 +1,374 @@
+// Package daemon implements the long-running pipeline service that picks up
+// pending jobs from the database and executes their stage binaries.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"github.com/cuivienor/media-pipeline/internal/queue"
+)
+
+// DefaultPollInterval is how often the daemon looks for pending jobs
+const DefaultPollInterval = 10 * time.Second
+
+// DefaultReapInterval is how often the daemon looks for jobs whose worker died
+const DefaultReapInterval = time.Minute
+
+// Logger interface for daemon logging
+type Logger interface {
+	Info(format string, args ...interface{})
+	Error(format string, args ...interface{})
+}
+
+// Router picks the host a stage runs on: "" for this host, otherwise an SSH target
+type Router interface {
+	Route(ctx context.Context, stage model.Stage) (string, error)
+}
+
+// localRouter runs every stage on this host
+type localRouter struct{}
+
+func (localRouter) Route(context.Context, model.Stage) (string, error) { return "", nil }
+
+// Options configures the daemon
+type Options struct {
+	PollInterval time.Duration      // Defaults to DefaultPollInterval
+	ReapInterval time.Duration      // Defaults to DefaultReapInterval
+	Limits       queue.Limits       // Per-host concurrency limits for each stage; nil means unlimited
+	Windows      queue.Windows      // Daily windows jobs of each stage may start in; nil means any time
+	Retry        jobs.RetryPolicies // Per-stage retry policies; nil means never retry
+	Router       Router             // Defaults to running everything on this host
+}
+
+// Daemon polls for pending jobs, claims them and runs them via a Launcher
+type Daemon struct {
+	repo     db.Repository
+	launcher Launcher
+	logger   Logger
+	reaper   *Reaper
+	queue    *queue.Queue
+	opts     Options
+	workerID string
+
+	// inFlight holds the jobs this daemon is executing, which the reaper
+	// must leave to execute, with the func that stops each one
+	mu       sync.Mutex
+	inFlight map[int64]context.CancelFunc
+
+	// unroutable holds pending jobs that had no worker at the last poll,
+	// so the reason is logged once rather than on every poll
+	unroutable map[int64]bool
+
+	// wake is signalled when a job finishes so the next one starts
+	// without waiting for the poll interval
+	wake chan struct{}
+
+	wg sync.WaitGroup
+}
+
+// New creates a new Daemon
+func New(repo db.Repository, launcher Launcher, logger Logger, opts Options) *Daemon {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.ReapInterval <= 0 {
+		opts.ReapInterval = DefaultReapInterval
+	}
+	if opts.Router == nil {
+		opts.Router = localRouter{}
+	}
+	return &Daemon{
+		repo:       repo,
+		launcher:   launcher,
+		logger:     logger,
+		reaper:     NewReaper(repo, logger, opts.Retry),
+		queue:      queue.New(repo, opts.Limits, opts.Windows),
+		opts:       opts,
+		workerID:   jobs.LocalWorkerID(),
+		wake:       make(chan struct{}, 1),
+		inFlight:   make(map[int64]context.CancelFunc),
+		unroutable: make(map[int64]bool),
+	}
+}
+
+// Run polls for pending jobs until ctx is cancelled.
+// Jobs left behind by dead workers are reaped at startup and every ReapInterval.
+// On shutdown, running stage processes are signalled and Run waits for them to exit.
+func (d *Daemon) Run(ctx context.Context) error {
+	d.logger.Info("Pipeline daemon started on %s (poll interval %s)", d.workerID, d.opts.PollInterval)
+
+	d.reap(ctx)
+
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	reapTicker := time.NewTicker(d.opts.ReapInterval)
+	defer reapTicker.Stop()
+
+	for {
+		if _, err := d.Poll(ctx); err != nil {
+			d.logger.Error("Poll failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Shutting down, waiting for running jobs to exit")
+			d.Wait()
+			return nil
+		case <-reapTicker.C:
+			d.reap(ctx)
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// reap fails jobs whose worker process has died
+func (d *Daemon) reap(ctx context.Context) {
+	reaped, err := d.reaper.Reap(ctx, d.inFlightJobs())
+	if err != nil {
+		d.logger.Error("Reap failed: %v", err)
+		return
+	}
+	if reaped > 0 {
+		d.logger.Info("Reaped %d job(s) from vanished workers", reaped)
+	}
+}
+
+// Poll claims the pending jobs the queue allows to run and starts them in
+// the background. Returns the number of jobs started.
+func (d *Daemon) Poll(ctx context.Context) (int, error) {
+	if err := d.stopCancelled(ctx); err != nil {
+		return 0, err
+	}
+
+	if err := d.pruneUnroutable(ctx); err != nil {
+		return 0, err
+	}
+
+	ready, err := d.queue.Ready(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	started := 0
+	for _, job := range ready {
+		// Organize is a manual stage with nothing to execute
+		if _, err := BinaryName(job.Stage); err != nil {
+			continue
+		}
+
+		target, err := d.opts.Router.Route(ctx, job.Stage)
+		if err != nil {
+			// Leave the job pending until a worker is available
+			if !d.unroutable[job.ID] {
+				d.logger.Error("Job %d: not dispatching %s: %v", job.ID, job.Stage, err)
+				d.unroutable[job.ID] = true
+			}
+			continue
+		}
+		delete(d.unroutable, job.ID)
+
+		// The job's worker is where it is dispatched, so it can be cancelled
+		// there and counts against that host's limit
+		worker := d.workerID
+		if target != "" {
+			worker = target
+		}
+		claimed, err := d.queue.Claim(ctx, &job, worker)
+		if err != nil {
+			return started, err
+		}
+		if !claimed {
+			// The host's slots are taken, or another daemon got there first
+			continue
+		}
+
+		started++
+		jobCtx, cancel := context.WithCancel(ctx)
+		d.setInFlight(job.ID, cancel)
+		d.wg.Add(1)
+		go d.execute(jobCtx, job, target)
+	}
+
+	return started, nil
+}
+
+// pruneUnroutable forgets unroutable jobs that are no longer pending
+func (d *Daemon) pruneUnroutable(ctx context.Context) error {
+	if len(d.unroutable) == 0 {
+		return nil
+	}
+	pending, err := d.repo.ListJobsByStatus(ctx, model.JobStatusPending)
+	if err != nil {
+		return err
+	}
+	stillPending := make(map[int64]bool, len(pending))
+	for _, job := range pending {
+		stillPending[job.ID] = true
+	}
+	for id := range d.unroutable {
+		if !stillPending[id] {
+			delete(d.unroutable, id)
+		}
+	}
+	return nil
+}
+
+// stopCancelled stops the jobs this daemon is executing that were cancelled
+// before their stage process recorded a PID
+func (d *Daemon) stopCancelled(ctx context.Context) error {
+	running, err := d.repo.ListJobsByStatus(ctx, model.JobStatusInProgress)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, job := range running {
+		if cancel, ok := d.inFlight[job.ID]; ok && job.CancelRequested {
+			cancel()
+		}
+	}
+	return nil
+}
+
+// setInFlight records that this daemon is executing a job, or with a nil
+// cancel that it has finished
+func (d *Daemon) setInFlight(id int64, cancel context.CancelFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cancel != nil {
+		d.inFlight[id] = cancel
+		return
+	}
+	if stop, ok := d.inFlight[id]; ok {
+		stop()
+		delete(d.inFlight, id)
+	}
+}
+
+// inFlightJobs returns a snapshot of the jobs this daemon is executing
+func (d *Daemon) inFlightJobs() map[int64]bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make(map[int64]bool, len(d.inFlight))
+	for id := range d.inFlight {
+		ids[id] = true
+	}
+	return ids
+}
+
+// Wait blocks until all jobs started by Poll have finished
+func (d *Daemon) Wait() {
+	d.wg.Wait()
+}
+
+// execute runs a claimed job and records its result
+func (d *Daemon) execute(ctx context.Context, job model.Job, target string) {
+	defer d.wg.Done()
+	defer d.signalWake()
+	defer d.setInFlight(job.ID, nil)
+
+	if target == "" {
+		d.logger.Info("Job %d: starting %s", job.ID, job.Stage)
+	} else {
+		d.logger.Info("Job %d: starting %s on %s", job.ID, job.Stage, target)
+	}
+	launchErr := d.launcher.Launch(ctx, &job, target)
+
+	// Use a fresh context: ctx may already be cancelled by shutdown,
+	// and the outcome still has to be written
+	recordCtx := context.Background()
+
+	current, err := d.repo.GetJob(recordCtx, job.ID)
+	if err != nil || current == nil {
+		d.logger.Error("Job %d: failed to reload after run: %v", job.ID, err)
+		return
+	}
+
+	// Cancelled before the stage binary could record it itself
+	if current.IsActive() && current.CancelRequested {
+		if err := jobs.Finish(recordCtx, d.repo, current, model.JobStatusCancelled, "cancelled"); err != nil {
+			d.logger.Error("Job %d: failed to record cancel: %v", job.ID, err)
+			return
+		}
+		d.logger.Info("Job %d: %s cancelled", job.ID, job.Stage)
+		return
+	}
+
+	// The stage binary normally records its own result. If it didn't
+	// (crash, launch failure, killed), record the failure on its behalf.
+	if current.IsActive() {
+		kind, msg := model.FailureKindCrash, "stage process exited without recording a result"
+		if launchErr != nil {
+			msg = launchErr.Error()
+			var exitErr *exec.ExitError
+			if !errors.As(launchErr, &exitErr) {
+				kind = model.FailureKindLaunch
+			}
+		}
+		failed, err := jobs.Fail(recordCtx, d.repo, current, kind, msg)
+		if err != nil {
+			d.logger.Error("Job %d: failed to record failure: %v", job.ID, err)
+			return
+		}
+		if !failed {
+			// Finished by someone else in the meantime, who takes it from here
+			return
+		}
+		d.logger.Error("Job %d: %s failed: %s", job.ID, job.Stage, msg)
+		d.retry(recordCtx, current)
+		return
+	}
+
+	if err := jobs.RecordOutcome(recordCtx, d.repo, current); err != nil {
+		d.logger.Error("Job %d: failed to record outcome: %v", job.ID, err)
+	}
+	d.logger.Info("Job %d: %s %s", job.ID, job.Stage, current.Status)
+
+	if current.Status == model.JobStatusFailed {
+		// Failures recorded by anything but the stage itself (e.g. the
+		// reaper) were retried by whoever recorded them
+		if current.FailureKind == model.FailureKindStage {
+			d.retry(recordCtx, current)
+		}
+		return
+	}
+
+	next, err := jobs.Advance(recordCtx, d.repo, current)
+	if err != nil {
+		d.logger.Error("Job %d: autopilot failed to queue next stage: %v", job.ID, err)
+		return
+	}
+	if next != nil {
+		d.logger.Info("Job %d: autopilot queued %s as job %d", job.ID, next.Stage, next.ID)
+	}
+}
+
+// retry queues the next attempt of a failed job if its stage's policy allows it
+func (d *Daemon) retry(ctx context.Context, job *model.Job) {
+	next, err := jobs.Retry(ctx, d.repo, d.opts.Retry, job, time.Now())
+	if err != nil {
+		d.logger.Error("Job %d: failed to queue retry: %v", job.ID, err)
+		return
+	}
+	if next != nil {
+		d.logger.Info("Job %d: attempt %d of %s failed, queued attempt %d as job %d (not before %s)",
+			job.ID, job.Attempt, job.Stage, next.Attempt, next.ID, next.NotBefore.Local().Format(time.TimeOnly))
+	}
+}
+
+// signalWake asks Run to poll again; a pending signal is enough
+func (d *Daemon) signalWake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,474 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"github.com/cuivienor/media-pipeline/internal/queue"
+)
+
+// fakeLauncher records launched jobs and runs an optional function in place of a binary
+type fakeLauncher struct {
+	mu       sync.Mutex
+	launched []int64
+	targets  []string
+	run      func(ctx context.Context, job *model.Job) error
+}
+
+func (f *fakeLauncher) Launch(ctx context.Context, job *model.Job, target string) error {
+	f.mu.Lock()
+	f.launched = append(f.launched, job.ID)
+	f.targets = append(f.targets, target)
+	f.mu.Unlock()
+	if f.run != nil {
+		return f.run(ctx, job)
+	}
+	return nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// newTestRepo opens a file-backed database: the daemon runs jobs on
+// goroutines, which may use more than one connection
+func newTestRepo(t *testing.T) *db.SQLiteRepository {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "pipeline.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return db.NewSQLiteRepository(database)
+}
+
+func createPendingMovieJob(t *testing.T, repo db.Repository, stage model.Stage) (*model.MediaItem, *model.Job) {
+	t.Helper()
+	ctx := context.Background()
+	item := &model.MediaItem{
+		Type:         model.MediaTypeMovie,
+		Name:         "The Matrix",
+		SafeName:     "The_Matrix",
+		CurrentStage: stage,
+		StageStatus:  model.StatusInProgress,
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	job := &model.Job{
+		MediaItemID: item.ID,
+		Stage:       stage,
+		Status:      model.JobStatusPending,
+	}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	return item, job
+}
+
+func TestDaemon_Poll_RunsPendingJob(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, job := createPendingMovieJob(t, repo, model.StageRemux)
+
+	launcher := &fakeLauncher{
+		run: func(ctx context.Context, j *model.Job) error {
+			// Simulate the stage binary recording success
+			return repo.UpdateJobStatus(ctx, j.ID, model.JobStatusCompleted, "")
+		},
+	}
+	d := New(repo, launcher, nopLogger{}, Options{})
+
+	started, err := d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if started != 1 {
+		t.Fatalf("Poll() started = %d, want 1", started)
+	}
+	d.Wait()
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusCompleted {
+		t.Errorf("job status = %q, want completed", got.Status)
+	}
+
+	items, _ := repo.ListActiveItems(ctx)
+	if items[0].ID != item.ID || items[0].StageStatus != model.StatusCompleted {
+		t.Errorf("item stage status = %q, want completed", items[0].StageStatus)
+	}
+
+	// A second poll finds nothing left to do
+	started, err = d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if started != 0 {
+		t.Errorf("second Poll() started = %d, want 0", started)
+	}
+}
+
+func TestDaemon_Poll_AutopilotQueuesNextStage(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, _ := createPendingMovieJob(t, repo, model.StageRemux)
+	if err := repo.SetMediaItemAutopilot(ctx, item.ID, true); err != nil {
+		t.Fatalf("SetMediaItemAutopilot() error = %v", err)
+	}
+
+	launcher := &fakeLauncher{
+		run: func(ctx context.Context, j *model.Job) error {
+			return repo.UpdateJobStatus(ctx, j.ID, model.JobStatusCompleted, "")
+		},
+	}
+	d := New(repo, launcher, nopLogger{}, Options{})
+
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+
+	pending, err := repo.ListJobsByStatus(ctx, model.JobStatusPending)
+	if err != nil {
+		t.Fatalf("ListJobsByStatus() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Stage != model.StageTranscode {
+		t.Fatalf("pending jobs = %+v, want one transcode job", pending)
+	}
+
+	items, _ := repo.ListActiveItems(ctx)
+	if items[0].CurrentStage != model.StageTranscode || items[0].StageStatus != model.StatusInProgress {
+		t.Errorf("item = %s/%s, want transcode/in_progress", items[0].CurrentStage, items[0].StageStatus)
+	}
+}
+
+func TestDaemon_Poll_RecordsLaunchFailure(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	_, job := createPendingMovieJob(t, repo, model.StageTranscode)
+
+	launcher := &fakeLauncher{
+		run: func(ctx context.Context, j *model.Job) error {
+			return errors.New("transcode: exit status 2")
+		},
+	}
+	d := New(repo, launcher, nopLogger{}, Options{})
+
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusFailed {
+		t.Errorf("job status = %q, want failed", got.Status)
+	}
+	if got.ErrorMessage != "transcode: exit status 2" {
+		t.Errorf("error message = %q, want launch error", got.ErrorMessage)
+	}
+
+	items, _ := repo.ListActiveItems(ctx)
+	if items[0].StageStatus != model.StatusFailed {
+		t.Errorf("item stage status = %q, want failed", items[0].StageStatus)
+	}
+}
+
+func TestDaemon_Poll_RetriesCrashedJob(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, job := createPendingMovieJob(t, repo, model.StageRemux)
+
+	// The binary exits without recording a result
+	launcher := &fakeLauncher{}
+	d := New(repo, launcher, nopLogger{}, Options{
+		Retry: jobs.RetryPolicies{
+			model.StageRemux: {MaxAttempts: 2, Backoff: time.Hour, Retryable: []model.FailureKind{model.FailureKindCrash}},
+		},
+	})
+
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusFailed || got.FailureKind != model.FailureKindCrash {
+		t.Errorf("job = %s %q, want failed crash", got.Status, got.FailureKind)
+	}
+
+	history, _ := repo.ListJobsForMedia(ctx, item.ID)
+	if len(history) != 2 {
+		t.Fatalf("got %d jobs, want the failed attempt and its retry", len(history))
+	}
+	retry := history[1]
+	if retry.Status != model.JobStatusPending || retry.Attempt != 2 || retry.RunID != job.ID {
+		t.Errorf("retry = %s attempt %d run %d, want pending attempt 2 of run %d", retry.Status, retry.Attempt, retry.RunID, job.ID)
+	}
+
+	// The retry waits out its backoff
+	started, err := d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+	if started != 0 {
+		t.Errorf("Poll() started %d jobs during backoff, want 0", started)
+	}
+}
+
+func TestDaemon_Poll_DoesNotRetryJobFailedElsewhere(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, _ := createPendingMovieJob(t, repo, model.StageRemux)
+
+	policies := jobs.RetryPolicies{
+		model.StageRemux: {MaxAttempts: 3, Backoff: time.Hour, Retryable: []model.FailureKind{model.FailureKindCrash, model.FailureKindVanished}},
+	}
+	launcher := &fakeLauncher{
+		run: func(ctx context.Context, j *model.Job) error {
+			// Another daemon's reaper fails and retries the job while it runs
+			current, _ := repo.GetJob(ctx, j.ID)
+			if _, err := jobs.Fail(ctx, repo, current, model.FailureKindVanished, "worker vanished"); err != nil {
+				return err
+			}
+			if _, err := jobs.Retry(ctx, repo, policies, current, time.Now()); err != nil {
+				return err
+			}
+			return errors.New("exit status 1")
+		},
+	}
+	d := New(repo, launcher, nopLogger{}, Options{Retry: policies})
+
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+
+	history, _ := repo.ListJobsForMedia(ctx, item.ID)
+	if len(history) != 2 {
+		t.Fatalf("got %d jobs, want the failed attempt and a single retry", len(history))
+	}
+	if history[0].FailureKind != model.FailureKindVanished {
+		t.Errorf("FailureKind = %q, want vanished", history[0].FailureKind)
+	}
+}
+
+// fakeRouter routes every stage to target, or fails with err
+type fakeRouter struct {
+	target string
+	err    error
+}
+
+func (r fakeRouter) Route(context.Context, model.Stage) (string, error) { return r.target, r.err }
+
+func TestDaemon_Poll_Routing(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	_, job := createPendingMovieJob(t, repo, model.StageRemux)
+
+	// No worker: the job stays pending
+	launcher := &fakeLauncher{}
+	d := New(repo, launcher, nopLogger{}, Options{
+		Router: fakeRouter{err: errors.New("no worker available for remux: analyzer is offline")},
+	})
+	started, err := d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if started != 0 {
+		t.Errorf("Poll() started %d jobs without a worker, want 0", started)
+	}
+	if got, _ := repo.GetJob(ctx, job.ID); got.Status != model.JobStatusPending {
+		t.Errorf("job status = %q, want pending", got.Status)
+	}
+
+	// Worker found: the job is launched there
+	d.opts.Router = fakeRouter{target: "spare"}
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+	if len(launcher.targets) != 1 || launcher.targets[0] != "spare" {
+		t.Errorf("launched on %v, want [spare]", launcher.targets)
+	}
+	// The job records where it was dispatched, e.g. for cancelling it there
+	if got, _ := repo.GetJob(ctx, job.ID); got.WorkerID != "spare" {
+		t.Errorf("job worker = %q, want spare", got.WorkerID)
+	}
+}
+
+func TestDaemon_Poll_ForgetsUnroutableJobsNoLongerPending(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	_, job := createPendingMovieJob(t, repo, model.StageRemux)
+
+	d := New(repo, &fakeLauncher{}, nopLogger{}, Options{
+		Router: fakeRouter{err: errors.New("no worker available")},
+	})
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if !d.unroutable[job.ID] {
+		t.Fatalf("job %d not recorded as unroutable", job.ID)
+	}
+
+	if cancelled, err := repo.CancelPendingJob(ctx, job.ID); err != nil || !cancelled {
+		t.Fatalf("CancelPendingJob() = %v, %v", cancelled, err)
+	}
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(d.unroutable) != 0 {
+		t.Errorf("unroutable = %v after the job was cancelled, want empty", d.unroutable)
+	}
+}
+
+func TestDaemon_Poll_SkipsClaimedJobs(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	_, job := createPendingMovieJob(t, repo, model.StageRemux)
+
+	// Someone else claims the job between listing and claiming
+	claimed, err := repo.ClaimJob(ctx, job.ID, "other-host", 4242, 0)
+	if err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+
+	launcher := &fakeLauncher{}
+	d := New(repo, launcher, nopLogger{}, Options{})
+	started, err := d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+
+	if started != 0 || len(launcher.launched) != 0 {
+		t.Errorf("started = %d, launched = %v, want nothing", started, launcher.launched)
+	}
+}
+
+func TestDaemon_Poll_RespectsConcurrencyLimit(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	_, first := createPendingMovieJob(t, repo, model.StageTranscode)
+	_, second := createPendingMovieJob(t, repo, model.StageTranscode)
+
+	release := make(chan struct{})
+	launcher := &fakeLauncher{
+		run: func(ctx context.Context, j *model.Job) error {
+			<-release
+			j.Status = model.JobStatusCompleted
+			return repo.UpdateJob(context.Background(), j)
+		},
+	}
+	d := New(repo, launcher, nopLogger{}, Options{
+		Limits: queue.Limits{model.StageTranscode: 1},
+	})
+
+	started, err := d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if started != 1 {
+		t.Fatalf("first Poll() started = %d, want 1", started)
+	}
+
+	// The slot is still taken
+	started, err = d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if started != 0 {
+		t.Fatalf("second Poll() started = %d, want 0", started)
+	}
+
+	close(release)
+	d.Wait()
+
+	started, err = d.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+	if started != 1 {
+		t.Fatalf("third Poll() started = %d, want 1", started)
+	}
+
+	if len(launcher.launched) != 2 || launcher.launched[0] != first.ID || launcher.launched[1] != second.ID {
+		t.Errorf("launched = %v, want [%d %d]", launcher.launched, first.ID, second.ID)
+	}
+}
+
+func TestDaemon_Poll_StopsJobCancelledWhileStarting(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, job := createPendingMovieJob(t, repo, model.StageTranscode)
+
+	launched := make(chan struct{})
+	launcher := &fakeLauncher{
+		run: func(ctx context.Context, j *model.Job) error {
+			// Still starting: no PID recorded until ctx is cancelled
+			close(launched)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	d := New(repo, launcher, nopLogger{}, Options{})
+
+	if started, err := d.Poll(ctx); err != nil || started != 1 {
+		t.Fatalf("Poll() = %d, %v; want 1 job started", started, err)
+	}
+	<-launched
+
+	running, _ := repo.GetJob(ctx, job.ID)
+	if err := jobs.Cancel(ctx, repo, &config.Config{}, running); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	if _, err := d.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	d.Wait()
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusCancelled {
+		t.Errorf("job status = %q, want cancelled", got.Status)
+	}
+	items, _ := repo.ListActiveItems(ctx)
+	if items[0].ID != item.ID || items[0].StageStatus != model.StatusPending {
+		t.Errorf("item stage status = %q, want pending", items[0].StageStatus)
+	}
+}
+
+func TestBinaryName(t *testing.T) {
+	tests := []struct {
+		stage   model.Stage
+		want    string
+		wantErr bool
+	}{
+		{model.StageRip, "ripper", false},
+		{model.StageOrganize, "", true},
+		{model.StageRemux, "remux", false},
+		{model.StageTranscode, "transcode", false},
+		{model.StagePublish, "publish", false},
+	}
+
+	for _, tt := range tests {
+		got, err := BinaryName(tt.stage)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("BinaryName(%s) error = %v, wantErr %v", tt.stage, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("BinaryName(%s) = %q, want %q", tt.stage, got, tt.want)
+		}
+	}
+}
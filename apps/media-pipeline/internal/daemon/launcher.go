@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Launcher runs the stage binary for a job and blocks until it exits.
+// target is the SSH target to run it on, or "" for this host.
+type Launcher interface {
+	Launch(ctx context.Context, job *model.Job, target string) error
+}
+
+// killGracePeriod is how long a stage process gets to exit after SIGTERM
+// before it is killed outright
+const killGracePeriod = 30 * time.Second
+
+// ExecLauncher runs stage binaries as local child processes, or over SSH
+// when routed to another host
+type ExecLauncher struct {
+	cfg *config.Config
+}
+
+// NewExecLauncher creates a launcher for the given configuration
+func NewExecLauncher(cfg *config.Config) *ExecLauncher {
+	return &ExecLauncher{cfg: cfg}
+}
+
+// BinaryName returns the name of the binary that executes a stage
+func BinaryName(stage model.Stage) (string, error) {
+	switch stage {
+	case model.StageRip:
+		return "ripper", nil
+	case model.StageRemux, model.StageTranscode, model.StagePublish:
+		return stage.String(), nil
+	default:
+		return "", fmt.Errorf("stage %s has no binary", stage)
+	}
+}
+
+// BinaryPath returns the path of the binary that executes a stage on this host
+func BinaryPath(stage model.Stage) (string, error) {
+	name, err := BinaryName(stage)
+	if err != nil {
+		return "", err
+	}
+	return siblingPath(name), nil
+}
+
+// Launch runs the job's stage binary to completion.
+// Cancelling ctx sends SIGTERM to the process.
+func (l *ExecLauncher) Launch(ctx context.Context, job *model.Job, target string) error {
+	binaryName, err := BinaryName(job.Stage)
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"-job-id", fmt.Sprintf("%d", job.ID),
+		"-db", l.cfg.DatabasePath(),
+	}
+
+	var cmd *exec.Cmd
+	if target == "" {
+		cmd = exec.CommandContext(ctx, siblingPath(binaryName), args...)
+	} else {
+		// SSH dispatch - assume the binary is in PATH on the remote
+		sshArgs := append([]string{target, binaryName}, args...)
+		cmd = exec.CommandContext(ctx, "ssh", sshArgs...)
+	}
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = killGracePeriod
+
+	// Stdout goes to the job log already; keep stderr for the failure reason
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := lastLine(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", binaryName, err, msg)
+		}
+		return fmt.Errorf("%s: %w", binaryName, err)
+	}
+	return nil
+}
+
+// siblingPath looks for a binary next to the current executable,
+// falling back to a PATH lookup by name
+func siblingPath(name string) string {
+	if exe, err := os.Executable(); err == nil {
+		path := filepath.Join(filepath.Dir(exe), name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return name
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
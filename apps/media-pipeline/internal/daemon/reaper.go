@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"github.com/cuivienor/media-pipeline/internal/workers"
+)
+
+// Reaper fails in_progress jobs whose process has died on this host,
+// e.g. after a crash or container reboot, and jobs on other hosts that have
+// stopped sending heartbeats
+type Reaper struct {
+	repo     db.Repository
+	logger   Logger
+	workerID string
+	since    time.Time
+	alive    func(pid int) bool
+	now      func() time.Time
+	retry    jobs.RetryPolicies
+}
+
+// NewReaper creates a reaper for jobs owned by the local host.
+// Reaped jobs are retried according to retry.
+func NewReaper(repo db.Repository, logger Logger, retry jobs.RetryPolicies) *Reaper {
+	return &Reaper{
+		repo:     repo,
+		logger:   logger,
+		retry:    retry,
+		workerID: jobs.LocalWorkerID(),
+		// Job timestamps have second precision
+		since: time.Now().Truncate(time.Second),
+		alive: processAlive,
+		now:   time.Now,
+	}
+}
+
+// Reap marks every in_progress job on this host with a dead PID as failed.
+// A job claimed by a daemon has no PID until its stage binary starts; one
+// claimed before this reaper was created was left behind by a previous
+// daemon and is reaped too, as is a job with no worker at all from before
+// workers were recorded. Jobs on other hosts are reaped once their host's
+// heartbeat has gone offline; hosts that never sent one are left alone.
+// Jobs in skip are being executed by this daemon. Returns the number of
+// jobs reaped.
+func (r *Reaper) Reap(ctx context.Context, skip map[int64]bool) (int, error) {
+	running, err := r.repo.ListJobsByStatus(ctx, model.JobStatusInProgress)
+	if err != nil {
+		return 0, err
+	}
+	registry, err := r.repo.ListWorkers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := r.now()
+
+	reaped := 0
+	for i := range running {
+		job := &running[i]
+		if skip[job.ID] {
+			continue
+		}
+
+		var msg string
+		switch {
+		case job.WorkerID == "":
+			if job.StartedAt != nil && !job.StartedAt.Before(r.since) {
+				continue
+			}
+			msg = "worker vanished: job has no recorded worker and was started before the daemon"
+		case job.WorkerID != r.workerID:
+			worker := findWorker(registry, job.WorkerID)
+			if worker == nil || worker.Online(now, workers.OfflineAfter) {
+				continue
+			}
+			msg = fmt.Sprintf("worker vanished: %s has been offline since %s", worker.Hostname, worker.LastSeen.Local().Format(time.DateTime))
+		case job.PID > 0 && !r.alive(job.PID):
+			msg = fmt.Sprintf("worker vanished: process %d on %s is no longer running", job.PID, job.WorkerID)
+		case job.PID <= 0 && job.StartedAt != nil && job.StartedAt.Before(r.since):
+			msg = fmt.Sprintf("worker vanished: job was claimed on %s but never started", job.WorkerID)
+		default:
+			continue
+		}
+
+		failed, err := jobs.Fail(ctx, r.repo, job, model.FailureKindVanished, msg)
+		if err != nil {
+			return reaped, fmt.Errorf("failed to reap job %d: %w", job.ID, err)
+		}
+		if !failed {
+			// Finished since it was listed
+			continue
+		}
+		r.logger.Error("Job %d: %s", job.ID, msg)
+		reaped++
+
+		next, err := jobs.Retry(ctx, r.repo, r.retry, job, time.Now())
+		if err != nil {
+			return reaped, fmt.Errorf("failed to retry job %d: %w", job.ID, err)
+		}
+		if next != nil {
+			r.logger.Info("Job %d: queued attempt %d as job %d", job.ID, next.Attempt, next.ID)
+		}
+	}
+
+	return reaped, nil
+}
+
+// findWorker returns the registered worker a job's worker ID leads to, or nil
+func findWorker(registry []model.Worker, workerID string) *model.Worker {
+	for i := range registry {
+		if workers.ReachedBy(&registry[i], workerID) {
+			return &registry[i]
+		}
+	}
+	return nil
+}
+
+// processAlive reports whether a process with the given PID exists.
+// EPERM means it exists but belongs to another user.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
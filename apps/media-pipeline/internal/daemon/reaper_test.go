@@ -0,0 +1,237 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestReaper_Reap(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	const (
+		deadPID  = 1001
+		alivePID = 1002
+	)
+
+	r := NewReaper(repo, nopLogger{}, nil)
+	r.workerID = "analyzer"
+	r.alive = func(pid int) bool { return pid == alivePID }
+
+	tests := []struct {
+		name       string
+		workerID   string
+		pid        int
+		inFlight   bool
+		wantStatus model.JobStatus
+	}{
+		{"dead process on this host", "analyzer", deadPID, false, model.JobStatusFailed},
+		{"live process on this host", "analyzer", alivePID, false, model.JobStatusInProgress},
+		{"process on another host", "transcoder", deadPID, false, model.JobStatusInProgress},
+		{"no recorded PID", "analyzer", 0, false, model.JobStatusInProgress},
+		{"executed by this daemon", "analyzer", deadPID, true, model.JobStatusInProgress},
+		{"no worker, started after the daemon", "", 0, false, model.JobStatusInProgress},
+	}
+
+	skip := make(map[int64]bool)
+	ids := make([]int64, len(tests))
+	items := make([]int64, len(tests))
+	for i, tt := range tests {
+		item, job := createPendingMovieJob(t, repo, model.StageRemux)
+		claimed, err := repo.ClaimJob(ctx, job.ID, tt.workerID, tt.pid, 0)
+		if err != nil || !claimed {
+			t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+		}
+		ids[i] = job.ID
+		items[i] = item.ID
+		skip[job.ID] = tt.inFlight
+	}
+
+	reaped, err := r.Reap(ctx, skip)
+	if err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if reaped != 1 {
+		t.Errorf("Reap() = %d, want 1", reaped)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := repo.GetJob(ctx, ids[i])
+			if got.Status != tt.wantStatus {
+				t.Errorf("job status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if tt.wantStatus != model.JobStatusFailed {
+				return
+			}
+			if !strings.HasPrefix(got.ErrorMessage, "worker vanished") {
+				t.Errorf("error message = %q, want worker vanished reason", got.ErrorMessage)
+			}
+			active, _ := repo.ListActiveItems(ctx)
+			for _, item := range active {
+				if item.ID == items[i] && item.StageStatus != model.StatusFailed {
+					t.Errorf("item stage status = %q, want failed", item.StageStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestReaper_Reap_OrphanedClaim(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	_, job := createPendingMovieJob(t, repo, model.StageTranscode)
+	claimed, err := repo.ClaimJob(ctx, job.ID, "analyzer", 0, 0)
+	if err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+
+	r := NewReaper(repo, nopLogger{}, nil)
+	r.workerID = "analyzer"
+
+	// Claimed by this daemon: the stage binary may still be starting
+	if reaped, _ := r.Reap(ctx, nil); reaped != 0 {
+		t.Fatalf("Reap() = %d for a fresh claim, want 0", reaped)
+	}
+
+	// Claimed before this daemon started: nobody is going to launch it
+	r.since = time.Now().Add(time.Hour)
+	reaped, err := r.Reap(ctx, nil)
+	if err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if reaped != 1 {
+		t.Fatalf("Reap() = %d for an orphaned claim, want 1", reaped)
+	}
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusFailed {
+		t.Errorf("job status = %q, want failed", got.Status)
+	}
+}
+
+func TestReaper_Reap_JobWithoutWorker(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	// Left in_progress before jobs recorded their worker
+	_, job := createPendingMovieJob(t, repo, model.StageRemux)
+	if err := repo.UpdateJobStatus(ctx, job.ID, model.JobStatusInProgress, ""); err != nil {
+		t.Fatalf("UpdateJobStatus() error = %v", err)
+	}
+
+	r := NewReaper(repo, nopLogger{}, nil)
+	r.workerID = "analyzer"
+	r.since = time.Now().Add(time.Hour)
+
+	reaped, err := r.Reap(ctx, nil)
+	if err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if reaped != 1 {
+		t.Fatalf("Reap() = %d, want 1", reaped)
+	}
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusFailed || got.FailureKind != model.FailureKindVanished {
+		t.Errorf("job = %s/%s, want failed/vanished", got.Status, got.FailureKind)
+	}
+}
+
+func TestReaper_Reap_OfflineRemoteWorker(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, w := range []model.Worker{
+		{Hostname: "transcoder", Target: "media@transcoder", LastSeen: now.Add(-time.Hour)},
+		{Hostname: "spare", LastSeen: now},
+	} {
+		if err := repo.UpsertWorker(ctx, &w); err != nil {
+			t.Fatalf("UpsertWorker() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		workerID   string
+		wantStatus model.JobStatus
+	}{
+		{"offline worker", "media@transcoder", model.JobStatusFailed},
+		{"online worker", "media@spare", model.JobStatusInProgress},
+		{"worker never registered", "media@elsewhere", model.JobStatusInProgress},
+	}
+
+	ids := make([]int64, len(tests))
+	for i, tt := range tests {
+		_, job := createPendingMovieJob(t, repo, model.StageTranscode)
+		if claimed, err := repo.ClaimJob(ctx, job.ID, tt.workerID, 4242, 0); err != nil || !claimed {
+			t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+		}
+		ids[i] = job.ID
+	}
+
+	r := NewReaper(repo, nopLogger{}, nil)
+	r.workerID = "analyzer"
+	reaped, err := r.Reap(ctx, nil)
+	if err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if reaped != 1 {
+		t.Errorf("Reap() = %d, want 1", reaped)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := repo.GetJob(ctx, ids[i])
+			if got.Status != tt.wantStatus {
+				t.Errorf("job status = %q, want %q", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReaper_Reap_RetriesVanishedJob(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	item, job := createPendingMovieJob(t, repo, model.StageTranscode)
+	claimed, err := repo.ClaimJob(ctx, job.ID, "analyzer", 1001, 0)
+	if err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+
+	r := NewReaper(repo, nopLogger{}, jobs.RetryPolicies{
+		model.StageTranscode: {MaxAttempts: 3, Backoff: time.Minute, Retryable: []model.FailureKind{model.FailureKindVanished}},
+	})
+	r.workerID = "analyzer"
+	r.alive = func(int) bool { return false }
+
+	if _, err := r.Reap(ctx, nil); err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+
+	history, _ := repo.ListJobsForMedia(ctx, item.ID)
+	if len(history) != 2 {
+		t.Fatalf("got %d jobs, want the reaped attempt and its retry", len(history))
+	}
+	if history[0].FailureKind != model.FailureKindVanished {
+		t.Errorf("FailureKind = %q, want vanished", history[0].FailureKind)
+	}
+	if history[1].Status != model.JobStatusPending || history[1].Attempt != 2 {
+		t.Errorf("retry = %s attempt %d, want pending attempt 2", history[1].Status, history[1].Attempt)
+	}
+}
+
+func TestProcessAlive(t *testing.T) {
+	if !processAlive(os.Getpid()) {
+		t.Error("processAlive(self) = false, want true")
+	}
+}
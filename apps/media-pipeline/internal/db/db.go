@@ -1,11 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
-	"io/fs"
-	"sort"
 	"strings"
 
 	_ "modernc.org/sqlite"
@@ -16,31 +15,82 @@ var migrations embed.FS
 
 // DB wraps a SQLite database connection
 type DB struct {
-	db *sql.DB
+	db   *sql.DB
+	path string
 }
 
-// Open opens a SQLite database at the given path
+// busyTimeoutMillis is how long a connection waits on another connection's
+// lock before failing with SQLITE_BUSY. The daemon, stage binaries and TUI
+// all write the same file.
+const busyTimeoutMillis = 5000
+
+// maxOpenConns bounds the pool of a database file. In WAL mode readers never
+// wait on the writer, so a few connections keep the TUI's reads from queuing
+// behind progress writes, while only one of them can write at a time anyway.
+const maxOpenConns = 4
+
+// Open opens a SQLite database at the given path and applies pending
+// migrations
 func Open(path string) (*DB, error) {
-	db, err := sql.Open("sqlite", path)
+	database, err := OpenUnmigrated(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := database.MigrateUp(context.Background()); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return database, nil
+}
+
+// OpenUnmigrated opens a SQLite database without applying migrations, for
+// inspecting or rolling back its schema
+func OpenUnmigrated(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", dataSourceName(path))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	if isMemory(path) {
+		// Every connection to :memory: opens a separate, empty database
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(maxOpenConns)
+		db.SetMaxIdleConns(maxOpenConns)
 	}
 
-	database := &DB{db: db}
-	if err := database.migrate(); err != nil {
+	database := &DB{db: db, path: path}
+	if err := database.ensureMigrationsTable(context.Background()); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+		return nil, err
 	}
-
 	return database, nil
 }
 
+// dataSourceName adds the connection pragmas to a database path, so every
+// connection in the pool gets them:
+//   - WAL journaling, so readers and the writer do not block each other
+//   - synchronous NORMAL, which is durable across process crashes in WAL mode
+//   - the busy timeout, and foreign keys
+//   - BEGIN IMMEDIATE, so a transaction waits for the write lock up front
+//     instead of failing when it upgrades from reading to writing
+func dataSourceName(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	pragmas := fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)&_txlock=immediate", busyTimeoutMillis)
+	if !isMemory(path) {
+		pragmas = "_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&" + pragmas
+	}
+	return path + sep + pragmas
+}
+
+// isMemory reports whether path is an in-memory database
+func isMemory(path string) bool {
+	return strings.HasPrefix(path, ":memory:")
+}
+
 // OpenInMemory opens an in-memory SQLite database for testing
 func OpenInMemory() (*DB, error) {
 	return Open(":memory:")
@@ -51,60 +101,22 @@ func (d *DB) Close() error {
 	return d.db.Close()
 }
 
-// migrate runs all SQL migrations
-func (d *DB) migrate() error {
-	// Create migrations tracking table if it doesn't exist
-	_, err := d.db.Exec(`
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			version TEXT PRIMARY KEY,
-			applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+// SchemaVersion returns the last migration applied to the database, and the
+// last one this binary knows about
+func (d *DB) SchemaVersion() (applied, latest string, err error) {
+	var version sql.NullString
+	if err := d.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return "", "", fmt.Errorf("failed to read schema version: %w", err)
 	}
 
-	entries, err := fs.ReadDir(migrations, "migrations")
+	known, err := loadMigrations()
 	if err != nil {
-		return fmt.Errorf("failed to read migrations: %w", err)
+		return "", "", err
 	}
-
-	// Sort by filename to ensure order
-	var files []string
-	for _, entry := range entries {
-		if strings.HasSuffix(entry.Name(), ".sql") {
-			files = append(files, entry.Name())
+	for _, m := range known {
+		if m.Version > latest {
+			latest = m.Version
 		}
 	}
-	sort.Strings(files)
-
-	for _, file := range files {
-		// Check if migration has already been applied
-		var count int
-		err := d.db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", file).Scan(&count)
-		if err != nil {
-			return fmt.Errorf("failed to check migration status for %s: %w", file, err)
-		}
-		if count > 0 {
-			// Migration already applied, skip
-			continue
-		}
-
-		content, err := fs.ReadFile(migrations, "migrations/"+file)
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", file, err)
-		}
-
-		if _, err := d.db.Exec(string(content)); err != nil {
-			return fmt.Errorf("failed to execute %s: %w", file, err)
-		}
-
-		// Record that migration was applied
-		_, err = d.db.Exec("INSERT INTO schema_migrations (version) VALUES (?)", file)
-		if err != nil {
-			return fmt.Errorf("failed to record migration %s: %w", file, err)
-		}
-	}
-
-	return nil
+	return version.String, latest, nil
 }
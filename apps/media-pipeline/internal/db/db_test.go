@@ -63,6 +63,41 @@ func TestOpen_ForeignKeysEnabled(t *testing.T) {
 	}
 }
 
+func TestOpen_BusyTimeoutSet(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "pipeline.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+
+	var timeout int
+	if err := database.db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if timeout != busyTimeoutMillis {
+		t.Errorf("busy_timeout = %d, want %d", timeout, busyTimeoutMillis)
+	}
+}
+
+func TestOpen_WALEnabled(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "pipeline.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+
+	var mode string
+	if err := database.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+	if got := database.db.Stats().MaxOpenConnections; got != maxOpenConns {
+		t.Errorf("MaxOpenConnections = %d, want %d", got, maxOpenConns)
+	}
+}
+
 func TestOpen_IndexesCreated(t *testing.T) {
 	database, err := OpenInMemory()
 	if err != nil {
@@ -139,3 +174,39 @@ func TestOpen_ReopensExistingDatabase(t *testing.T) {
 		t.Errorf("jobs table not found in reopened database")
 	}
 }
+
+func TestOpen_JobsRebuildKeepsLogEvents(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db1, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	statements := []string{
+		"INSERT INTO media_items (id, type, name, safe_name) VALUES (1, 'movie', 'Test', 'Test')",
+		"INSERT INTO jobs (id, media_item_id, stage, status) VALUES (1, 1, 'rip', 'completed')",
+		"INSERT INTO log_events (job_id, level, message) VALUES (1, 'info', 'ripped')",
+		// Force 007 to run again against the populated database
+		"DELETE FROM schema_migrations WHERE version = '007_job_cancelled.sql'",
+	}
+	for _, stmt := range statements {
+		if _, err := db1.db.Exec(stmt); err != nil {
+			t.Fatalf("Exec(%q) error = %v", stmt, err)
+		}
+	}
+	db1.Close()
+
+	db2, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	defer db2.Close()
+
+	var count int
+	if err := db2.db.QueryRow("SELECT COUNT(*) FROM log_events WHERE job_id = 1").Scan(&count); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("log_events count = %d after jobs rebuild, want 1", count)
+	}
+}
@@ -0,0 +1,241 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Export is a portable copy of the pipeline's history: every row of the
+// tables below, keyed by column name, with the IDs that link them. Job
+// options are inlined as JSON rather than the string they are stored as.
+type Export struct {
+	Schema         string    `json:"schema"` // Last migration applied to the exported database
+	ExportedAt     time.Time `json:"exported_at"`
+	MediaItems     []Row     `json:"media_items"`
+	Seasons        []Row     `json:"seasons"`
+	Jobs           []Row     `json:"jobs"`
+	TranscodeFiles []Row     `json:"transcode_files"`
+	Transitions    []Row     `json:"stage_transitions,omitempty"`
+}
+
+// Row is a table row, keyed by column name
+type Row map[string]interface{}
+
+// exportTables are the exported tables, parents before children
+var exportTables = []string{"media_items", "seasons", "jobs", "transcode_files", "stage_transitions"}
+
+// rows returns the rows of a table in an export
+func (e *Export) rows(table string) *[]Row {
+	switch table {
+	case "media_items":
+		return &e.MediaItems
+	case "seasons":
+		return &e.Seasons
+	case "jobs":
+		return &e.Jobs
+	case "stage_transitions":
+		return &e.Transitions
+	default:
+		return &e.TranscodeFiles
+	}
+}
+
+// Export reads the items, seasons, jobs, transcode files and transitions into a portable
+// export, in a single read transaction so it is consistent
+func (d *DB) Export(ctx context.Context) (*Export, error) {
+	schema, _, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	export := &Export{Schema: schema, ExportedAt: time.Now().UTC()}
+	for _, table := range exportTables {
+		rows, err := exportRows(ctx, tx, table)
+		if err != nil {
+			return nil, err
+		}
+		*export.rows(table) = rows
+	}
+	return export, nil
+}
+
+func exportRows(ctx context.Context, tx *sql.Tx, table string) ([]Row, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT * FROM `+table+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+
+	out := []Row{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+
+		row := make(Row, len(columns))
+		for i, column := range columns {
+			value := values[i]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			// Inline job options so they can be queried as JSON
+			if s, ok := value.(string); ok && table == "jobs" && column == "options" && json.Valid([]byte(s)) {
+				value = json.RawMessage(s)
+			}
+			row[column] = value
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// Import loads an export into a database that has no items yet, keeping the
+// exported IDs. Columns the export lacks get their defaults, so exports from
+// older versions load; columns this version does not know are an error.
+func (d *DB) Import(ctx context.Context, export *Export) error {
+	var count int
+	if err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM media_items`).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count media items: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("database already has %d media items: import into a new database", count)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range exportTables {
+		known, err := tableColumns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		for _, row := range *export.rows(table) {
+			if err := importRow(ctx, tx, table, known, row); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+	return nil
+}
+
+func importRow(ctx context.Context, tx *sql.Tx, table string, known map[string]bool, row Row) error {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		if !known[column] {
+			return fmt.Errorf("%s has no column %q: the export is from a newer version", table, column)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		value, err := importValue(row[column])
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", table, column, err)
+		}
+		args[i] = value
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, table,
+		strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to import %s row %v: %w", table, row["id"], err)
+	}
+	return nil
+}
+
+// importValue converts a decoded JSON value back to what the column stores
+func importValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+		return v, nil
+	case json.RawMessage:
+		return string(v), nil
+	case map[string]interface{}, []interface{}:
+		// Inlined job options go back to their JSON string
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	default:
+		return v, nil
+	}
+}
+
+func tableColumns(ctx context.Context, tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan %s column: %w", table, err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// ReadExport decodes an export, keeping integers exact
+func ReadExport(r io.Reader) (*Export, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var export Export
+	if err := dec.Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse export: %w", err)
+	}
+	return &export, nil
+}
+
+// Backup writes a consistent snapshot of the database to path with VACUUM
+// INTO, which is safe while other processes are writing. path must not
+// exist yet.
+func (d *DB) Backup(ctx context.Context, path string) error {
+	if _, err := d.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to back up database to %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// seedHistory creates a TV show with a season, a transcode job with options
+// and a transcode file
+func seedHistory(t *testing.T, repo *SQLiteRepository) (*model.MediaItem, *model.Job) {
+	t.Helper()
+	ctx := context.Background()
+
+	tvdbID := 81189
+	item := &model.MediaItem{Type: model.MediaTypeTV, Name: "Breaking Bad", SafeName: "Breaking_Bad", TvdbID: &tvdbID}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	season := &model.Season{ItemID: item.ID, Number: 1, CurrentStage: model.StageTranscode, StageStatus: model.StatusInProgress, DiscCount: 3}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason() error = %v", err)
+	}
+	job := &model.Job{MediaItemID: item.ID, SeasonID: &season.ID, Stage: model.StageTranscode, Status: model.JobStatusFailed,
+		ErrorMessage: "ffmpeg exited 1", FailureKind: model.FailureKindStage}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if err := repo.SetJobOptions(ctx, job.ID, map[string]interface{}{"crf": 18, "mode": "hardware"}); err != nil {
+		t.Fatalf("SetJobOptions() error = %v", err)
+	}
+	file := &model.TranscodeFile{JobID: job.ID, RelativePath: "S01E01.mkv", Status: model.TranscodeFileStatusCompleted,
+		InputSize: 4 << 30, DurationSecs: 2820.5}
+	if err := repo.CreateTranscodeFile(ctx, file); err != nil {
+		t.Fatalf("CreateTranscodeFile() error = %v", err)
+	}
+	return item, job
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	src, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer src.Close()
+	srcRepo := NewSQLiteRepository(src)
+	item, job := seedHistory(t, srcRepo)
+	ctx := context.Background()
+
+	export, err := src.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !strings.HasPrefix(export.Schema, "0") || len(export.MediaItems) != 1 || len(export.Jobs) != 1 || len(export.TranscodeFiles) != 1 {
+		t.Fatalf("Export() = schema %q with %d items, %d jobs, %d files", export.Schema, len(export.MediaItems), len(export.Jobs), len(export.TranscodeFiles))
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(export); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	// Job options are inlined as JSON, not a string
+	if !strings.Contains(buf.String(), `"options":{"crf":18,"mode":"hardware"}`) {
+		t.Errorf("export does not inline job options:\n%s", buf.String())
+	}
+
+	decoded, err := ReadExport(&buf)
+	if err != nil {
+		t.Fatalf("ReadExport() error = %v", err)
+	}
+	dst, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer dst.Close()
+	if err := dst.Import(ctx, decoded); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	dstRepo := NewSQLiteRepository(dst)
+	wantItem, _ := srcRepo.GetMediaItem(ctx, item.ID)
+	gotItem, _ := dstRepo.GetMediaItem(ctx, item.ID)
+	if !reflect.DeepEqual(gotItem, wantItem) {
+		t.Errorf("imported item = %+v, want %+v", gotItem, wantItem)
+	}
+	wantJob, _ := srcRepo.GetJob(ctx, job.ID)
+	gotJob, _ := dstRepo.GetJob(ctx, job.ID)
+	if !reflect.DeepEqual(gotJob, wantJob) {
+		t.Errorf("imported job = %+v, want %+v", gotJob, wantJob)
+	}
+	options, _ := dstRepo.GetJobOptions(ctx, job.ID)
+	if options["mode"] != "hardware" || options["crf"] != float64(18) {
+		t.Errorf("imported options = %v, want crf 18 and hardware", options)
+	}
+	seasons, _ := dstRepo.ListSeasonsForItem(ctx, item.ID)
+	if len(seasons) != 1 || seasons[0].DiscCount != 3 || seasons[0].CurrentStage != model.StageTranscode {
+		t.Errorf("imported seasons = %+v, want season 1 transcoding with 3 discs", seasons)
+	}
+	files, _ := dstRepo.ListTranscodeFiles(ctx, job.ID)
+	if len(files) != 1 || files[0].InputSize != 4<<30 || files[0].DurationSecs != 2820.5 {
+		t.Errorf("imported files = %+v, want the transcode file", files)
+	}
+
+	// A database with items refuses an import
+	if err := dst.Import(ctx, decoded); err == nil || !strings.Contains(err.Error(), "already has 1 media items") {
+		t.Errorf("Import() into a used database error = %v, want a refusal", err)
+	}
+}
+
+func TestImport_RejectsUnknownColumns(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	export := &Export{MediaItems: []Row{{"id": json.Number("1"), "type": "movie", "name": "Alien", "safe_name": "Alien", "rating": "R"}}}
+	if err := database.Import(context.Background(), export); err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Errorf("Import() error = %v, want an unknown column error", err)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	database, err := Open(filepath.Join(dir, "pipeline.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+	item, _ := seedHistory(t, NewSQLiteRepository(database))
+	ctx := context.Background()
+
+	path := filepath.Join(dir, "snapshot.db")
+	if err := database.Backup(ctx, path); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	// VACUUM INTO does not overwrite
+	if err := database.Backup(ctx, path); err == nil {
+		t.Error("Backup() over an existing file error = nil, want an error")
+	}
+
+	snapshot, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open(snapshot) error = %v", err)
+	}
+	defer snapshot.Close()
+	got, _ := NewSQLiteRepository(snapshot).GetMediaItem(ctx, item.ID)
+	if got == nil || got.Name != "Breaking Bad" {
+		t.Errorf("snapshot item = %+v, want Breaking Bad", got)
+	}
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListJobs returns the page of jobs matching a filter. Pages are keyed on
+// creation time and ID, so jobs created while paging do not shift them.
+func (r *SQLiteRepository) ListJobs(ctx context.Context, filter JobFilter) (*JobPage, error) {
+	var where []string
+	var args []interface{}
+	in := func(column string, n int, value func(i int) interface{}) {
+		if n == 0 {
+			return
+		}
+		where = append(where, column+" IN ("+placeholders(n)+")")
+		for i := 0; i < n; i++ {
+			args = append(args, value(i))
+		}
+	}
+
+	in("media_item_id", len(filter.MediaItemIDs), func(i int) interface{} { return filter.MediaItemIDs[i] })
+	in("stage", len(filter.Stages), func(i int) interface{} { return filter.Stages[i].String() })
+	in("status", len(filter.Statuses), func(i int) interface{} { return filter.Statuses[i] })
+	if filter.SeasonID != nil {
+		where = append(where, "season_id = ?")
+		args = append(args, *filter.SeasonID)
+	}
+	if filter.WorkerID != "" {
+		where = append(where, "worker_id = ?")
+		args = append(args, filter.WorkerID)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.Since.UTC().Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.Until.UTC().Format(time.RFC3339))
+	}
+
+	order, after := "ASC", ">"
+	if filter.Order == JobOrderNewest {
+		order, after = "DESC", "<"
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeJobCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, "(created_at, id) "+after+" (?, ?)")
+		args = append(args, createdAt, id)
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at " + order + ", id " + order
+
+	// One more than the page, to tell whether there is a next one
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit+1)
+	}
+
+	rows, err := r.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	page := &JobPage{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		page.Jobs = append(page.Jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+
+	if filter.Limit > 0 && len(page.Jobs) > filter.Limit {
+		page.Jobs = page.Jobs[:filter.Limit]
+		last := page.Jobs[len(page.Jobs)-1]
+		page.Next = encodeJobCursor(last.CreatedAt.UTC().Format(time.RFC3339), last.ID)
+	}
+	return page, nil
+}
+
+// encodeJobCursor returns the cursor of the page after a job
+func encodeJobCursor(createdAt string, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt + "|" + strconv.FormatInt(id, 10)))
+}
+
+// decodeJobCursor returns the creation time and ID of the job a cursor
+// continues after
+func decodeJobCursor(cursor string) (string, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid job cursor %q", cursor)
+	}
+	createdAt, idText, ok := strings.Cut(string(raw), "|")
+	id, err := strconv.ParseInt(idText, 10, 64)
+	if !ok || err != nil {
+		return "", 0, fmt.Errorf("invalid job cursor %q", cursor)
+	}
+	return createdAt, id, nil
+}
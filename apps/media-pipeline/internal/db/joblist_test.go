@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestSQLiteRepository_ListJobs(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	movie := &model.MediaItem{Type: model.MediaTypeMovie, Name: "Movie", SafeName: "Movie"}
+	show := &model.MediaItem{Type: model.MediaTypeTV, Name: "Show", SafeName: "Show"}
+	for _, item := range []*model.MediaItem{movie, show} {
+		if err := repo.CreateMediaItem(ctx, item); err != nil {
+			t.Fatalf("CreateMediaItem() error = %v", err)
+		}
+	}
+	season := &model.Season{ItemID: show.ID, Number: 1, StageStatus: model.StatusPending}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason() error = %v", err)
+	}
+
+	// One job a day, the last created first so IDs don't follow creation
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	specs := []struct {
+		item    *model.MediaItem
+		season  *int64
+		stage   model.Stage
+		status  model.JobStatus
+		worker  string
+		daysIn  int
+		attempt int
+	}{
+		{movie, nil, model.StageTranscode, model.JobStatusFailed, "nas", 4, 2},
+		{show, &season.ID, model.StageRemux, model.JobStatusCompleted, "nas", 3, 1},
+		{show, &season.ID, model.StageRip, model.JobStatusCompleted, "desktop", 2, 1},
+		{movie, nil, model.StageTranscode, model.JobStatusFailed, "nas", 1, 1},
+		{movie, nil, model.StageRip, model.JobStatusCompleted, "desktop", 0, 1},
+	}
+	ids := make(map[int]int64) // daysIn -> job ID
+	for _, spec := range specs {
+		job := &model.Job{
+			MediaItemID: spec.item.ID,
+			SeasonID:    spec.season,
+			Stage:       spec.stage,
+			Status:      spec.status,
+			WorkerID:    spec.worker,
+			Attempt:     spec.attempt,
+			CreatedAt:   base.AddDate(0, 0, spec.daysIn),
+		}
+		if err := repo.CreateJob(ctx, job); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+		ids[spec.daysIn] = job.ID
+	}
+
+	list := func(filter JobFilter) *JobPage {
+		t.Helper()
+		page, err := repo.ListJobs(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListJobs(%+v) error = %v", filter, err)
+		}
+		return page
+	}
+	days := func(jobs []model.Job) []int {
+		var out []int
+		for _, job := range jobs {
+			for day, id := range ids {
+				if id == job.ID {
+					out = append(out, day)
+				}
+			}
+		}
+		return out
+	}
+	equal := func(got, want []int) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	tests := []struct {
+		name   string
+		filter JobFilter
+		want   []int
+	}{
+		{"all in creation order", JobFilter{}, []int{0, 1, 2, 3, 4}},
+		{"newest first", JobFilter{Order: JobOrderNewest}, []int{4, 3, 2, 1, 0}},
+		{"item", JobFilter{MediaItemIDs: []int64{movie.ID}}, []int{0, 1, 4}},
+		{"season", JobFilter{SeasonID: &season.ID}, []int{2, 3}},
+		{"stages", JobFilter{Stages: []model.Stage{model.StageRip, model.StageRemux}}, []int{0, 2, 3}},
+		{"status and worker", JobFilter{Statuses: []model.JobStatus{model.JobStatusFailed}, WorkerID: "nas"}, []int{1, 4}},
+		{"date range", JobFilter{Since: base.AddDate(0, 0, 1), Until: base.AddDate(0, 0, 3)}, []int{1, 2}},
+		{"no match", JobFilter{WorkerID: "laptop"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := list(tt.filter)
+			if got := days(page.Jobs); !equal(got, tt.want) {
+				t.Errorf("ListJobs() days = %v, want %v", got, tt.want)
+			}
+			if page.Next != "" {
+				t.Errorf("Next = %q, want none without a limit", page.Next)
+			}
+		})
+	}
+
+	t.Run("pages with a cursor", func(t *testing.T) {
+		filter := JobFilter{Order: JobOrderNewest, Limit: 2}
+		var got []int
+		for pages := 0; ; pages++ {
+			if pages == 5 {
+				t.Fatal("ListJobs() never returned the last page")
+			}
+			page := list(filter)
+			if len(page.Jobs) > 2 {
+				t.Fatalf("page has %d jobs, want at most 2", len(page.Jobs))
+			}
+			got = append(got, days(page.Jobs)...)
+			if page.Next == "" {
+				break
+			}
+			filter.Cursor = page.Next
+		}
+		if want := []int{4, 3, 2, 1, 0}; !equal(got, want) {
+			t.Errorf("pages days = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("rejects a bad cursor", func(t *testing.T) {
+		if _, err := repo.ListJobs(ctx, JobFilter{Cursor: "not a cursor"}); err == nil {
+			t.Error("ListJobs() with a bad cursor error = nil, want an error")
+		}
+	})
+}
@@ -7,10 +7,11 @@ import (
 	"time"
 )
 
-// MigrateToItemCentric migrates existing TV show data to the new schema.
+// migrateToItemCentric migrates existing TV show data to the new schema.
 // This consolidates multiple media_items rows (one per season) into
-// a single item with multiple seasons.
-func (r *SQLiteRepository) MigrateToItemCentric(ctx context.Context) error {
+// a single item with multiple seasons. It runs after 003, whose unique
+// indexes tell the moved jobs apart by season.
+func migrateToItemCentric(ctx context.Context, tx *sql.Tx) error {
 	// Find TV shows that need migration (have season in media_items but no seasons table entries)
 	query := `
 		SELECT DISTINCT safe_name
@@ -23,7 +24,7 @@ func (r *SQLiteRepository) MigrateToItemCentric(ctx context.Context) error {
 		)
 	`
 
-	rows, err := r.db.db.QueryContext(ctx, query)
+	rows, err := tx.QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to find TV shows to migrate: %w", err)
 	}
@@ -39,7 +40,7 @@ func (r *SQLiteRepository) MigrateToItemCentric(ctx context.Context) error {
 	}
 
 	for _, safeName := range showsToMigrate {
-		if err := r.migrateShow(ctx, safeName); err != nil {
+		if err := migrateShow(ctx, tx, safeName); err != nil {
 			return fmt.Errorf("failed to migrate show %s: %w", safeName, err)
 		}
 	}
@@ -47,7 +48,7 @@ func (r *SQLiteRepository) MigrateToItemCentric(ctx context.Context) error {
 	return nil
 }
 
-func (r *SQLiteRepository) migrateShow(ctx context.Context, safeName string) error {
+func migrateShow(ctx context.Context, tx *sql.Tx, safeName string) error {
 	// Get all media_items for this show
 	query := `
 		SELECT id, name, season, created_at
@@ -56,7 +57,7 @@ func (r *SQLiteRepository) migrateShow(ctx context.Context, safeName string) err
 		ORDER BY season ASC
 	`
 
-	rows, err := r.db.db.QueryContext(ctx, query, safeName)
+	rows, err := tx.QueryContext(ctx, query, safeName)
 	if err != nil {
 		return err
 	}
@@ -97,7 +98,7 @@ func (r *SQLiteRepository) migrateShow(ctx context.Context, safeName string) err
 	for _, item := range items {
 		// Get latest job to determine stage/status
 		var stage, status string
-		err := r.db.db.QueryRowContext(ctx, `
+		err := tx.QueryRowContext(ctx, `
 			SELECT stage, status FROM jobs
 			WHERE media_item_id = ?
 			ORDER BY created_at DESC LIMIT 1
@@ -111,25 +112,29 @@ func (r *SQLiteRepository) migrateShow(ctx context.Context, safeName string) err
 		}
 
 		now := time.Now().UTC().Format(time.RFC3339)
-		_, err = r.db.db.ExecContext(ctx, `
+		res, err := tx.ExecContext(ctx, `
 			INSERT INTO seasons (item_id, number, current_stage, stage_status, created_at, updated_at)
 			VALUES (?, ?, ?, ?, ?, ?)
 		`, parentID, item.season, stage, status, item.createdAt, now)
 		if err != nil {
 			return fmt.Errorf("failed to create season: %w", err)
 		}
+		seasonID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get season id: %w", err)
+		}
 
-		// Update jobs to reference parent item
-		if item.id != parentID {
-			_, err = r.db.db.ExecContext(ctx, `
-				UPDATE jobs SET media_item_id = ? WHERE media_item_id = ?
-			`, parentID, item.id)
-			if err != nil {
-				return fmt.Errorf("failed to update jobs: %w", err)
-			}
+		// Move the jobs to the parent item and their season
+		_, err = tx.ExecContext(ctx, `
+			UPDATE jobs SET media_item_id = ?, season_id = ? WHERE media_item_id = ?
+		`, parentID, seasonID, item.id)
+		if err != nil {
+			return fmt.Errorf("failed to update jobs: %w", err)
+		}
 
+		if item.id != parentID {
 			// Delete the old media_item
-			_, err = r.db.db.ExecContext(ctx, `
+			_, err = tx.ExecContext(ctx, `
 				DELETE FROM media_items WHERE id = ?
 			`, item.id)
 			if err != nil {
@@ -139,7 +144,7 @@ func (r *SQLiteRepository) migrateShow(ctx context.Context, safeName string) err
 	}
 
 	// Update parent item to remove season field and set status
-	_, err = r.db.db.ExecContext(ctx, `
+	_, err = tx.ExecContext(ctx, `
 		UPDATE media_items SET season = NULL, status = 'active' WHERE id = ?
 	`, parentID)
 	if err != nil {
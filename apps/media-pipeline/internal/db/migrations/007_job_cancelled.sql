@@ -0,0 +1,41 @@
+-- Allow 'cancelled' as a job status
+-- Cancelled jobs were stopped on request, which is distinct from a failure
+
+-- SQLite requires recreating the table to modify a CHECK constraint.
+-- The migration runner disables foreign keys while the table is swapped out,
+-- otherwise dropping jobs would cascade into log_events and transcode_files.
+
+CREATE TABLE jobs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    media_item_id INTEGER NOT NULL REFERENCES media_items(id) ON DELETE CASCADE,
+    season_id INTEGER REFERENCES seasons(id) ON DELETE CASCADE,
+    stage TEXT NOT NULL CHECK (stage IN ('rip', 'organize', 'remux', 'transcode', 'publish')),
+    status TEXT NOT NULL CHECK (status IN ('pending', 'in_progress', 'completed', 'failed', 'cancelled')),
+    disc INTEGER,
+    worker_id TEXT,
+    pid INTEGER,
+    input_dir TEXT,
+    output_dir TEXT,
+    log_path TEXT,
+    error_message TEXT,
+    started_at TEXT,
+    completed_at TEXT,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    options TEXT,
+    progress INTEGER DEFAULT 0
+);
+
+INSERT INTO jobs_new (id, media_item_id, season_id, stage, status, disc, worker_id, pid, input_dir, output_dir, log_path, error_message, started_at, completed_at, created_at, options, progress)
+SELECT id, media_item_id, season_id, stage, status, disc, worker_id, pid, input_dir, output_dir, log_path, error_message, started_at, completed_at, created_at, options, progress
+FROM jobs;
+
+DROP TABLE jobs;
+
+ALTER TABLE jobs_new RENAME TO jobs;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_media_item ON jobs(media_item_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+CREATE INDEX IF NOT EXISTS idx_jobs_season ON jobs(season_id);
+CREATE UNIQUE INDEX idx_jobs_unique_movie ON jobs(media_item_id, stage, disc) WHERE season_id IS NULL;
+CREATE UNIQUE INDEX idx_jobs_unique_tv ON jobs(media_item_id, season_id, stage, disc) WHERE season_id IS NOT NULL;
+
@@ -0,0 +1,2 @@
+ALTER TABLE seasons DROP COLUMN autopilot;
+ALTER TABLE media_items DROP COLUMN autopilot;
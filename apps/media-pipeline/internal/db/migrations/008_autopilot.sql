@@ -0,0 +1,4 @@
+-- Autopilot: automatically queue the next stage when remux or transcode completes
+-- Set on a movie, a TV show (applies to all its seasons) or a single season
+ALTER TABLE media_items ADD COLUMN autopilot INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE seasons ADD COLUMN autopilot INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_jobs_queue;
+ALTER TABLE jobs DROP COLUMN priority;
@@ -0,0 +1,4 @@
+-- Job priority: higher priority pending jobs are dispatched first, FIFO within a priority
+ALTER TABLE jobs ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queue ON jobs(status, priority DESC, created_at);
@@ -0,0 +1,11 @@
+-- Fails on the unique indexes if a job has been retried: delete the extra
+-- attempts first
+DROP INDEX IF EXISTS idx_jobs_unique_movie;
+DROP INDEX IF EXISTS idx_jobs_unique_tv;
+DROP INDEX IF EXISTS idx_jobs_run;
+CREATE UNIQUE INDEX idx_jobs_unique_movie ON jobs(media_item_id, stage, disc) WHERE season_id IS NULL;
+CREATE UNIQUE INDEX idx_jobs_unique_tv ON jobs(media_item_id, season_id, stage, disc) WHERE season_id IS NOT NULL;
+ALTER TABLE jobs DROP COLUMN failure_kind;
+ALTER TABLE jobs DROP COLUMN not_before;
+ALTER TABLE jobs DROP COLUMN run_id;
+ALTER TABLE jobs DROP COLUMN attempt;
@@ -0,0 +1,15 @@
+-- Job attempts: a failed job may be retried automatically. Each retry is a new
+-- job row numbered by attempt and linked to the first attempt through run_id
+-- (NULL on the first attempt itself).
+ALTER TABLE jobs ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE jobs ADD COLUMN run_id INTEGER;
+ALTER TABLE jobs ADD COLUMN not_before TEXT;
+ALTER TABLE jobs ADD COLUMN failure_kind TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_run ON jobs(run_id);
+
+-- Attempts of the same disc rip share media item, season, stage and disc
+DROP INDEX IF EXISTS idx_jobs_unique_movie;
+DROP INDEX IF EXISTS idx_jobs_unique_tv;
+CREATE UNIQUE INDEX idx_jobs_unique_movie ON jobs(media_item_id, stage, disc, attempt) WHERE season_id IS NULL;
+CREATE UNIQUE INDEX idx_jobs_unique_tv ON jobs(media_item_id, season_id, stage, disc, attempt) WHERE season_id IS NOT NULL;
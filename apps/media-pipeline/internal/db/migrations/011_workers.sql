@@ -0,0 +1,8 @@
+-- Worker registry: each host running stage binaries reports what it can do
+-- and when it was last alive. Capabilities are a comma-separated list.
+CREATE TABLE IF NOT EXISTS workers (
+    hostname TEXT PRIMARY KEY,
+    capabilities TEXT NOT NULL DEFAULT '',
+    current_job_id INTEGER,
+    last_seen TEXT NOT NULL
+);
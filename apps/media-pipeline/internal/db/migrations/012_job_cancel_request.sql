@@ -0,0 +1,3 @@
+-- A running job can only be signalled once its stage binary has recorded a
+-- PID. Cancelling before that leaves a request for the daemon running it.
+ALTER TABLE jobs ADD COLUMN cancel_requested INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE workers ADD COLUMN current_job_id INTEGER;
+ALTER TABLE workers DROP COLUMN target;
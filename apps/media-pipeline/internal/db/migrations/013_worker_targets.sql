@@ -0,0 +1,6 @@
+-- Workers record the target the daemon dispatches to (an SSH target or agent
+-- address), so jobs are only rerouted to hosts it knows how to reach. What a
+-- host is running is derived from jobs.worker_id: one recorded job per host
+-- was wrong as soon as several jobs ran there.
+ALTER TABLE workers ADD COLUMN target TEXT NOT NULL DEFAULT '';
+ALTER TABLE workers DROP COLUMN current_job_id;
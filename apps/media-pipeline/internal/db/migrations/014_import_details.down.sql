@@ -0,0 +1,2 @@
+ALTER TABLE seasons DROP COLUMN disc_count;
+ALTER TABLE media_items DROP COLUMN year;
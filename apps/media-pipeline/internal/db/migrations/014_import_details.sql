@@ -0,0 +1,4 @@
+-- Details recorded by bulk import: the release year of an item, and how many
+-- discs a season spans so it is clear when all of them have been ripped
+ALTER TABLE media_items ADD COLUMN year INTEGER;
+ALTER TABLE seasons ADD COLUMN disc_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,19 @@
+-- Audit trail of every stage change of an item or season, and every job
+-- status change, with who made it. job_id is the job changed, or for items
+-- and seasons the latest job of the stage moved to.
+CREATE TABLE IF NOT EXISTS stage_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('item', 'season', 'job')),
+    media_item_id INTEGER NOT NULL REFERENCES media_items(id) ON DELETE CASCADE,
+    season_id INTEGER REFERENCES seasons(id) ON DELETE CASCADE,
+    job_id INTEGER REFERENCES jobs(id) ON DELETE SET NULL,
+    from_stage TEXT NOT NULL,
+    from_status TEXT NOT NULL,
+    to_stage TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_stage_transitions_item ON stage_transitions(media_item_id);
+CREATE INDEX IF NOT EXISTS idx_stage_transitions_job ON stage_transitions(job_id);
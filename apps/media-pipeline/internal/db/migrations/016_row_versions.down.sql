@@ -0,0 +1,3 @@
+ALTER TABLE jobs DROP COLUMN version;
+ALTER TABLE seasons DROP COLUMN version;
+ALTER TABLE media_items DROP COLUMN version;
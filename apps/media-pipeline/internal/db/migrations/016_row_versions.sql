@@ -0,0 +1,5 @@
+-- Row versions for optimistic concurrency: every update increments version,
+-- and an update made from a struct only applies to the version it was read at
+ALTER TABLE media_items ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE seasons ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE jobs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
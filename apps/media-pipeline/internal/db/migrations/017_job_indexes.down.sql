@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jobs_worker_created;
+DROP INDEX IF EXISTS idx_jobs_status_created;
+DROP INDEX IF EXISTS idx_jobs_stage_created;
+DROP INDEX IF EXISTS idx_jobs_created;
@@ -0,0 +1,6 @@
+-- Indexes for ListJobs: each filter narrows by its column, then pages in
+-- creation order
+CREATE INDEX IF NOT EXISTS idx_jobs_created ON jobs(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_stage_created ON jobs(stage, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_created ON jobs(status, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_worker_created ON jobs(worker_id, created_at, id);
@@ -0,0 +1,12 @@
+DROP TRIGGER search_logs_delete;
+DROP TRIGGER search_logs_insert;
+DROP TRIGGER search_jobs_delete;
+DROP TRIGGER search_jobs_update;
+DROP TRIGGER search_jobs_insert;
+DROP TRIGGER search_seasons_delete;
+DROP TRIGGER search_seasons_update;
+DROP TRIGGER search_seasons_insert;
+DROP TRIGGER search_items_delete;
+DROP TRIGGER search_items_update;
+DROP TRIGGER search_items_insert;
+DROP TABLE search_index;
@@ -0,0 +1,92 @@
+-- Full-text index over item names, season labels, job errors and log
+-- messages, kept in sync by the triggers below. The rowid is the source
+-- row's id * 4 plus its kind (0 item, 1 season, 2 job, 3 log), so a source
+-- row's entry is found without a scan. Job errors and logs only record the
+-- job, whose item and season are joined in when searching; triggers that
+-- read jobs would stop a migration from rebuilding the table.
+CREATE VIRTUAL TABLE search_index USING fts5(
+    body,
+    kind UNINDEXED,
+    media_item_id UNINDEXED,
+    season_id UNINDEXED,
+    job_id UNINDEXED
+);
+
+INSERT INTO search_index (rowid, body, kind, media_item_id, season_id, job_id)
+SELECT id * 4, name, 'item', id, NULL, NULL FROM media_items;
+
+INSERT INTO search_index (rowid, body, kind, media_item_id, season_id, job_id)
+SELECT s.id * 4 + 1, m.name || ' Season ' || s.number, 'season', s.item_id, s.id, NULL
+FROM seasons s JOIN media_items m ON m.id = s.item_id;
+
+INSERT INTO search_index (rowid, body, kind, media_item_id, season_id, job_id)
+SELECT id * 4 + 2, error_message, 'job', NULL, NULL, id
+FROM jobs WHERE error_message IS NOT NULL AND error_message != '';
+
+INSERT INTO search_index (rowid, body, kind, media_item_id, season_id, job_id)
+SELECT id * 4 + 3, message, 'log', NULL, NULL, job_id
+FROM log_events;
+
+-- Items; a rename also relabels the item's seasons
+CREATE TRIGGER search_items_insert AFTER INSERT ON media_items BEGIN
+    INSERT INTO search_index (rowid, body, kind, media_item_id)
+    VALUES (new.id * 4, new.name, 'item', new.id);
+END;
+
+CREATE TRIGGER search_items_update AFTER UPDATE OF id, name ON media_items BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+    INSERT INTO search_index (rowid, body, kind, media_item_id)
+    VALUES (new.id * 4, new.name, 'item', new.id);
+    UPDATE search_index SET body = new.name || ' Season ' || (SELECT number FROM seasons WHERE id = search_index.season_id)
+    WHERE rowid IN (SELECT id * 4 + 1 FROM seasons WHERE item_id = new.id);
+END;
+
+CREATE TRIGGER search_items_delete AFTER DELETE ON media_items BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+END;
+
+-- Seasons
+CREATE TRIGGER search_seasons_insert AFTER INSERT ON seasons BEGIN
+    INSERT INTO search_index (rowid, body, kind, media_item_id, season_id)
+    SELECT new.id * 4 + 1, name || ' Season ' || new.number, 'season', new.item_id, new.id
+    FROM media_items WHERE id = new.item_id;
+END;
+
+CREATE TRIGGER search_seasons_update AFTER UPDATE OF id, item_id, number ON seasons BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+    INSERT INTO search_index (rowid, body, kind, media_item_id, season_id)
+    SELECT new.id * 4 + 1, name || ' Season ' || new.number, 'season', new.item_id, new.id
+    FROM media_items WHERE id = new.item_id;
+END;
+
+CREATE TRIGGER search_seasons_delete AFTER DELETE ON seasons BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+END;
+
+-- Job errors
+CREATE TRIGGER search_jobs_insert AFTER INSERT ON jobs
+WHEN new.error_message IS NOT NULL AND new.error_message != '' BEGIN
+    INSERT INTO search_index (rowid, body, kind, job_id)
+    VALUES (new.id * 4 + 2, new.error_message, 'job', new.id);
+END;
+
+CREATE TRIGGER search_jobs_update AFTER UPDATE OF id, error_message ON jobs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+    INSERT INTO search_index (rowid, body, kind, job_id)
+    SELECT new.id * 4 + 2, new.error_message, 'job', new.id
+    WHERE new.error_message IS NOT NULL AND new.error_message != '';
+END;
+
+CREATE TRIGGER search_jobs_delete AFTER DELETE ON jobs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+END;
+
+-- Log messages
+CREATE TRIGGER search_logs_insert AFTER INSERT ON log_events BEGIN
+    INSERT INTO search_index (rowid, body, kind, job_id)
+    VALUES (new.id * 4 + 3, new.message, 'log', new.job_id);
+END;
+
+CREATE TRIGGER search_logs_delete AFTER DELETE ON log_events BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+END;
@@ -0,0 +1,12 @@
+-- Raw output files of the external tools a job ran (makemkv, mkvmerge,
+-- ffmpeg), kept next to its job.log
+CREATE TABLE IF NOT EXISTS job_tool_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    tool TEXT NOT NULL,
+    path TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    UNIQUE(job_id, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_tool_logs_job ON job_tool_logs(job_id);
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/cuivienor/media-pipeline/internal/model"
 )
@@ -16,16 +17,31 @@ type Repository interface {
 
 	// Jobs
 	CreateJob(ctx context.Context, job *model.Job) error
+	EnqueueJob(ctx context.Context, job *model.Job, fromPendingOnly bool) error
 	GetJob(ctx context.Context, id int64) (*model.Job, error)
 	GetActiveJobForStage(ctx context.Context, mediaItemID int64, stage model.Stage, disc *int) (*model.Job, error)
 	UpdateJob(ctx context.Context, job *model.Job) error
+	StartJob(ctx context.Context, id int64, workerID string, pid int, inputDir, outputDir string) error
+	SetJobOutputDir(ctx context.Context, id int64, dir string) error
 	UpdateJobStatus(ctx context.Context, id int64, status model.JobStatus, errorMsg string) error
+	FailJob(ctx context.Context, id int64, kind model.FailureKind, errorMsg string) (bool, error)
 	UpdateJobProgress(ctx context.Context, id int64, progress int) error
+	SetJobPriority(ctx context.Context, id int64, priority int) error
 	ListJobsForMedia(ctx context.Context, mediaItemID int64) ([]model.Job, error)
+	ListJobsByStatus(ctx context.Context, status model.JobStatus) ([]model.Job, error)
+	ListJobs(ctx context.Context, filter JobFilter) (*JobPage, error)
+	ClaimJob(ctx context.Context, id int64, workerID string, pid int, limit int) (bool, error)
+	CancelPendingJob(ctx context.Context, id int64) (bool, error)
+	RequestJobCancel(ctx context.Context, id int64) (bool, error)
 
 	// Log events
 	CreateLogEvent(ctx context.Context, event *model.LogEvent) error
 	ListLogEvents(ctx context.Context, jobID int64, limit int) ([]model.LogEvent, error)
+	ListRecentLogEvents(ctx context.Context, jobIDs []int64, limit int) (map[int64][]model.LogEvent, error)
+
+	// Tool logs
+	AddToolLog(ctx context.Context, toolLog *model.ToolLog) error
+	ListToolLogs(ctx context.Context, jobID int64) ([]model.ToolLog, error)
 
 	// Disc progress (TV shows)
 	GetDiscProgress(ctx context.Context, mediaItemID int64) ([]model.DiscProgress, error)
@@ -34,25 +50,45 @@ type Repository interface {
 	CreateSeason(ctx context.Context, season *model.Season) error
 	GetSeason(ctx context.Context, id int64) (*model.Season, error)
 	ListSeasonsForItem(ctx context.Context, itemID int64) ([]model.Season, error)
+	ListSeasonsForItems(ctx context.Context, itemIDs []int64) (map[int64][]model.Season, error)
 	UpdateSeason(ctx context.Context, season *model.Season) error
 	UpdateSeasonStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error
+	SetSeasonAutopilot(ctx context.Context, id int64, enabled bool) error
 
 	// Updated item methods
 	UpdateMediaItemStatus(ctx context.Context, id int64, status model.ItemStatus) error
 	UpdateMediaItemStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error
+	SetMediaItemAutopilot(ctx context.Context, id int64, enabled bool) error
 	ListActiveItems(ctx context.Context) ([]model.MediaItem, error)
+	LoadActiveItems(ctx context.Context) (*ActiveItems, error)
 
 	// Transcode files
 	CreateTranscodeFile(ctx context.Context, file *model.TranscodeFile) error
 	GetTranscodeFile(ctx context.Context, id int64) (*model.TranscodeFile, error)
 	ListTranscodeFiles(ctx context.Context, jobID int64) ([]model.TranscodeFile, error)
+	ListTranscodeSummaries(ctx context.Context, jobIDs []int64) (map[int64]model.TranscodeSummary, error)
 	UpdateTranscodeFile(ctx context.Context, file *model.TranscodeFile) error
 	UpdateTranscodeFileProgress(ctx context.Context, id int64, progress int) error
 	UpdateTranscodeFileStatus(ctx context.Context, id int64, status model.TranscodeFileStatus, errorMsg string) error
+	TranscodeCompressionRatio(ctx context.Context) (float64, error)
 
 	// Job options
 	GetJobOptions(ctx context.Context, jobID int64) (map[string]interface{}, error)
 	SetJobOptions(ctx context.Context, jobID int64, options map[string]interface{}) error
+
+	// Workers
+	UpsertWorker(ctx context.Context, worker *model.Worker) error
+	GetWorker(ctx context.Context, hostname string) (*model.Worker, error)
+	ListWorkers(ctx context.Context) ([]model.Worker, error)
+
+	// Stage transitions
+	ListTransitions(ctx context.Context, mediaItemID int64) ([]model.Transition, error)
+
+	// Search
+	Search(ctx context.Context, query string) ([]model.SearchResult, error)
+
+	// Transactions
+	WithTx(ctx context.Context, fn func(tx Repository) error) error
 }
 
 // ListOptions configures media item listing
@@ -62,3 +98,31 @@ type ListOptions struct {
 	Limit      int
 	Offset     int
 }
+
+// JobFilter selects a page of jobs for ListJobs. Zero fields match every job.
+type JobFilter struct {
+	MediaItemIDs []int64
+	SeasonID     *int64
+	Stages       []model.Stage
+	Statuses     []model.JobStatus
+	WorkerID     string
+	Since        time.Time // Created at or after
+	Until        time.Time // Created before
+	Order        JobOrder
+	Limit        int    // Page size, 0 for every match
+	Cursor       string // Next of the previous page, empty for the first
+}
+
+// JobOrder is the order ListJobs returns jobs in
+type JobOrder int
+
+const (
+	JobOrderOldest JobOrder = iota // Oldest created first
+	JobOrderNewest                 // Newest created first
+)
+
+// JobPage is a page of jobs from ListJobs
+type JobPage struct {
+	Jobs []model.Job
+	Next string // Cursor of the next page, empty on the last
+}
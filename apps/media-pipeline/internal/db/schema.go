@@ -0,0 +1,349 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Migration is one versioned schema change: an embedded NNN_name.sql file,
+// with an optional NNN_name.down.sql that reverts it, or a data migration
+// written in Go
+type Migration struct {
+	Version string // As recorded in schema_migrations: the file name, or NNN_name.go
+	up      string
+	down    string
+	apply   func(ctx context.Context, tx *sql.Tx) error
+}
+
+// Reversible reports whether the migration has a down migration
+func (m Migration) Reversible() bool {
+	return m.down != ""
+}
+
+// goMigrations are the data migrations written in Go. Their versions sort
+// them among the SQL migrations they depend on.
+var goMigrations = []Migration{
+	{Version: "003_item_centric_data.go", apply: migrateToItemCentric},
+}
+
+// MigrationState is a migration this binary knows, or one the database
+// records, and when it was applied
+type MigrationState struct {
+	Version    string
+	AppliedAt  string // Empty while pending
+	Reversible bool
+	Known      bool // False for migrations applied by a newer binary
+}
+
+// MigrationRun reports what MigrateUp or MigrateDown did
+type MigrationRun struct {
+	Versions []string // Applied, or rolled back, in the order they ran
+	Backup   string   // Snapshot taken before the first one ran, if any
+}
+
+// loadMigrations returns the embedded and Go migrations in version order
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var list []Migration
+	downs := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		content, err := fs.ReadFile(migrations, "migrations/"+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if strings.HasSuffix(name, ".down.sql") {
+			downs[strings.TrimSuffix(name, ".down.sql")+".sql"] = string(content)
+			continue
+		}
+		list = append(list, Migration{Version: name, up: string(content)})
+	}
+
+	for i := range list {
+		if down, ok := downs[list[i].Version]; ok {
+			list[i].down = down
+			delete(downs, list[i].Version)
+		}
+	}
+	for version := range downs {
+		return nil, fmt.Errorf("down migration for %s has no up migration", version)
+	}
+
+	list = append(list, goMigrations...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// ensureMigrationsTable creates the table recording applied migrations
+func (d *DB) ensureMigrationsTable(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	return nil
+}
+
+// appliedMigrations returns when each applied migration was applied
+func (d *DB) appliedMigrations(ctx context.Context) (map[string]string, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]string)
+	for rows.Next() {
+		var version, at string
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// Migrations returns every known or applied migration in version order
+func (d *DB) Migrations(ctx context.Context) ([]MigrationState, error) {
+	known, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	for _, m := range known {
+		states = append(states, MigrationState{Version: m.Version, AppliedAt: applied[m.Version], Reversible: m.Reversible(), Known: true})
+		delete(applied, m.Version)
+	}
+	for version, at := range applied {
+		states = append(states, MigrationState{Version: version, AppliedAt: at})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
+}
+
+// MigrateUp applies the pending migrations in order, each in its own
+// transaction, after snapshotting a database that already has a schema
+func (d *DB) MigrateUp(ctx context.Context) (*MigrationRun, error) {
+	known, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range known {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+
+	run := &MigrationRun{}
+	if len(pending) == 0 {
+		return run, nil
+	}
+	if len(applied) > 0 {
+		if run.Backup, err = d.preMigrationBackup(ctx); err != nil {
+			return run, err
+		}
+	}
+
+	for _, m := range pending {
+		ran, err := d.runMigration(ctx, m, false)
+		if err != nil {
+			return run, err
+		}
+		if ran {
+			run.Versions = append(run.Versions, m.Version)
+		}
+	}
+	return run, nil
+}
+
+// MigrateDown rolls back the last steps applied migrations, newest first,
+// after snapshotting the database. It refuses before changing anything if
+// one of them has no down migration.
+func (d *DB) MigrateDown(ctx context.Context, steps int) (*MigrationRun, error) {
+	known, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[string]Migration, len(known))
+	for _, m := range known {
+		byVersion[m.Version] = m
+	}
+	versions := make([]string, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	if steps > len(versions) {
+		steps = len(versions)
+	}
+
+	var rollback []Migration
+	for _, version := range versions[:steps] {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("%s was applied by a newer version: roll it back with that version", version)
+		}
+		if !m.Reversible() {
+			return nil, fmt.Errorf("%s has no down migration", version)
+		}
+		rollback = append(rollback, m)
+	}
+
+	run := &MigrationRun{}
+	if len(rollback) == 0 {
+		return run, nil
+	}
+	if run.Backup, err = d.preMigrationBackup(ctx); err != nil {
+		return run, err
+	}
+	for _, m := range rollback {
+		ran, err := d.runMigration(ctx, m, true)
+		if err != nil {
+			return run, err
+		}
+		if ran {
+			run.Versions = append(run.Versions, m.Version)
+		}
+	}
+	return run, nil
+}
+
+// runMigration applies or reverts one migration in a transaction. It
+// reports false if another process got there first.
+func (d *DB) runMigration(ctx context.Context, m Migration, down bool) (bool, error) {
+	// foreign_keys cannot change inside a transaction, so it is turned off
+	// on a dedicated connection: rebuilding a table must not cascade its
+	// DROP TABLE to child rows. The result is checked before committing.
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return false, fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Recording the change first takes the write lock, so when several
+	// processes open the database at once only one of them runs it
+	record := `INSERT OR IGNORE INTO schema_migrations (version) VALUES (?)`
+	if down {
+		record = `DELETE FROM schema_migrations WHERE version = ?`
+	}
+	res, err := tx.ExecContext(ctx, record, m.Version)
+	if err != nil {
+		return false, fmt.Errorf("failed to record migration %s: %w", m.Version, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	switch {
+	case down:
+		_, err = tx.ExecContext(ctx, m.down)
+	case m.apply != nil:
+		err = m.apply(ctx, tx)
+	default:
+		_, err = tx.ExecContext(ctx, m.up)
+	}
+	if err != nil {
+		if down {
+			return false, fmt.Errorf("failed to roll back %s: %w", m.Version, err)
+		}
+		return false, fmt.Errorf("failed to execute %s: %w", m.Version, err)
+	}
+
+	if err := checkForeignKeys(ctx, tx); err != nil {
+		return false, fmt.Errorf("%s: %w", m.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit %s: %w", m.Version, err)
+	}
+	return true, nil
+}
+
+// checkForeignKeys fails if any row references a row that does not exist
+func checkForeignKeys(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	violations := 0
+	var table string
+	for rows.Next() {
+		var rowid, parent, fkid interface{}
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return fmt.Errorf("failed to scan foreign key check: %w", err)
+		}
+		violations++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	if violations > 0 {
+		return fmt.Errorf("%d rows violate foreign keys (first in %s)", violations, table)
+	}
+	return nil
+}
+
+// preMigrationBackup snapshots a database file next to itself before its
+// schema changes. In-memory databases are not backed up.
+func (d *DB) preMigrationBackup(ctx context.Context) (string, error) {
+	if d.path == "" || isMemory(d.path) {
+		return "", nil
+	}
+	// Processes opening the database at the same time each take their own
+	base := fmt.Sprintf("%s.pre-migrate-%s-%d", d.path, time.Now().UTC().Format("20060102-150405"), os.Getpid())
+	path := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = fmt.Sprintf("%s-%d", base, n)
+	}
+	if err := d.Backup(ctx, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func hasColumn(t *testing.T, database *DB, table, column string) bool {
+	t.Helper()
+	var count int
+	if err := database.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	return count == 1
+}
+
+func TestOpen_NewDatabaseIsNotBackedUp(t *testing.T) {
+	dir := t.TempDir()
+	database, err := Open(filepath.Join(dir, "pipeline.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	database.Close()
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "*.pre-migrate-*"))
+	if len(backups) != 0 {
+		t.Errorf("Open() of a new database took backups %v", backups)
+	}
+}
+
+func TestMigrateDown_ThenUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeline.db")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	// Roll back to before 013, whatever has been added since
+	known, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	steps := 0
+	for _, m := range known {
+		if m.Version >= "013" {
+			steps++
+		}
+	}
+
+	run, err := database.MigrateDown(ctx, steps)
+	if err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+	if len(run.Versions) != steps || run.Versions[steps-1] != "013_worker_targets.sql" {
+		t.Errorf("MigrateDown() rolled back %v, want everything from 013 on, newest first", run.Versions)
+	}
+	if _, err := os.Stat(run.Backup); err != nil || !strings.HasPrefix(run.Backup, path+".pre-migrate-") {
+		t.Errorf("MigrateDown() backup = %q (%v), want a snapshot next to the database", run.Backup, err)
+	}
+	if hasColumn(t, database, "media_items", "year") || hasColumn(t, database, "workers", "target") {
+		t.Error("MigrateDown() left the columns of 013 and 014")
+	}
+
+	states, err := database.Migrations(ctx)
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	pending := 0
+	for _, s := range states {
+		if s.AppliedAt == "" {
+			pending++
+		}
+	}
+	if pending != steps {
+		t.Errorf("Migrations() has %d pending, want %d", pending, steps)
+	}
+
+	run, err = database.MigrateUp(ctx)
+	if err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if len(run.Versions) != steps || run.Backup == "" {
+		t.Errorf("MigrateUp() = %+v, want %d applied after a backup", run, steps)
+	}
+	if !hasColumn(t, database, "media_items", "year") || !hasColumn(t, database, "workers", "target") {
+		t.Error("MigrateUp() did not restore the columns of 013 and 014")
+	}
+}
+
+func TestMigrateDown_RefusesIrreversible(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	if _, err := database.MigrateDown(context.Background(), 100); err == nil || !strings.Contains(err.Error(), "has no down migration") {
+		t.Fatalf("MigrateDown() error = %v, want a refusal", err)
+	}
+	if !hasColumn(t, database, "media_items", "year") {
+		t.Error("MigrateDown() changed the schema before refusing")
+	}
+}
+
+func TestMigrateUp_FailureRollsBack(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	saved := goMigrations
+	defer func() { goMigrations = saved }()
+	goMigrations = append(append([]Migration{}, saved...), Migration{
+		Version: "999_orphans.go",
+		apply: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `CREATE TABLE scratch (id INTEGER)`); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO jobs (media_item_id, stage, status) VALUES (42, 'rip', 'pending')`)
+			return err
+		},
+	})
+
+	_, err = database.MigrateUp(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "violate foreign keys") {
+		t.Fatalf("MigrateUp() error = %v, want a foreign key violation", err)
+	}
+	var count int
+	database.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'scratch'`).Scan(&count)
+	if count != 0 {
+		t.Error("failed migration left its table behind")
+	}
+	database.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = '999_orphans.go'`).Scan(&count)
+	if count != 0 {
+		t.Error("failed migration was recorded as applied")
+	}
+}
+
+func TestMigrateToItemCentric(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeline.db")
+	db1, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	// One row per season, as before 002
+	statements := []string{
+		"INSERT INTO media_items (id, type, name, safe_name, season) VALUES (1, 'tv', 'Show', 'Show', 1)",
+		"INSERT INTO media_items (id, type, name, safe_name, season) VALUES (2, 'tv', 'Show', 'Show', 2)",
+		"INSERT INTO jobs (id, media_item_id, stage, status, disc) VALUES (1, 1, 'rip', 'completed', 1)",
+		"INSERT INTO jobs (id, media_item_id, stage, status, disc) VALUES (2, 2, 'rip', 'completed', 1)",
+		"DELETE FROM schema_migrations WHERE version = '003_item_centric_data.go'",
+	}
+	for _, stmt := range statements {
+		if _, err := db1.db.Exec(stmt); err != nil {
+			t.Fatalf("Exec(%q) error = %v", stmt, err)
+		}
+	}
+	db1.Close()
+
+	db2, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	defer db2.Close()
+
+	var items, seasons, unassigned int
+	db2.db.QueryRow("SELECT COUNT(*) FROM media_items").Scan(&items)
+	db2.db.QueryRow("SELECT COUNT(*) FROM seasons WHERE item_id = 1").Scan(&seasons)
+	db2.db.QueryRow("SELECT COUNT(*) FROM jobs WHERE media_item_id != 1 OR season_id IS NULL").Scan(&unassigned)
+	if items != 1 || seasons != 2 || unassigned != 0 {
+		t.Errorf("after migration: %d items, %d seasons, %d unassigned jobs; want 1, 2 and 0", items, seasons, unassigned)
+	}
+}
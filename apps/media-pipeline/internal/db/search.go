@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// maxSearchResults bounds how many matches Search returns
+const maxSearchResults = 50
+
+// Search returns the items, seasons, job errors and log messages matching
+// every word of query, best match first. Words match as prefixes, so
+// "read err" finds "read error".
+func (r *SQLiteRepository) Search(ctx context.Context, query string) ([]model.SearchResult, error) {
+	match := searchMatch(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := r.conn().QueryContext(ctx, `
+		SELECT s.kind, COALESCE(s.media_item_id, j.media_item_id), COALESCE(s.season_id, j.season_id), s.job_id,
+		       snippet(search_index, 0, '[', ']', '…', 12)
+		FROM search_index s
+		LEFT JOIN jobs j ON j.id = s.job_id
+		WHERE search_index MATCH ? AND (s.media_item_id IS NOT NULL OR j.id IS NOT NULL)
+		ORDER BY s.rank
+		LIMIT ?
+	`, match, maxSearchResults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	var results []model.SearchResult
+	for rows.Next() {
+		var result model.SearchResult
+		var seasonID, jobID sql.NullInt64
+		if err := rows.Scan(&result.Kind, &result.MediaItemID, &seasonID, &jobID, &result.Snippet); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		if seasonID.Valid {
+			result.SeasonID = &seasonID.Int64
+		}
+		if jobID.Valid {
+			result.JobID = &jobID.Int64
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// searchMatch turns what a user typed into an FTS5 query: each word quoted,
+// so punctuation is never query syntax, and matched as a prefix
+func searchMatch(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestSQLiteRepository_Search(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	show := &model.MediaItem{Type: model.MediaTypeTV, Name: "Breaking Bad", SafeName: "Breaking_Bad"}
+	if err := repo.CreateMediaItem(ctx, show); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	season := &model.Season{ItemID: show.ID, Number: 2, CurrentStage: model.StageRip, StageStatus: model.StatusInProgress}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason() error = %v", err)
+	}
+	job := &model.Job{MediaItemID: show.ID, SeasonID: &season.ID, Stage: model.StageRip, Status: model.JobStatusInProgress}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	event := &model.LogEvent{JobID: job.ID, Level: "error", Message: "Scsi error - MEDIUM ERROR:L-EC UNCORRECTABLE ERROR"}
+	if err := repo.CreateLogEvent(ctx, event); err != nil {
+		t.Fatalf("CreateLogEvent() error = %v", err)
+	}
+	if _, err := repo.FailJob(ctx, job.ID, model.FailureKindCrash, "makemkvcon: read error on disc 3"); err != nil {
+		t.Fatalf("FailJob() error = %v", err)
+	}
+
+	search := func(query string) []model.SearchResult {
+		t.Helper()
+		results, err := repo.Search(ctx, query)
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", query, err)
+		}
+		return results
+	}
+	kinds := func(results []model.SearchResult) map[model.SearchKind]model.SearchResult {
+		out := make(map[model.SearchKind]model.SearchResult)
+		for _, r := range results {
+			out[r.Kind] = r
+		}
+		return out
+	}
+
+	t.Run("item and season names", func(t *testing.T) {
+		got := kinds(search("breaking"))
+		if len(got) != 2 || got[model.SearchItem].MediaItemID != show.ID {
+			t.Fatalf("Search() = %+v, want the show and its season", got)
+		}
+		if s := got[model.SearchSeason]; s.SeasonID == nil || *s.SeasonID != season.ID {
+			t.Errorf("season result = %+v, want season %d", s, season.ID)
+		}
+		if got := search("bad season 2"); len(got) != 1 || got[0].Kind != model.SearchSeason {
+			t.Errorf("Search(season label) = %+v, want the season", got)
+		}
+	})
+
+	t.Run("job errors and logs by prefix", func(t *testing.T) {
+		got := kinds(search("read err"))
+		r, ok := got[model.SearchJob]
+		if !ok || r.JobID == nil || *r.JobID != job.ID || r.MediaItemID != show.ID || r.SeasonID == nil {
+			t.Fatalf("Search() = %+v, want the failed job", got)
+		}
+		if r.Snippet != "makemkvcon: [read] [error] on disc 3" {
+			t.Errorf("Snippet = %q", r.Snippet)
+		}
+		if got := kinds(search("uncorrectable")); got[model.SearchLog].JobID == nil {
+			t.Errorf("Search() = %+v, want the log event", got)
+		}
+	})
+
+	t.Run("follows renames and deletes", func(t *testing.T) {
+		if _, err := database.db.ExecContext(ctx, `UPDATE media_items SET name = 'Better Call Saul' WHERE id = ?`, show.ID); err != nil {
+			t.Fatalf("rename error = %v", err)
+		}
+		if got := search("breaking"); len(got) != 0 {
+			t.Errorf("Search(old name) = %+v, want nothing", got)
+		}
+		if got := search("saul"); len(got) != 2 {
+			t.Errorf("Search(new name) = %+v, want the show and its season", got)
+		}
+
+		if _, err := database.db.ExecContext(ctx, `DELETE FROM media_items WHERE id = ?`, show.ID); err != nil {
+			t.Fatalf("delete error = %v", err)
+		}
+		if got := search("error"); len(got) != 0 {
+			t.Errorf("Search() after delete = %+v, want nothing", got)
+		}
+	})
+
+	t.Run("query syntax is plain text", func(t *testing.T) {
+		for _, query := range []string{`"`, `AND`, `a:b`, `(x`, `   `} {
+			if _, err := repo.Search(ctx, query); err != nil {
+				t.Errorf("Search(%q) error = %v", query, err)
+			}
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cuivienor/media-pipeline/internal/model"
@@ -12,7 +13,9 @@ import (
 
 // SQLiteRepository implements Repository using SQLite
 type SQLiteRepository struct {
-	db *DB
+	db    *DB
+	actor string  // Recorded with each transition; see WithActor
+	tx    *sql.Tx // Set on the repository WithTx passes to its function
 }
 
 // NewSQLiteRepository creates a new SQLite repository
@@ -23,8 +26,8 @@ func NewSQLiteRepository(db *DB) *SQLiteRepository {
 // CreateMediaItem creates a new media item
 func (r *SQLiteRepository) CreateMediaItem(ctx context.Context, item *model.MediaItem) error {
 	query := `
-		INSERT INTO media_items (type, name, safe_name, season, tmdb_id, tvdb_id, status, current_stage, stage_status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO media_items (type, name, safe_name, year, season, tmdb_id, tvdb_id, status, current_stage, stage_status, autopilot, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	// Set defaults if not provided
@@ -39,16 +42,18 @@ func (r *SQLiteRepository) CreateMediaItem(ctx context.Context, item *model.Medi
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
-	result, err := r.db.db.ExecContext(ctx, query,
+	result, err := r.exec(ctx, query,
 		item.Type,
 		item.Name,
 		item.SafeName,
+		item.Year,
 		item.Season,
 		item.TmdbID,
 		item.TvdbID,
 		itemStatus,
 		item.CurrentStage.String(),
 		stageStatus,
+		item.Autopilot,
 		now,
 		now,
 	)
@@ -62,31 +67,39 @@ func (r *SQLiteRepository) CreateMediaItem(ctx context.Context, item *model.Medi
 	}
 
 	item.ID = id
+	item.Version = 1
 	return nil
 }
 
 // GetMediaItem retrieves a media item by ID
 func (r *SQLiteRepository) GetMediaItem(ctx context.Context, id int64) (*model.MediaItem, error) {
 	query := `
-		SELECT id, type, name, safe_name, season, tmdb_id, tvdb_id, created_at, updated_at
+		SELECT id, type, name, safe_name, year, season, tmdb_id, tvdb_id, status, current_stage, stage_status, autopilot, created_at, updated_at, version
 		FROM media_items
 		WHERE id = ?
 	`
 
 	var item model.MediaItem
-	var season, tmdbID, tvdbID sql.NullInt64
+	var year, season, tmdbID, tvdbID sql.NullInt64
+	var stageStr, stageStatusStr sql.NullString
 	var createdAt, updatedAt string
 
-	err := r.db.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.conn().QueryRowContext(ctx, query, id).Scan(
 		&item.ID,
 		&item.Type,
 		&item.Name,
 		&item.SafeName,
+		&year,
 		&season,
 		&tmdbID,
 		&tvdbID,
+		&item.ItemStatus,
+		&stageStr,
+		&stageStatusStr,
+		&item.Autopilot,
 		&createdAt,
 		&updatedAt,
+		&item.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -95,6 +108,10 @@ func (r *SQLiteRepository) GetMediaItem(ctx context.Context, id int64) (*model.M
 		return nil, fmt.Errorf("failed to get media item: %w", err)
 	}
 
+	if year.Valid {
+		y := int(year.Int64)
+		item.Year = &y
+	}
 	if season.Valid {
 		s := int(season.Int64)
 		item.Season = &s
@@ -107,6 +124,12 @@ func (r *SQLiteRepository) GetMediaItem(ctx context.Context, id int64) (*model.M
 		id := int(tvdbID.Int64)
 		item.TvdbID = &id
 	}
+	if stageStr.Valid {
+		item.CurrentStage = parseStage(stageStr.String)
+	}
+	if stageStatusStr.Valid {
+		item.StageStatus = model.Status(stageStatusStr.String)
+	}
 
 	return &item, nil
 }
@@ -114,13 +137,13 @@ func (r *SQLiteRepository) GetMediaItem(ctx context.Context, id int64) (*model.M
 // GetMediaItemBySafeName retrieves a media item by safe name and season
 func (r *SQLiteRepository) GetMediaItemBySafeName(ctx context.Context, safeName string, season *int) (*model.MediaItem, error) {
 	query := `
-		SELECT id, type, name, safe_name, season, tmdb_id, tvdb_id, created_at, updated_at
+		SELECT id, type, name, safe_name, year, season, tmdb_id, tvdb_id, autopilot, created_at, updated_at
 		FROM media_items
 		WHERE safe_name = ? AND (? IS NULL AND season IS NULL OR season = ?)
 	`
 
 	var item model.MediaItem
-	var dbSeason, tmdbID, tvdbID sql.NullInt64
+	var year, dbSeason, tmdbID, tvdbID sql.NullInt64
 	var createdAt, updatedAt string
 
 	var seasonVal interface{}
@@ -128,14 +151,16 @@ func (r *SQLiteRepository) GetMediaItemBySafeName(ctx context.Context, safeName
 		seasonVal = *season
 	}
 
-	err := r.db.db.QueryRowContext(ctx, query, safeName, seasonVal, seasonVal).Scan(
+	err := r.conn().QueryRowContext(ctx, query, safeName, seasonVal, seasonVal).Scan(
 		&item.ID,
 		&item.Type,
 		&item.Name,
 		&item.SafeName,
+		&year,
 		&dbSeason,
 		&tmdbID,
 		&tvdbID,
+		&item.Autopilot,
 		&createdAt,
 		&updatedAt,
 	)
@@ -146,6 +171,10 @@ func (r *SQLiteRepository) GetMediaItemBySafeName(ctx context.Context, safeName
 		return nil, fmt.Errorf("failed to get media item by safe name: %w", err)
 	}
 
+	if year.Valid {
+		y := int(year.Int64)
+		item.Year = &y
+	}
 	if dbSeason.Valid {
 		s := int(dbSeason.Int64)
 		item.Season = &s
@@ -165,7 +194,7 @@ func (r *SQLiteRepository) GetMediaItemBySafeName(ctx context.Context, safeName
 // ListMediaItems lists media items with optional filters
 func (r *SQLiteRepository) ListMediaItems(ctx context.Context, opts ListOptions) ([]model.MediaItem, error) {
 	query := `
-		SELECT id, type, name, safe_name, season, tmdb_id, tvdb_id, created_at, updated_at
+		SELECT id, type, name, safe_name, season, tmdb_id, tvdb_id, autopilot, created_at, updated_at
 		FROM media_items
 		WHERE 1=1
 	`
@@ -197,7 +226,7 @@ func (r *SQLiteRepository) ListMediaItems(ctx context.Context, opts ListOptions)
 		args = append(args, opts.Offset)
 	}
 
-	rows, err := r.db.db.QueryContext(ctx, query, args...)
+	rows, err := r.conn().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list media items: %w", err)
 	}
@@ -217,6 +246,7 @@ func (r *SQLiteRepository) ListMediaItems(ctx context.Context, opts ListOptions)
 			&season,
 			&tmdbID,
 			&tvdbID,
+			&item.Autopilot,
 			&createdAt,
 			&updatedAt,
 		)
@@ -247,18 +277,55 @@ func (r *SQLiteRepository) ListMediaItems(ctx context.Context, opts ListOptions)
 	return items, nil
 }
 
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 // CreateJob creates a new job
 func (r *SQLiteRepository) CreateJob(ctx context.Context, job *model.Job) error {
+	return retryBusy(ctx, func() error { return insertJob(ctx, r.conn(), job) })
+}
+
+// EnqueueJob creates a job and moves its owning season or media item to the
+// job's stage in progress, in a single transaction. With fromPendingOnly the
+// owner is only updated while its stage status is still pending.
+func (r *SQLiteRepository) EnqueueJob(ctx context.Context, job *model.Job, fromPendingOnly bool) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := insertJob(ctx, tx, job); err != nil {
+			return err
+		}
+
+		table, ownerID := "media_items", job.MediaItemID
+		if job.SeasonID != nil {
+			table, ownerID = "seasons", *job.SeasonID
+		}
+		to := model.StageState{Stage: job.Stage, Status: model.StatusInProgress}
+		return r.moveOwner(ctx, tx, table, ownerID, to, &job.ID, fromPendingOnly)
+	})
+}
+
+// insertJob inserts a job row and fills in its ID and run ID
+func insertJob(ctx context.Context, ex execer, job *model.Job) error {
 	query := `
 		INSERT INTO jobs (
 			media_item_id, season_id, stage, status, disc, worker_id, pid,
-			input_dir, output_dir, log_path, error_message, progress,
+			input_dir, output_dir, log_path, error_message, progress, priority,
+			attempt, run_id, not_before, failure_kind,
 			started_at, completed_at, created_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	now := time.Now().UTC().Format(time.RFC3339)
+	// Jobs imported from elsewhere keep their creation time
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	if !job.CreatedAt.IsZero() {
+		createdAt = job.CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	if job.Attempt == 0 {
+		job.Attempt = 1
+	}
 
 	var startedAt, completedAt interface{}
 	if job.StartedAt != nil {
@@ -268,7 +335,7 @@ func (r *SQLiteRepository) CreateJob(ctx context.Context, job *model.Job) error
 		completedAt = job.CompletedAt.UTC().Format(time.RFC3339)
 	}
 
-	result, err := r.db.db.ExecContext(ctx, query,
+	result, err := ex.ExecContext(ctx, query,
 		job.MediaItemID,
 		job.SeasonID,
 		job.Stage.String(),
@@ -281,9 +348,14 @@ func (r *SQLiteRepository) CreateJob(ctx context.Context, job *model.Job) error
 		job.LogPath,
 		job.ErrorMessage,
 		job.Progress,
+		job.Priority,
+		job.Attempt,
+		nullableRunID(job),
+		formatNullableTime(job.NotBefore),
+		nullableString(string(job.FailureKind)),
 		startedAt,
 		completedAt,
-		now,
+		createdAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert job: %w", err)
@@ -295,44 +367,21 @@ func (r *SQLiteRepository) CreateJob(ctx context.Context, job *model.Job) error
 	}
 
 	job.ID = id
+	job.Version = 1
+	if job.RunID == 0 {
+		job.RunID = id
+	}
 	return nil
 }
 
 // GetJob retrieves a job by ID
 func (r *SQLiteRepository) GetJob(ctx context.Context, id int64) (*model.Job, error) {
-	query := `
-		SELECT id, media_item_id, season_id, stage, status, disc, worker_id, pid,
-		       input_dir, output_dir, log_path, error_message, progress,
-		       started_at, completed_at, created_at
+	query := `SELECT ` + jobColumns + `
 		FROM jobs
 		WHERE id = ?
 	`
 
-	var job model.Job
-	var stageStr string
-	var seasonID, disc sql.NullInt64
-	var workerID, inputDir, outputDir, logPath, errorMessage sql.NullString
-	var pid sql.NullInt64
-	var startedAt, completedAt, createdAt sql.NullString
-
-	err := r.db.db.QueryRowContext(ctx, query, id).Scan(
-		&job.ID,
-		&job.MediaItemID,
-		&seasonID,
-		&stageStr,
-		&job.Status,
-		&disc,
-		&workerID,
-		&pid,
-		&inputDir,
-		&outputDir,
-		&logPath,
-		&errorMessage,
-		&job.Progress,
-		&startedAt,
-		&completedAt,
-		&createdAt,
-	)
+	job, err := scanJob(r.conn().QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -340,64 +389,12 @@ func (r *SQLiteRepository) GetJob(ctx context.Context, id int64) (*model.Job, er
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
 
-	// Parse stage
-	job.Stage = parseStage(stageStr)
-
-	// Handle nullable fields
-	if seasonID.Valid {
-		s := seasonID.Int64
-		job.SeasonID = &s
-	}
-	if disc.Valid {
-		d := int(disc.Int64)
-		job.Disc = &d
-	}
-	if workerID.Valid {
-		job.WorkerID = workerID.String
-	}
-	if pid.Valid {
-		job.PID = int(pid.Int64)
-	}
-	if inputDir.Valid {
-		job.InputDir = inputDir.String
-	}
-	if outputDir.Valid {
-		job.OutputDir = outputDir.String
-	}
-	if logPath.Valid {
-		job.LogPath = logPath.String
-	}
-	if errorMessage.Valid {
-		job.ErrorMessage = errorMessage.String
-	}
-	if startedAt.Valid {
-		t, err := time.Parse(time.RFC3339, startedAt.String)
-		if err == nil {
-			job.StartedAt = &t
-		}
-	}
-	if completedAt.Valid {
-		t, err := time.Parse(time.RFC3339, completedAt.String)
-		if err == nil {
-			job.CompletedAt = &t
-		}
-	}
-	if createdAt.Valid {
-		t, err := time.Parse(time.RFC3339, createdAt.String)
-		if err == nil {
-			job.CreatedAt = t
-		}
-	}
-
-	return &job, nil
+	return job, nil
 }
 
 // GetActiveJobForStage retrieves an active job for a specific stage
 func (r *SQLiteRepository) GetActiveJobForStage(ctx context.Context, mediaItemID int64, stage model.Stage, disc *int) (*model.Job, error) {
-	query := `
-		SELECT id, media_item_id, stage, status, disc, worker_id, pid,
-		       input_dir, output_dir, log_path, error_message, progress,
-		       started_at, completed_at, created_at
+	query := `SELECT ` + jobColumns + `
 		FROM jobs
 		WHERE media_item_id = ?
 		  AND stage = ?
@@ -411,30 +408,7 @@ func (r *SQLiteRepository) GetActiveJobForStage(ctx context.Context, mediaItemID
 		discVal = *disc
 	}
 
-	var job model.Job
-	var stageStr string
-	var dbDisc sql.NullInt64
-	var workerID, inputDir, outputDir, logPath, errorMessage sql.NullString
-	var pid sql.NullInt64
-	var startedAt, completedAt, createdAt sql.NullString
-
-	err := r.db.db.QueryRowContext(ctx, query, mediaItemID, stage.String(), discVal, discVal).Scan(
-		&job.ID,
-		&job.MediaItemID,
-		&stageStr,
-		&job.Status,
-		&dbDisc,
-		&workerID,
-		&pid,
-		&inputDir,
-		&outputDir,
-		&logPath,
-		&errorMessage,
-		&job.Progress,
-		&startedAt,
-		&completedAt,
-		&createdAt,
-	)
+	job, err := scanJob(r.conn().QueryRowContext(ctx, query, mediaItemID, stage.String(), discVal, discVal))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -442,62 +416,21 @@ func (r *SQLiteRepository) GetActiveJobForStage(ctx context.Context, mediaItemID
 		return nil, fmt.Errorf("failed to get active job: %w", err)
 	}
 
-	// Parse stage
-	job.Stage = parseStage(stageStr)
-
-	// Handle nullable fields
-	if dbDisc.Valid {
-		d := int(dbDisc.Int64)
-		job.Disc = &d
-	}
-	if workerID.Valid {
-		job.WorkerID = workerID.String
-	}
-	if pid.Valid {
-		job.PID = int(pid.Int64)
-	}
-	if inputDir.Valid {
-		job.InputDir = inputDir.String
-	}
-	if outputDir.Valid {
-		job.OutputDir = outputDir.String
-	}
-	if logPath.Valid {
-		job.LogPath = logPath.String
-	}
-	if errorMessage.Valid {
-		job.ErrorMessage = errorMessage.String
-	}
-	if startedAt.Valid {
-		t, err := time.Parse(time.RFC3339, startedAt.String)
-		if err == nil {
-			job.StartedAt = &t
-		}
-	}
-	if completedAt.Valid {
-		t, err := time.Parse(time.RFC3339, completedAt.String)
-		if err == nil {
-			job.CompletedAt = &t
-		}
-	}
-	if createdAt.Valid {
-		t, err := time.Parse(time.RFC3339, createdAt.String)
-		if err == nil {
-			job.CreatedAt = t
-		}
-	}
-
-	return &job, nil
+	return job, nil
 }
 
-// UpdateJob updates all fields of a job
+// UpdateJob updates all fields of a job other than its progress, if the job
+// is still at the version it was read at; otherwise it returns a
+// *ConflictError. A status change must be allowed by the state machine.
 func (r *SQLiteRepository) UpdateJob(ctx context.Context, job *model.Job) error {
 	query := `
 		UPDATE jobs
 		SET media_item_id = ?, stage = ?, status = ?, disc = ?,
 		    worker_id = ?, pid = ?, input_dir = ?, output_dir = ?,
-		    log_path = ?, error_message = ?, started_at = ?, completed_at = ?
-		WHERE id = ?
+		    log_path = ?, error_message = ?, priority = ?, attempt = ?, run_id = ?,
+		    not_before = ?, failure_kind = ?, started_at = ?, completed_at = ?,
+		    version = version + 1
+		WHERE id = ? AND version = ?
 	`
 
 	var startedAt, completedAt interface{}
@@ -508,54 +441,163 @@ func (r *SQLiteRepository) UpdateJob(ctx context.Context, job *model.Job) error
 		completedAt = job.CompletedAt.UTC().Format(time.RFC3339)
 	}
 
-	_, err := r.db.db.ExecContext(ctx, query,
-		job.MediaItemID,
-		job.Stage.String(),
-		job.Status,
-		job.Disc,
-		job.WorkerID,
-		job.PID,
-		job.InputDir,
-		job.OutputDir,
-		job.LogPath,
-		job.ErrorMessage,
-		startedAt,
-		completedAt,
-		job.ID,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update job: %w", err)
-	}
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		t, from, err := jobState(ctx, tx, job.ID)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return nil
+		}
+		if err := checkVersion(ctx, tx, "jobs", job.ID, job.Version); err != nil {
+			return err
+		}
+		if err := model.CheckJobTransition(job.ID, from, job.Status); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query,
+			job.MediaItemID,
+			job.Stage.String(),
+			job.Status,
+			job.Disc,
+			job.WorkerID,
+			job.PID,
+			job.InputDir,
+			job.OutputDir,
+			job.LogPath,
+			job.ErrorMessage,
+			job.Priority,
+			job.Attempt,
+			nullableRunID(job),
+			formatNullableTime(job.NotBefore),
+			nullableString(string(job.FailureKind)),
+			startedAt,
+			completedAt,
+			job.ID,
+			job.Version,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update job: %w", err)
+		}
+		if err := r.recordJobTransition(ctx, tx, t, job.Status); err != nil {
+			return err
+		}
+		job.Version++
+		return nil
+	})
+}
+
+// StartJob marks a job in progress on a worker and process, recording the
+// directories it reads and writes. Empty directories are left as they are.
+// Only these columns are written, so it never undoes a concurrent update.
+func (r *SQLiteRepository) StartJob(ctx context.Context, id int64, workerID string, pid int, inputDir, outputDir string) error {
+	query := `
+		UPDATE jobs
+		SET status = ?, worker_id = ?, pid = ?, started_at = ?,
+		    input_dir = COALESCE(NULLIF(?, ''), input_dir),
+		    output_dir = COALESCE(NULLIF(?, ''), output_dir),
+		    version = version + 1
+		WHERE id = ?
+	`
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		t, from, err := jobState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return fmt.Errorf("job %d not found", id)
+		}
+		if err := model.CheckJobTransition(id, from, model.JobStatusInProgress); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, model.JobStatusInProgress, workerID, pid, now, inputDir, outputDir, id); err != nil {
+			return fmt.Errorf("failed to start job: %w", err)
+		}
+		return r.recordJobTransition(ctx, tx, t, model.JobStatusInProgress)
+	})
+}
 
+// SetJobOutputDir records where a job wrote its output
+func (r *SQLiteRepository) SetJobOutputDir(ctx context.Context, id int64, dir string) error {
+	query := `UPDATE jobs SET output_dir = ?, version = version + 1 WHERE id = ?`
+	if _, err := r.exec(ctx, query, dir, id); err != nil {
+		return fmt.Errorf("failed to set job output dir: %w", err)
+	}
 	return nil
 }
 
-// UpdateJobStatus updates a job's status and optionally sets error message and completion time
+// UpdateJobStatus updates a job's status and optionally sets error message and completion time.
+// The change must be allowed by the state machine.
 func (r *SQLiteRepository) UpdateJobStatus(ctx context.Context, id int64, status model.JobStatus, errorMsg string) error {
 	query := `
 		UPDATE jobs
-		SET status = ?, error_message = ?, completed_at = ?
+		SET status = ?, error_message = ?, completed_at = ?, version = version + 1
 		WHERE id = ?
 	`
 
 	var completedAt interface{}
-	if status == model.JobStatusCompleted || status == model.JobStatusFailed {
+	if status == model.JobStatusCompleted || status == model.JobStatusFailed || status == model.JobStatusCancelled {
 		completedAt = time.Now().UTC().Format(time.RFC3339)
 	}
 
-	_, err := r.db.db.ExecContext(ctx, query, status, errorMsg, completedAt, id)
-	if err != nil {
-		return fmt.Errorf("failed to update job status: %w", err)
-	}
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		t, from, err := jobState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return fmt.Errorf("job %d not found", id)
+		}
+		if err := model.CheckJobTransition(id, from, status); err != nil {
+			return err
+		}
 
-	return nil
+		if _, err := tx.ExecContext(ctx, query, status, errorMsg, completedAt, id); err != nil {
+			return fmt.Errorf("failed to update job status: %w", err)
+		}
+		return r.recordJobTransition(ctx, tx, t, status)
+	})
+}
+
+// FailJob marks an active job as failed, recording why and what kind of
+// failure it was. Returns false if the job had already finished.
+func (r *SQLiteRepository) FailJob(ctx context.Context, id int64, kind model.FailureKind, errorMsg string) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'failed', failure_kind = ?, error_message = ?, completed_at = ?, version = version + 1
+		WHERE id = ? AND status IN ('pending', 'in_progress')
+	`
+
+	completedAt := time.Now().UTC().Format(time.RFC3339)
+	var failed bool
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		failed = false
+		t, _, err := jobState(ctx, tx, id)
+		if err != nil || t == nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, query, string(kind), errorMsg, completedAt, id)
+		if err != nil {
+			return fmt.Errorf("failed to fail job: %w", err)
+		}
+		if failed, err = affectedOne(result); err != nil || !failed {
+			return err
+		}
+		return r.recordJobTransition(ctx, tx, t, model.JobStatusFailed)
+	})
+	return failed, err
 }
 
 // UpdateJobProgress updates a job's progress percentage (0-100)
 func (r *SQLiteRepository) UpdateJobProgress(ctx context.Context, id int64, progress int) error {
 	query := `UPDATE jobs SET progress = ? WHERE id = ?`
 
-	_, err := r.db.db.ExecContext(ctx, query, progress, id)
+	_, err := r.exec(ctx, query, progress, id)
 	if err != nil {
 		return fmt.Errorf("failed to update job progress: %w", err)
 	}
@@ -565,16 +607,13 @@ func (r *SQLiteRepository) UpdateJobProgress(ctx context.Context, id int64, prog
 
 // ListJobsForMedia lists all jobs for a media item
 func (r *SQLiteRepository) ListJobsForMedia(ctx context.Context, mediaItemID int64) ([]model.Job, error) {
-	query := `
-		SELECT id, media_item_id, season_id, stage, status, disc, worker_id, pid,
-		       input_dir, output_dir, log_path, error_message, progress,
-		       started_at, completed_at, created_at
+	query := `SELECT ` + jobColumns + `
 		FROM jobs
 		WHERE media_item_id = ?
-		ORDER BY created_at ASC
+		ORDER BY created_at ASC, id ASC
 	`
 
-	rows, err := r.db.db.QueryContext(ctx, query, mediaItemID)
+	rows, err := r.conn().QueryContext(ctx, query, mediaItemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list jobs: %w", err)
 	}
@@ -582,92 +621,277 @@ func (r *SQLiteRepository) ListJobsForMedia(ctx context.Context, mediaItemID int
 
 	var jobs []model.Job
 	for rows.Next() {
-		var job model.Job
-		var stageStr string
-		var seasonID, disc sql.NullInt64
-		var workerID, inputDir, outputDir, logPath, errorMessage sql.NullString
-		var pid sql.NullInt64
-		var startedAt, completedAt, createdAt sql.NullString
-
-		err := rows.Scan(
-			&job.ID,
-			&job.MediaItemID,
-			&seasonID,
-			&stageStr,
-			&job.Status,
-			&disc,
-			&workerID,
-			&pid,
-			&inputDir,
-			&outputDir,
-			&logPath,
-			&errorMessage,
-			&job.Progress,
-			&startedAt,
-			&completedAt,
-			&createdAt,
-		)
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan job: %w", err)
 		}
+		jobs = append(jobs, *job)
+	}
 
-		// Parse stage
-		job.Stage = parseStage(stageStr)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
 
-		// Handle nullable fields
-		if seasonID.Valid {
-			s := seasonID.Int64
-			job.SeasonID = &s
-		}
-		if disc.Valid {
-			d := int(disc.Int64)
-			job.Disc = &d
+	return jobs, nil
+}
+
+// SetJobPriority changes a job's priority
+func (r *SQLiteRepository) SetJobPriority(ctx context.Context, id int64, priority int) error {
+	query := `UPDATE jobs SET priority = ?, version = version + 1 WHERE id = ?`
+
+	_, err := r.exec(ctx, query, priority, id)
+	if err != nil {
+		return fmt.Errorf("failed to set job priority: %w", err)
+	}
+
+	return nil
+}
+
+// ListJobsByStatus lists all jobs with the given status in dispatch order:
+// highest priority first, oldest first within a priority
+func (r *SQLiteRepository) ListJobsByStatus(ctx context.Context, status model.JobStatus) ([]model.Job, error) {
+	query := `SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE status = ?
+		ORDER BY priority DESC, created_at ASC, id ASC
+	`
+
+	rows, err := r.conn().QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs by status: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []model.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
 		}
-		if workerID.Valid {
-			job.WorkerID = workerID.String
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ClaimJob atomically moves a pending job to in_progress, recording the
+// worker and process that claimed it. A limit above 0 caps how many jobs of
+// the job's stage may be in progress on that worker.
+// Returns false if the job was no longer pending (claimed by someone else)
+// or the worker had no free slot.
+func (r *SQLiteRepository) ClaimJob(ctx context.Context, id int64, workerID string, pid int, limit int) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET status = ?, worker_id = ?, pid = ?, started_at = ?, version = version + 1
+		WHERE id = ? AND status = ?
+	`
+	now := time.Now().UTC().Format(time.RFC3339)
+	args := []interface{}{model.JobStatusInProgress, workerID, pid, now, id, model.JobStatusPending}
+
+	// Counted in the same statement, so two daemons can't both take the last slot
+	if limit > 0 {
+		query += `
+		  AND (SELECT COUNT(*) FROM jobs running
+		       WHERE running.status = ? AND running.stage = jobs.stage AND running.worker_id = ?) < ?
+		`
+		args = append(args, model.JobStatusInProgress, workerID, limit)
+	}
+
+	var claimed bool
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		claimed = false
+		t, _, err := jobState(ctx, tx, id)
+		if err != nil || t == nil {
+			return err
 		}
-		if pid.Valid {
-			job.PID = int(pid.Int64)
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to claim job: %w", err)
 		}
-		if inputDir.Valid {
-			job.InputDir = inputDir.String
+		if claimed, err = affectedOne(result); err != nil || !claimed {
+			return err
 		}
-		if outputDir.Valid {
-			job.OutputDir = outputDir.String
+		return r.recordJobTransition(ctx, tx, t, model.JobStatusInProgress)
+	})
+	return claimed, err
+}
+
+// CancelPendingJob atomically moves a pending job to cancelled.
+// Returns false if the job was no longer pending (already claimed).
+func (r *SQLiteRepository) CancelPendingJob(ctx context.Context, id int64) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET status = ?, error_message = ?, completed_at = ?, version = version + 1
+		WHERE id = ? AND status = ?
+	`
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var cancelled bool
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		cancelled = false
+		t, _, err := jobState(ctx, tx, id)
+		if err != nil || t == nil {
+			return err
 		}
-		if logPath.Valid {
-			job.LogPath = logPath.String
+
+		result, err := tx.ExecContext(ctx, query, model.JobStatusCancelled, "cancelled", now, id, model.JobStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to cancel job: %w", err)
 		}
-		if errorMessage.Valid {
-			job.ErrorMessage = errorMessage.String
+		if cancelled, err = affectedOne(result); err != nil || !cancelled {
+			return err
 		}
-		if startedAt.Valid {
-			t, err := time.Parse(time.RFC3339, startedAt.String)
-			if err == nil {
-				job.StartedAt = &t
-			}
+		return r.recordJobTransition(ctx, tx, t, model.JobStatusCancelled)
+	})
+	return cancelled, err
+}
+
+// affectedOne reports whether a guarded update changed its row
+func affectedOne(result sql.Result) (bool, error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// RequestJobCancel asks the daemon running an in_progress job to stop it.
+// Returns false if the job is no longer in progress.
+func (r *SQLiteRepository) RequestJobCancel(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE jobs SET cancel_requested = 1, version = version + 1 WHERE id = ? AND status = ?`
+
+	result, err := r.exec(ctx, query, id, model.JobStatusInProgress)
+	if err != nil {
+		return false, fmt.Errorf("failed to request job cancel: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// jobColumns is the column list read by scanJob
+const jobColumns = `id, media_item_id, season_id, stage, status, disc, worker_id, pid,
+		       input_dir, output_dir, log_path, error_message, progress, priority,
+		       attempt, COALESCE(run_id, id), not_before, failure_kind,
+		       started_at, completed_at, created_at, cancel_requested, version`
+
+// nullableRunID returns the run_id to store: NULL for the first attempt of a run,
+// which is its own run
+func nullableRunID(job *model.Job) interface{} {
+	if job.RunID == 0 || job.RunID == job.ID {
+		return nil
+	}
+	return job.RunID
+}
+
+// formatNullableTime formats an optional timestamp for storage
+func formatNullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// nullableString stores an empty string as NULL
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob scans a single job row selected with jobColumns
+func scanJob(row rowScanner) (*model.Job, error) {
+	var job model.Job
+	var stageStr string
+	var seasonID, disc sql.NullInt64
+	var workerID, inputDir, outputDir, logPath, errorMessage sql.NullString
+	var pid sql.NullInt64
+	var notBefore, failureKind sql.NullString
+	var startedAt, completedAt, createdAt sql.NullString
+
+	err := row.Scan(
+		&job.ID,
+		&job.MediaItemID,
+		&seasonID,
+		&stageStr,
+		&job.Status,
+		&disc,
+		&workerID,
+		&pid,
+		&inputDir,
+		&outputDir,
+		&logPath,
+		&errorMessage,
+		&job.Progress,
+		&job.Priority,
+		&job.Attempt,
+		&job.RunID,
+		&notBefore,
+		&failureKind,
+		&startedAt,
+		&completedAt,
+		&createdAt,
+		&job.CancelRequested,
+		&job.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Stage = parseStage(stageStr)
+
+	if seasonID.Valid {
+		s := seasonID.Int64
+		job.SeasonID = &s
+	}
+	if disc.Valid {
+		d := int(disc.Int64)
+		job.Disc = &d
+	}
+	job.WorkerID = workerID.String
+	job.PID = int(pid.Int64)
+	job.InputDir = inputDir.String
+	job.OutputDir = outputDir.String
+	job.LogPath = logPath.String
+	job.ErrorMessage = errorMessage.String
+	job.FailureKind = model.FailureKind(failureKind.String)
+	if notBefore.Valid {
+		if t, err := time.Parse(time.RFC3339, notBefore.String); err == nil {
+			job.NotBefore = &t
 		}
-		if completedAt.Valid {
-			t, err := time.Parse(time.RFC3339, completedAt.String)
-			if err == nil {
-				job.CompletedAt = &t
-			}
+	}
+	if startedAt.Valid {
+		if t, err := time.Parse(time.RFC3339, startedAt.String); err == nil {
+			job.StartedAt = &t
 		}
-		if createdAt.Valid {
-			t, err := time.Parse(time.RFC3339, createdAt.String)
-			if err == nil {
-				job.CreatedAt = t
-			}
+	}
+	if completedAt.Valid {
+		if t, err := time.Parse(time.RFC3339, completedAt.String); err == nil {
+			job.CompletedAt = &t
 		}
-
-		jobs = append(jobs, job)
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	if createdAt.Valid {
+		if t, err := time.Parse(time.RFC3339, createdAt.String); err == nil {
+			job.CreatedAt = t
+		}
 	}
 
-	return jobs, nil
+	return &job, nil
 }
 
 // CreateLogEvent creates a new log event
@@ -679,7 +903,7 @@ func (r *SQLiteRepository) CreateLogEvent(ctx context.Context, event *model.LogE
 
 	now := time.Now().UTC().Format(time.RFC3339)
 
-	result, err := r.db.db.ExecContext(ctx, query,
+	result, err := r.exec(ctx, query,
 		event.JobID,
 		event.Level,
 		event.Message,
@@ -713,7 +937,7 @@ func (r *SQLiteRepository) ListLogEvents(ctx context.Context, jobID int64, limit
 		args = append(args, limit)
 	}
 
-	rows, err := r.db.db.QueryContext(ctx, query, args...)
+	rows, err := r.conn().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list log events: %w", err)
 	}
@@ -761,7 +985,7 @@ func (r *SQLiteRepository) GetDiscProgress(ctx context.Context, mediaItemID int6
 		ORDER BY disc ASC
 	`
 
-	rows, err := r.db.db.QueryContext(ctx, query, mediaItemID)
+	rows, err := r.conn().QueryContext(ctx, query, mediaItemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get disc progress: %w", err)
 	}
@@ -809,15 +1033,17 @@ func parseStage(s string) model.Stage {
 // CreateSeason creates a new season
 func (r *SQLiteRepository) CreateSeason(ctx context.Context, season *model.Season) error {
 	query := `
-		INSERT INTO seasons (item_id, number, current_stage, stage_status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO seasons (item_id, number, current_stage, stage_status, autopilot, disc_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	now := time.Now().UTC().Format(time.RFC3339)
-	result, err := r.db.db.ExecContext(ctx, query,
+	result, err := r.exec(ctx, query,
 		season.ItemID,
 		season.Number,
 		season.CurrentStage.String(),
 		season.StageStatus,
+		season.Autopilot,
+		season.DiscCount,
 		now,
 		now,
 	)
@@ -829,13 +1055,14 @@ func (r *SQLiteRepository) CreateSeason(ctx context.Context, season *model.Seaso
 		return fmt.Errorf("failed to get last insert id: %w", err)
 	}
 	season.ID = id
+	season.Version = 1
 	return nil
 }
 
 // GetSeason retrieves a season by ID
 func (r *SQLiteRepository) GetSeason(ctx context.Context, id int64) (*model.Season, error) {
 	query := `
-		SELECT id, item_id, number, current_stage, stage_status, created_at, updated_at
+		SELECT id, item_id, number, current_stage, stage_status, autopilot, disc_count, created_at, updated_at, version
 		FROM seasons
 		WHERE id = ?
 	`
@@ -843,14 +1070,17 @@ func (r *SQLiteRepository) GetSeason(ctx context.Context, id int64) (*model.Seas
 	var stageStr, statusStr string
 	var createdAt, updatedAt string
 
-	err := r.db.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.conn().QueryRowContext(ctx, query, id).Scan(
 		&season.ID,
 		&season.ItemID,
 		&season.Number,
 		&stageStr,
 		&statusStr,
+		&season.Autopilot,
+		&season.DiscCount,
 		&createdAt,
 		&updatedAt,
+		&season.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -869,101 +1099,81 @@ func (r *SQLiteRepository) GetSeason(ctx context.Context, id int64) (*model.Seas
 
 // ListSeasonsForItem lists all seasons for a TV show item
 func (r *SQLiteRepository) ListSeasonsForItem(ctx context.Context, itemID int64) ([]model.Season, error) {
-	query := `
-		SELECT id, item_id, number, current_stage, stage_status, created_at, updated_at
-		FROM seasons
-		WHERE item_id = ?
-		ORDER BY number ASC
-	`
-	rows, err := r.db.db.QueryContext(ctx, query, itemID)
+	seasons, err := r.ListSeasonsForItems(ctx, []int64{itemID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list seasons: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
-
-	var seasons []model.Season
-	for rows.Next() {
-		var season model.Season
-		var stageStr, statusStr string
-		var createdAt, updatedAt string
+	return seasons[itemID], nil
+}
 
-		err := rows.Scan(
-			&season.ID,
-			&season.ItemID,
-			&season.Number,
-			&stageStr,
-			&statusStr,
-			&createdAt,
-			&updatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan season: %w", err)
+// UpdateSeason updates a season's stage and status, if the state machine
+// allows the move and the season is still at the version it was read at;
+// otherwise it returns a *ConflictError
+func (r *SQLiteRepository) UpdateSeason(ctx context.Context, season *model.Season) error {
+	to := model.StageState{Stage: season.CurrentStage, Status: season.StageStatus}
+	var version int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkVersion(ctx, tx, "seasons", season.ID, season.Version); err != nil {
+			return err
 		}
+		if err := r.moveOwner(ctx, tx, "seasons", season.ID, to, nil, false); err != nil {
+			return err
+		}
+		var err error
+		version, err = rowVersion(ctx, tx, "seasons", season.ID)
+		return err
+	})
+	if err == nil {
+		season.Version = version
+	}
+	return err
+}
 
-		season.CurrentStage = parseStage(stageStr)
-		season.StageStatus = model.Status(statusStr)
-		season.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		season.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-
-		seasons = append(seasons, season)
-	}
-
-	return seasons, rows.Err()
+// UpdateSeasonStage moves a season to a stage and status, if the state
+// machine allows it
+func (r *SQLiteRepository) UpdateSeasonStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return r.moveOwner(ctx, tx, "seasons", id, model.StageState{Stage: stage, Status: status}, nil, false)
+	})
 }
 
-// UpdateSeason updates a season
-func (r *SQLiteRepository) UpdateSeason(ctx context.Context, season *model.Season) error {
-	query := `
-		UPDATE seasons
-		SET current_stage = ?, stage_status = ?, updated_at = ?
-		WHERE id = ?
-	`
+// UpdateMediaItemStatus updates an item's overall status
+func (r *SQLiteRepository) UpdateMediaItemStatus(ctx context.Context, id int64, status model.ItemStatus) error {
+	query := `UPDATE media_items SET status = ?, updated_at = ?, version = version + 1 WHERE id = ?`
 	now := time.Now().UTC().Format(time.RFC3339)
-	_, err := r.db.db.ExecContext(ctx, query,
-		season.CurrentStage.String(),
-		season.StageStatus,
-		now,
-		season.ID,
-	)
+	_, err := r.exec(ctx, query, status, now, id)
 	if err != nil {
-		return fmt.Errorf("failed to update season: %w", err)
+		return fmt.Errorf("failed to update media item status: %w", err)
 	}
 	return nil
 }
 
-// UpdateSeasonStage updates a season's stage and status
-func (r *SQLiteRepository) UpdateSeasonStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error {
-	query := `
-		UPDATE seasons
-		SET current_stage = ?, stage_status = ?, updated_at = ?
-		WHERE id = ?
-	`
-	now := time.Now().UTC().Format(time.RFC3339)
-	_, err := r.db.db.ExecContext(ctx, query, stage.String(), status, now, id)
-	if err != nil {
-		return fmt.Errorf("failed to update season stage: %w", err)
-	}
-	return nil
+// UpdateMediaItemStage moves a media item to a stage and status, if the
+// state machine allows it
+func (r *SQLiteRepository) UpdateMediaItemStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return r.moveOwner(ctx, tx, "media_items", id, model.StageState{Stage: stage, Status: status}, nil, false)
+	})
 }
 
-// UpdateMediaItemStatus updates an item's overall status
-func (r *SQLiteRepository) UpdateMediaItemStatus(ctx context.Context, id int64, status model.ItemStatus) error {
-	query := `UPDATE media_items SET status = ?, updated_at = ? WHERE id = ?`
+// SetMediaItemAutopilot turns autopilot on or off for a media item
+func (r *SQLiteRepository) SetMediaItemAutopilot(ctx context.Context, id int64, enabled bool) error {
+	query := `UPDATE media_items SET autopilot = ?, updated_at = ?, version = version + 1 WHERE id = ?`
 	now := time.Now().UTC().Format(time.RFC3339)
-	_, err := r.db.db.ExecContext(ctx, query, status, now, id)
+	_, err := r.exec(ctx, query, enabled, now, id)
 	if err != nil {
-		return fmt.Errorf("failed to update media item status: %w", err)
+		return fmt.Errorf("failed to set media item autopilot: %w", err)
 	}
 	return nil
 }
 
-// UpdateMediaItemStage updates a media item's current stage and stage status
-func (r *SQLiteRepository) UpdateMediaItemStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error {
-	query := `UPDATE media_items SET current_stage = ?, stage_status = ?, updated_at = ? WHERE id = ?`
+// SetSeasonAutopilot turns autopilot on or off for a season
+func (r *SQLiteRepository) SetSeasonAutopilot(ctx context.Context, id int64, enabled bool) error {
+	query := `UPDATE seasons SET autopilot = ?, updated_at = ?, version = version + 1 WHERE id = ?`
 	now := time.Now().UTC().Format(time.RFC3339)
-	_, err := r.db.db.ExecContext(ctx, query, stage.String(), status, now, id)
+	_, err := r.exec(ctx, query, enabled, now, id)
 	if err != nil {
-		return fmt.Errorf("failed to update media item stage: %w", err)
+		return fmt.Errorf("failed to set season autopilot: %w", err)
 	}
 	return nil
 }
@@ -971,12 +1181,12 @@ func (r *SQLiteRepository) UpdateMediaItemStage(ctx context.Context, id int64, s
 // ListActiveItems lists all items (including completed - history filtering will be added later)
 func (r *SQLiteRepository) ListActiveItems(ctx context.Context) ([]model.MediaItem, error) {
 	query := `
-		SELECT id, type, name, safe_name, tmdb_id, tvdb_id, status, current_stage, stage_status, created_at, updated_at
+		SELECT id, type, name, safe_name, tmdb_id, tvdb_id, status, current_stage, stage_status, autopilot, created_at, updated_at
 		FROM media_items
 		WHERE status IN ('active', 'not_started')
 		ORDER BY updated_at DESC
 	`
-	rows, err := r.db.db.QueryContext(ctx, query)
+	rows, err := r.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list active items: %w", err)
 	}
@@ -999,6 +1209,7 @@ func (r *SQLiteRepository) ListActiveItems(ctx context.Context) ([]model.MediaIt
 			&item.ItemStatus,
 			&stageStr,
 			&stageStatusStr,
+			&item.Autopilot,
 			&createdAt,
 			&updatedAt,
 		)
@@ -1033,7 +1244,7 @@ func (r *SQLiteRepository) CreateTranscodeFile(ctx context.Context, file *model.
 		INSERT INTO transcode_files (job_id, relative_path, status, input_size, duration_secs)
 		VALUES (?, ?, ?, ?, ?)
 	`
-	result, err := r.db.db.ExecContext(ctx, query,
+	result, err := r.exec(ctx, query,
 		file.JobID,
 		file.RelativePath,
 		file.Status,
@@ -1065,7 +1276,7 @@ func (r *SQLiteRepository) GetTranscodeFile(ctx context.Context, id int64) (*mod
 	var outputSize sql.NullInt64
 	var errorMsg sql.NullString
 
-	err := r.db.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.conn().QueryRowContext(ctx, query, id).Scan(
 		&file.ID,
 		&file.JobID,
 		&file.RelativePath,
@@ -1114,7 +1325,7 @@ func (r *SQLiteRepository) ListTranscodeFiles(ctx context.Context, jobID int64)
 		WHERE job_id = ?
 		ORDER BY relative_path
 	`
-	rows, err := r.db.db.QueryContext(ctx, query, jobID)
+	rows, err := r.conn().QueryContext(ctx, query, jobID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list transcode files: %w", err)
 	}
@@ -1184,7 +1395,7 @@ func (r *SQLiteRepository) UpdateTranscodeFile(ctx context.Context, file *model.
 		completedAt = &s
 	}
 
-	_, err := r.db.db.ExecContext(ctx, query,
+	_, err := r.exec(ctx, query,
 		file.Status,
 		file.InputSize,
 		file.OutputSize,
@@ -1204,7 +1415,7 @@ func (r *SQLiteRepository) UpdateTranscodeFile(ctx context.Context, file *model.
 // UpdateTranscodeFileProgress updates just the progress percentage
 func (r *SQLiteRepository) UpdateTranscodeFileProgress(ctx context.Context, id int64, progress int) error {
 	query := `UPDATE transcode_files SET progress = ? WHERE id = ?`
-	_, err := r.db.db.ExecContext(ctx, query, progress, id)
+	_, err := r.exec(ctx, query, progress, id)
 	if err != nil {
 		return fmt.Errorf("failed to update transcode file progress: %w", err)
 	}
@@ -1229,19 +1440,37 @@ func (r *SQLiteRepository) UpdateTranscodeFileStatus(ctx context.Context, id int
 		args = []interface{}{status, errorMsg, id}
 	}
 
-	_, err := r.db.db.ExecContext(ctx, query, args...)
+	_, err := r.exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update transcode file status: %w", err)
 	}
 	return nil
 }
 
+// TranscodeCompressionRatio returns the output size of all completed transcode
+// files as a fraction of their input size, or 0 if none have completed
+func (r *SQLiteRepository) TranscodeCompressionRatio(ctx context.Context) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(output_size), 0), COALESCE(SUM(input_size), 0)
+		FROM transcode_files
+		WHERE status = ? AND input_size > 0 AND output_size > 0
+	`
+	var output, input int64
+	if err := r.conn().QueryRowContext(ctx, query, model.TranscodeFileStatusCompleted).Scan(&output, &input); err != nil {
+		return 0, fmt.Errorf("failed to get transcode compression ratio: %w", err)
+	}
+	if input == 0 {
+		return 0, nil
+	}
+	return float64(output) / float64(input), nil
+}
+
 // GetJobOptions retrieves the JSON options for a job
 func (r *SQLiteRepository) GetJobOptions(ctx context.Context, jobID int64) (map[string]interface{}, error) {
 	query := `SELECT options FROM jobs WHERE id = ?`
 	var optionsJSON sql.NullString
 
-	err := r.db.db.QueryRowContext(ctx, query, jobID).Scan(&optionsJSON)
+	err := r.conn().QueryRowContext(ctx, query, jobID).Scan(&optionsJSON)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -1268,10 +1497,146 @@ func (r *SQLiteRepository) SetJobOptions(ctx context.Context, jobID int64, optio
 		return fmt.Errorf("failed to marshal job options: %w", err)
 	}
 
-	query := `UPDATE jobs SET options = ? WHERE id = ?`
-	_, err = r.db.db.ExecContext(ctx, query, string(optionsJSON), jobID)
+	query := `UPDATE jobs SET options = ?, version = version + 1 WHERE id = ?`
+	_, err = r.exec(ctx, query, string(optionsJSON), jobID)
 	if err != nil {
 		return fmt.Errorf("failed to set job options: %w", err)
 	}
 	return nil
 }
+
+// UpsertWorker records a worker heartbeat.
+// An empty Target keeps the target already recorded for the host: several
+// processes heartbeat for the same host, and only `media-pipeline worker`
+// knows how the daemon reaches it.
+func (r *SQLiteRepository) UpsertWorker(ctx context.Context, worker *model.Worker) error {
+	query := `
+		INSERT INTO workers (hostname, target, capabilities, last_seen)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(hostname) DO UPDATE SET
+			target = CASE WHEN excluded.target != '' THEN excluded.target ELSE workers.target END,
+			capabilities = excluded.capabilities,
+			last_seen = excluded.last_seen
+	`
+
+	caps := make([]string, len(worker.Capabilities))
+	for i, c := range worker.Capabilities {
+		caps[i] = string(c)
+	}
+
+	_, err := r.exec(ctx, query,
+		worker.Hostname,
+		worker.Target,
+		strings.Join(caps, ","),
+		worker.LastSeen.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert worker: %w", err)
+	}
+	return nil
+}
+
+// workerColumns is the column list read by scanWorker. Running jobs are
+// those recorded against the host's name or its dispatch target.
+const workerColumns = `w.hostname, w.target, w.capabilities, w.last_seen,
+		(SELECT COUNT(*) FROM jobs j
+		 WHERE j.status = 'in_progress'
+		   AND (j.worker_id = w.hostname OR (w.target != '' AND j.worker_id = w.target)))`
+
+// GetWorker retrieves a worker by hostname, or nil if it never sent a heartbeat
+func (r *SQLiteRepository) GetWorker(ctx context.Context, hostname string) (*model.Worker, error) {
+	query := `SELECT ` + workerColumns + ` FROM workers w WHERE w.hostname = ?`
+
+	worker, err := scanWorker(r.conn().QueryRowContext(ctx, query, hostname))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worker: %w", err)
+	}
+	return worker, nil
+}
+
+// ListWorkers lists all known workers by hostname
+func (r *SQLiteRepository) ListWorkers(ctx context.Context) ([]model.Worker, error) {
+	query := `SELECT ` + workerColumns + ` FROM workers w ORDER BY w.hostname`
+
+	rows, err := r.conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workers: %w", err)
+	}
+	defer rows.Close()
+
+	var workers []model.Worker
+	for rows.Next() {
+		worker, err := scanWorker(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan worker: %w", err)
+		}
+		workers = append(workers, *worker)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workers: %w", err)
+	}
+
+	return workers, nil
+}
+
+// scanWorker scans a single worker row selected with workerColumns
+func scanWorker(row rowScanner) (*model.Worker, error) {
+	var worker model.Worker
+	var caps, lastSeen string
+
+	if err := row.Scan(&worker.Hostname, &worker.Target, &caps, &lastSeen, &worker.RunningJobs); err != nil {
+		return nil, err
+	}
+
+	if caps != "" {
+		for _, c := range strings.Split(caps, ",") {
+			worker.Capabilities = append(worker.Capabilities, model.Capability(c))
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, lastSeen); err == nil {
+		worker.LastSeen = t
+	}
+
+	return &worker, nil
+}
+
+// SeedJob copies a job with its media item, season and options into an empty
+// database, keeping their IDs. The agent uses it to give a stage binary a
+// private database holding just the job it runs.
+func (r *SQLiteRepository) SeedJob(ctx context.Context, item *model.MediaItem, season *model.Season, job *model.Job, options map[string]interface{}) error {
+	itemCopy := *item
+	if err := r.CreateMediaItem(ctx, &itemCopy); err != nil {
+		return err
+	}
+	if _, err := r.exec(ctx, `UPDATE media_items SET id = ? WHERE id = ?`, item.ID, itemCopy.ID); err != nil {
+		return fmt.Errorf("failed to set media item id: %w", err)
+	}
+
+	if season != nil {
+		seasonCopy := *season
+		seasonCopy.ItemID = item.ID
+		if err := r.CreateSeason(ctx, &seasonCopy); err != nil {
+			return err
+		}
+		if _, err := r.exec(ctx, `UPDATE seasons SET id = ? WHERE id = ?`, season.ID, seasonCopy.ID); err != nil {
+			return fmt.Errorf("failed to set season id: %w", err)
+		}
+	}
+
+	jobCopy := *job
+	if err := r.CreateJob(ctx, &jobCopy); err != nil {
+		return err
+	}
+	if _, err := r.exec(ctx, `UPDATE jobs SET id = ? WHERE id = ?`, job.ID, jobCopy.ID); err != nil {
+		return fmt.Errorf("failed to set job id: %w", err)
+	}
+
+	if len(options) > 0 {
+		return r.SetJobOptions(ctx, job.ID, options)
+	}
+	return nil
+}
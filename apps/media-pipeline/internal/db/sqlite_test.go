@@ -129,6 +129,25 @@ func TestSQLiteRepository_GetMediaItem(t *testing.T) {
 		if item.Season == nil || *item.Season != *created.Season {
 			t.Errorf("Season = %v, want %v", item.Season, created.Season)
 		}
+		if item.ItemStatus != model.ItemStatusNotStarted {
+			t.Errorf("ItemStatus = %q, want %q", item.ItemStatus, model.ItemStatusNotStarted)
+		}
+	})
+
+	t.Run("get item stage", func(t *testing.T) {
+		if err := repo.UpdateMediaItemStage(ctx, created.ID, model.StageRip, model.StatusCompleted); err != nil {
+			t.Fatalf("UpdateMediaItemStage() error = %v", err)
+		}
+		if err := repo.UpdateMediaItemStage(ctx, created.ID, model.StageOrganize, model.StatusInProgress); err != nil {
+			t.Fatalf("UpdateMediaItemStage() error = %v", err)
+		}
+		item, err := repo.GetMediaItem(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetMediaItem() error = %v", err)
+		}
+		if item.CurrentStage != model.StageOrganize || item.StageStatus != model.StatusInProgress {
+			t.Errorf("stage = %s/%s, want organize/in_progress", item.CurrentStage, item.StageStatus)
+		}
 	})
 
 	t.Run("get non-existent item", func(t *testing.T) {
@@ -460,6 +479,87 @@ func TestSQLiteRepository_CreateJob(t *testing.T) {
 	})
 }
 
+func TestSQLiteRepository_EnqueueJob(t *testing.T) {
+	db, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	item := &model.MediaItem{
+		Type:         model.MediaTypeMovie,
+		Name:         "Test Movie",
+		SafeName:     "Test_Movie",
+		CurrentStage: model.StageOrganize,
+		StageStatus:  model.StatusCompleted,
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+
+	t.Run("creates job and starts owner stage", func(t *testing.T) {
+		job := &model.Job{MediaItemID: item.ID, Stage: model.StageRemux, Status: model.JobStatusPending}
+		if err := repo.EnqueueJob(ctx, job, false); err != nil {
+			t.Fatalf("EnqueueJob() error = %v", err)
+		}
+		if job.ID == 0 {
+			t.Error("ID not set after enqueue")
+		}
+
+		got, err := repo.GetMediaItem(ctx, item.ID)
+		if err != nil {
+			t.Fatalf("GetMediaItem() error = %v", err)
+		}
+		if got.CurrentStage != model.StageRemux || got.StageStatus != model.StatusInProgress {
+			t.Errorf("item = %s/%s, want remux/in_progress", got.CurrentStage, got.StageStatus)
+		}
+	})
+
+	t.Run("failed insert leaves owner untouched", func(t *testing.T) {
+		if err := repo.UpdateMediaItemStage(ctx, item.ID, model.StageRemux, model.StatusFailed); err != nil {
+			t.Fatalf("UpdateMediaItemStage() error = %v", err)
+		}
+
+		disc := 1
+		active := &model.Job{MediaItemID: item.ID, Stage: model.StageRip, Status: model.JobStatusInProgress, Disc: &disc}
+		if err := repo.CreateJob(ctx, active); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+
+		job := &model.Job{MediaItemID: item.ID, Stage: model.StageRip, Status: model.JobStatusPending, Disc: &disc}
+		if err := repo.EnqueueJob(ctx, job, false); err == nil {
+			t.Fatal("expected error for duplicate job, got nil")
+		}
+
+		got, err := repo.GetMediaItem(ctx, item.ID)
+		if err != nil {
+			t.Fatalf("GetMediaItem() error = %v", err)
+		}
+		if got.StageStatus != model.StatusFailed {
+			t.Errorf("item stage status = %s, want failed", got.StageStatus)
+		}
+	})
+
+	t.Run("from pending only skips started owner", func(t *testing.T) {
+		disc := 2
+		job := &model.Job{MediaItemID: item.ID, Stage: model.StageRip, Status: model.JobStatusPending, Disc: &disc}
+		if err := repo.EnqueueJob(ctx, job, true); err != nil {
+			t.Fatalf("EnqueueJob() error = %v", err)
+		}
+
+		got, err := repo.GetMediaItem(ctx, item.ID)
+		if err != nil {
+			t.Fatalf("GetMediaItem() error = %v", err)
+		}
+		if got.CurrentStage != model.StageRemux || got.StageStatus != model.StatusFailed {
+			t.Errorf("item = %s/%s, want remux/failed", got.CurrentStage, got.StageStatus)
+		}
+	})
+}
+
 func TestSQLiteRepository_GetJob(t *testing.T) {
 	db, err := OpenInMemory()
 	if err != nil {
@@ -693,6 +793,35 @@ func TestSQLiteRepository_UpdateJobStatus(t *testing.T) {
 			t.Errorf("ErrorMessage = %q, want %q", updated.ErrorMessage, "test error")
 		}
 	})
+
+	t.Run("update to cancelled", func(t *testing.T) {
+		cancelledJob := &model.Job{
+			MediaItemID: item.ID,
+			Stage:       model.StageTranscode,
+			Status:      model.JobStatusInProgress,
+		}
+		if err := repo.CreateJob(ctx, cancelledJob); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+
+		err := repo.UpdateJobStatus(ctx, cancelledJob.ID, model.JobStatusCancelled, "job cancelled")
+		if err != nil {
+			t.Fatalf("UpdateJobStatus() error = %v", err)
+		}
+
+		updated, err := repo.GetJob(ctx, cancelledJob.ID)
+		if err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+
+		if updated.Status != model.JobStatusCancelled {
+			t.Errorf("Status = %v, want %v", updated.Status, model.JobStatusCancelled)
+		}
+
+		if updated.CompletedAt == nil {
+			t.Error("CompletedAt should be set")
+		}
+	})
 }
 
 func TestSQLiteRepository_ListJobsForMedia(t *testing.T) {
@@ -1121,6 +1250,77 @@ func TestSQLiteRepository_GetSeason(t *testing.T) {
 	})
 }
 
+func TestSQLiteRepository_SetAutopilot(t *testing.T) {
+	db, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	show := &model.MediaItem{
+		Type:     model.MediaTypeTV,
+		Name:     "Test Show",
+		SafeName: "Test_Show",
+	}
+	if err := repo.CreateMediaItem(ctx, show); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	season := &model.Season{ItemID: show.ID, Number: 1, StageStatus: model.StatusPending}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason() error = %v", err)
+	}
+
+	t.Run("defaults to off", func(t *testing.T) {
+		item, _ := repo.GetMediaItem(ctx, show.ID)
+		if item.Autopilot {
+			t.Error("item Autopilot = true, want false")
+		}
+		got, _ := repo.GetSeason(ctx, season.ID)
+		if got.Autopilot {
+			t.Error("season Autopilot = true, want false")
+		}
+	})
+
+	t.Run("item", func(t *testing.T) {
+		if err := repo.SetMediaItemAutopilot(ctx, show.ID, true); err != nil {
+			t.Fatalf("SetMediaItemAutopilot() error = %v", err)
+		}
+		item, _ := repo.GetMediaItem(ctx, show.ID)
+		if !item.Autopilot {
+			t.Error("GetMediaItem() Autopilot = false, want true")
+		}
+		items, _ := repo.ListActiveItems(ctx)
+		if len(items) != 1 || !items[0].Autopilot {
+			t.Error("ListActiveItems() Autopilot = false, want true")
+		}
+		bySafeName, _ := repo.GetMediaItemBySafeName(ctx, "Test_Show", nil)
+		if bySafeName == nil || !bySafeName.Autopilot {
+			t.Error("GetMediaItemBySafeName() Autopilot = false, want true")
+		}
+		listed, _ := repo.ListMediaItems(ctx, ListOptions{})
+		if len(listed) != 1 || !listed[0].Autopilot {
+			t.Error("ListMediaItems() Autopilot = false, want true")
+		}
+	})
+
+	t.Run("season", func(t *testing.T) {
+		if err := repo.SetSeasonAutopilot(ctx, season.ID, true); err != nil {
+			t.Fatalf("SetSeasonAutopilot() error = %v", err)
+		}
+		got, _ := repo.GetSeason(ctx, season.ID)
+		if !got.Autopilot {
+			t.Error("GetSeason() Autopilot = false, want true")
+		}
+		seasons, _ := repo.ListSeasonsForItem(ctx, show.ID)
+		if len(seasons) != 1 || !seasons[0].Autopilot {
+			t.Error("ListSeasonsForItem() Autopilot = false, want true")
+		}
+	})
+}
+
 func TestSQLiteRepository_ListSeasonsForItem(t *testing.T) {
 	db, err := OpenInMemory()
 	if err != nil {
@@ -1353,6 +1553,15 @@ func TestSQLiteRepository_TranscodeFiles(t *testing.T) {
 	if got.OutputSize != 500*1024*1024 {
 		t.Errorf("OutputSize = %d, want %d", got.OutputSize, 500*1024*1024)
 	}
+
+	// Test TranscodeCompressionRatio: only the completed file counts
+	ratio, err := repo.TranscodeCompressionRatio(ctx)
+	if err != nil {
+		t.Fatalf("TranscodeCompressionRatio failed: %v", err)
+	}
+	if want := 500.0 / 1024; ratio != want {
+		t.Errorf("TranscodeCompressionRatio = %v, want %v", ratio, want)
+	}
 }
 
 func TestSQLiteRepository_JobOptions(t *testing.T) {
@@ -1411,6 +1620,150 @@ func TestSQLiteRepository_JobOptions(t *testing.T) {
 	}
 }
 
+func TestSQLiteRepository_JobAttempts(t *testing.T) {
+	db, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	item := &model.MediaItem{
+		Type:     model.MediaTypeMovie,
+		Name:     "Test Movie",
+		SafeName: "Test_Movie",
+	}
+	repo.CreateMediaItem(ctx, item)
+
+	first := &model.Job{
+		MediaItemID: item.ID,
+		Stage:       model.StageRemux,
+		Status:      model.JobStatusInProgress,
+	}
+	if err := repo.CreateJob(ctx, first); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if first.Attempt != 1 || first.RunID != first.ID {
+		t.Errorf("first attempt = %d, run = %d, want 1, %d", first.Attempt, first.RunID, first.ID)
+	}
+
+	if failed, err := repo.FailJob(ctx, first.ID, model.FailureKindCrash, "segfault"); err != nil || !failed {
+		t.Fatalf("FailJob() = %v, %v", failed, err)
+	}
+	// A finished job is not failed twice
+	if failed, err := repo.FailJob(ctx, first.ID, model.FailureKindVanished, "gone"); err != nil || failed {
+		t.Errorf("FailJob() on a failed job = %v, %v; want false", failed, err)
+	}
+	got, _ := repo.GetJob(ctx, first.ID)
+	if got.Status != model.JobStatusFailed || got.FailureKind != model.FailureKindCrash || got.ErrorMessage != "segfault" {
+		t.Errorf("failed job = %s %q %q, want failed crash segfault", got.Status, got.FailureKind, got.ErrorMessage)
+	}
+	if got.CompletedAt == nil {
+		t.Error("CompletedAt should be set on failure")
+	}
+
+	notBefore := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	retry := &model.Job{
+		MediaItemID: item.ID,
+		Stage:       model.StageRemux,
+		Status:      model.JobStatusPending,
+		Attempt:     2,
+		RunID:       first.ID,
+		NotBefore:   &notBefore,
+	}
+	if err := repo.CreateJob(ctx, retry); err != nil {
+		t.Fatalf("CreateJob() retry error = %v", err)
+	}
+
+	got, _ = repo.GetJob(ctx, retry.ID)
+	if got.Attempt != 2 || got.RunID != first.ID {
+		t.Errorf("retry attempt = %d, run = %d, want 2, %d", got.Attempt, got.RunID, first.ID)
+	}
+	if got.NotBefore == nil || !got.NotBefore.Equal(notBefore) {
+		t.Errorf("NotBefore = %v, want %v", got.NotBefore, notBefore)
+	}
+}
+
+func TestSQLiteRepository_Workers(t *testing.T) {
+	db, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	got, err := repo.GetWorker(ctx, "ripper")
+	if err != nil || got != nil {
+		t.Fatalf("GetWorker() for unknown host = %v, %v, want nil", got, err)
+	}
+
+	seen := time.Now().UTC().Truncate(time.Second)
+	worker := &model.Worker{
+		Hostname:     "ripper",
+		Target:       "media@ripper",
+		Capabilities: []model.Capability{model.CapabilityOpticalDrive, model.CapabilityFilebot},
+		LastSeen:     seen,
+	}
+	if err := repo.UpsertWorker(ctx, worker); err != nil {
+		t.Fatalf("UpsertWorker() error = %v", err)
+	}
+	repo.UpsertWorker(ctx, &model.Worker{Hostname: "analyzer", LastSeen: seen})
+
+	got, err = repo.GetWorker(ctx, "ripper")
+	if err != nil {
+		t.Fatalf("GetWorker() error = %v", err)
+	}
+	if !got.Has(model.CapabilityOpticalDrive, model.CapabilityFilebot) || len(got.Capabilities) != 2 {
+		t.Errorf("Capabilities = %v, want optical_drive and filebot", got.Capabilities)
+	}
+	if !got.LastSeen.Equal(seen) {
+		t.Errorf("LastSeen = %v, want %v", got.LastSeen, seen)
+	}
+
+	// A heartbeat without a target (e.g. from a stage binary) keeps the target
+	later := seen.Add(time.Minute)
+	repo.UpsertWorker(ctx, &model.Worker{Hostname: "ripper", Capabilities: worker.Capabilities, LastSeen: later})
+	got, _ = repo.GetWorker(ctx, "ripper")
+	if got.Target != "media@ripper" || !got.LastSeen.Equal(later) {
+		t.Errorf("after targetless heartbeat: target = %q, last seen %v", got.Target, got.LastSeen)
+	}
+
+	// Running jobs are counted by hostname or target
+	item := &model.MediaItem{Type: model.MediaTypeMovie, Name: "Test Movie", SafeName: "Test_Movie"}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	for i, workerID := range []string{"ripper", "media@ripper", "analyzer", ""} {
+		disc := i + 1
+		job := &model.Job{MediaItemID: item.ID, Stage: model.StageRip, Status: model.JobStatusInProgress, Disc: &disc, WorkerID: workerID}
+		if err := repo.CreateJob(ctx, job); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+	}
+	done := &model.Job{MediaItemID: item.ID, Stage: model.StageRemux, Status: model.JobStatusCompleted, WorkerID: "ripper"}
+	if err := repo.CreateJob(ctx, done); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if got, _ = repo.GetWorker(ctx, "ripper"); got.RunningJobs != 2 {
+		t.Errorf("ripper RunningJobs = %d, want 2", got.RunningJobs)
+	}
+
+	workers, err := repo.ListWorkers(ctx)
+	if err != nil {
+		t.Fatalf("ListWorkers() error = %v", err)
+	}
+	if len(workers) != 2 || workers[0].Hostname != "analyzer" || workers[1].Hostname != "ripper" {
+		t.Fatalf("ListWorkers() = %v, want analyzer and ripper", workers)
+	}
+	if workers[0].RunningJobs != 1 {
+		t.Errorf("analyzer RunningJobs = %d, want 1", workers[0].RunningJobs)
+	}
+}
+
 func TestSQLiteRepository_MediaItemDatabaseIDs(t *testing.T) {
 	db, err := OpenInMemory()
 	if err != nil {
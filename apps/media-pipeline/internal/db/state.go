@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// ActiveItems are the active media items with what is shown about them
+type ActiveItems struct {
+	Items      []model.MediaItem                // Seasons filled in for TV shows
+	Jobs       map[int64][]model.Job            // Item ID -> jobs, oldest first
+	Transcodes map[int64]model.TranscodeSummary // Job ID -> files of in-progress transcode jobs
+}
+
+// LoadActiveItems loads the active media items with their seasons, jobs and
+// in-progress transcodes in four queries, however many items there are
+func (r *SQLiteRepository) LoadActiveItems(ctx context.Context) (*ActiveItems, error) {
+	items, err := r.ListActiveItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := &ActiveItems{
+		Items:      items,
+		Jobs:       make(map[int64][]model.Job),
+		Transcodes: make(map[int64]model.TranscodeSummary),
+	}
+	if len(items) == 0 {
+		return active, nil
+	}
+
+	ids := make([]int64, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+
+	seasons, err := r.ListSeasonsForItems(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range active.Items {
+		active.Items[i].Seasons = seasons[active.Items[i].ID]
+	}
+
+	page, err := r.ListJobs(ctx, JobFilter{MediaItemIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	var transcoding []int64
+	for _, job := range page.Jobs {
+		active.Jobs[job.MediaItemID] = append(active.Jobs[job.MediaItemID], job)
+		if job.Stage == model.StageTranscode && job.Status == model.JobStatusInProgress {
+			transcoding = append(transcoding, job.ID)
+		}
+	}
+
+	if active.Transcodes, err = r.ListTranscodeSummaries(ctx, transcoding); err != nil {
+		return nil, err
+	}
+	return active, nil
+}
+
+// ListSeasonsForItems returns the seasons of several items in one query,
+// keyed by item ID
+func (r *SQLiteRepository) ListSeasonsForItems(ctx context.Context, itemIDs []int64) (map[int64][]model.Season, error) {
+	seasons := make(map[int64][]model.Season)
+	if len(itemIDs) == 0 {
+		return seasons, nil
+	}
+
+	query := `
+		SELECT id, item_id, number, current_stage, stage_status, autopilot, disc_count, created_at, updated_at, version
+		FROM seasons
+		WHERE item_id IN (` + placeholders(len(itemIDs)) + `)
+		ORDER BY item_id, number ASC
+	`
+	rows, err := r.conn().QueryContext(ctx, query, int64Args(itemIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list seasons: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var season model.Season
+		var stageStr, statusStr string
+		var createdAt, updatedAt string
+
+		err := rows.Scan(
+			&season.ID,
+			&season.ItemID,
+			&season.Number,
+			&stageStr,
+			&statusStr,
+			&season.Autopilot,
+			&season.DiscCount,
+			&createdAt,
+			&updatedAt,
+			&season.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan season: %w", err)
+		}
+
+		season.CurrentStage = parseStage(stageStr)
+		season.StageStatus = model.Status(statusStr)
+		season.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		season.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+		seasons[season.ItemID] = append(seasons[season.ItemID], season)
+	}
+
+	return seasons, rows.Err()
+}
+
+// ListTranscodeSummaries returns how far through their files transcode jobs
+// are in one query, keyed by job ID. Jobs with no files are left out.
+func (r *SQLiteRepository) ListTranscodeSummaries(ctx context.Context, jobIDs []int64) (map[int64]model.TranscodeSummary, error) {
+	summaries := make(map[int64]model.TranscodeSummary)
+	if len(jobIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := `
+		SELECT job_id, relative_path, status, progress
+		FROM transcode_files
+		WHERE job_id IN (` + placeholders(len(jobIDs)) + `)
+		ORDER BY job_id, relative_path
+	`
+	rows, err := r.conn().QueryContext(ctx, query, int64Args(jobIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transcode files: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var jobID int64
+		var path string
+		var status model.TranscodeFileStatus
+		var progress int
+		if err := rows.Scan(&jobID, &path, &status, &progress); err != nil {
+			return nil, fmt.Errorf("failed to scan transcode file: %w", err)
+		}
+
+		summary := summaries[jobID]
+		summary.JobID = jobID
+		summary.Files++
+		switch status {
+		case model.TranscodeFileStatusCompleted:
+			summary.Completed++
+		case model.TranscodeFileStatusInProgress:
+			summary.CurrentFile, summary.CurrentProgress = path, progress
+		}
+		summaries[jobID] = summary
+	}
+
+	return summaries, rows.Err()
+}
+
+// ListRecentLogEvents returns the last limit log events of several jobs in
+// one query, newest first and keyed by job ID. A limit of 0 returns all.
+func (r *SQLiteRepository) ListRecentLogEvents(ctx context.Context, jobIDs []int64, limit int) (map[int64][]model.LogEvent, error) {
+	events := make(map[int64][]model.LogEvent)
+	if len(jobIDs) == 0 {
+		return events, nil
+	}
+
+	query := `
+		SELECT id, job_id, level, message, timestamp
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY job_id ORDER BY timestamp DESC, id DESC) AS n
+			FROM log_events
+			WHERE job_id IN (` + placeholders(len(jobIDs)) + `)
+		)
+	`
+	args := int64Args(jobIDs)
+	if limit > 0 {
+		query += " WHERE n <= ?"
+		args = append(args, limit)
+	}
+	query += " ORDER BY job_id, n"
+	rows, err := r.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event model.LogEvent
+		var timestampStr string
+		if err := rows.Scan(&event.ID, &event.JobID, &event.Level, &event.Message, &timestampStr); err != nil {
+			return nil, fmt.Errorf("failed to scan log event: %w", err)
+		}
+		event.Timestamp, _ = time.Parse(time.RFC3339, timestampStr)
+		events[event.JobID] = append(events[event.JobID], event)
+	}
+
+	return events, rows.Err()
+}
+
+// placeholders returns n comma-separated bind parameters
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// int64Args converts IDs to query arguments
+func int64Args(ids []int64) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
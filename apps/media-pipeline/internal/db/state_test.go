@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestSQLiteRepository_LoadActiveItems(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	t.Run("empty database", func(t *testing.T) {
+		active, err := repo.LoadActiveItems(ctx)
+		if err != nil {
+			t.Fatalf("LoadActiveItems() error = %v", err)
+		}
+		if len(active.Items) != 0 || len(active.Jobs) != 0 || len(active.Transcodes) != 0 {
+			t.Errorf("LoadActiveItems() = %+v, want nothing", active)
+		}
+	})
+
+	seedActiveItems(t, repo, 4)
+
+	active, err := repo.LoadActiveItems(ctx)
+	if err != nil {
+		t.Fatalf("LoadActiveItems() error = %v", err)
+	}
+	if len(active.Items) != 4 {
+		t.Fatalf("LoadActiveItems() = %d items, want 4", len(active.Items))
+	}
+
+	for _, item := range active.Items {
+		jobs := active.Jobs[item.ID]
+		if item.Type == model.MediaTypeTV {
+			if len(item.Seasons) != 2 || item.Seasons[0].Number != 1 || item.Seasons[1].Number != 2 {
+				t.Errorf("%s seasons = %+v, want 1 and 2", item.Name, item.Seasons)
+			}
+			if len(jobs) != 2 {
+				t.Errorf("%s has %d jobs, want 2", item.Name, len(jobs))
+			}
+			continue
+		}
+
+		if len(item.Seasons) != 0 || len(jobs) != 3 {
+			t.Fatalf("%s has %d seasons and %d jobs, want 0 and 3", item.Name, len(item.Seasons), len(jobs))
+		}
+		transcode := jobs[2]
+		summary, ok := active.Transcodes[transcode.ID]
+		if !ok {
+			t.Fatalf("Transcodes[%d] missing for the in-progress transcode of %s", transcode.ID, item.Name)
+		}
+		want := model.TranscodeSummary{JobID: transcode.ID, Files: 3, Completed: 1, CurrentFile: "extras/b.mkv", CurrentProgress: 40}
+		if summary != want {
+			t.Errorf("Transcodes[%d] = %+v, want %+v", transcode.ID, summary, want)
+		}
+	}
+
+	t.Run("recent log events", func(t *testing.T) {
+		var ids []int64 // The rip and remux of a movie
+		for _, item := range active.Items {
+			if item.Type == model.MediaTypeMovie {
+				ids = []int64{active.Jobs[item.ID][0].ID, active.Jobs[item.ID][1].ID}
+			}
+		}
+
+		events, err := repo.ListRecentLogEvents(ctx, ids, 2)
+		if err != nil {
+			t.Fatalf("ListRecentLogEvents() error = %v", err)
+		}
+		for _, id := range ids {
+			got := events[id]
+			if len(got) != 2 || got[0].Message != "line 3" || got[1].Message != "line 2" {
+				t.Errorf("events of job %d = %+v, want lines 3 and 2", id, got)
+			}
+		}
+
+		all, err := repo.ListRecentLogEvents(ctx, ids[:1], 0)
+		if err != nil {
+			t.Fatalf("ListRecentLogEvents() error = %v", err)
+		}
+		if len(all[ids[0]]) != 3 {
+			t.Errorf("events without a limit = %d, want 3", len(all[ids[0]]))
+		}
+	})
+}
+
+// BenchmarkLoadActiveItems compares loading 1,000 active items in batches
+// with loading them one item at a time
+func BenchmarkLoadActiveItems(b *testing.B) {
+	database, err := OpenInMemory()
+	if err != nil {
+		b.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+	seedActiveItems(b, repo, 1000)
+
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.LoadActiveItems(ctx); err != nil {
+				b.Fatalf("LoadActiveItems() error = %v", err)
+			}
+		}
+	})
+
+	b.Run("per item", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			items, err := repo.ListActiveItems(ctx)
+			if err != nil {
+				b.Fatalf("ListActiveItems() error = %v", err)
+			}
+			for _, item := range items {
+				if _, err := repo.ListSeasonsForItem(ctx, item.ID); err != nil {
+					b.Fatalf("ListSeasonsForItem() error = %v", err)
+				}
+				jobs, err := repo.ListJobsForMedia(ctx, item.ID)
+				if err != nil {
+					b.Fatalf("ListJobsForMedia() error = %v", err)
+				}
+				for _, job := range jobs {
+					if job.Stage == model.StageTranscode && job.Status == model.JobStatusInProgress {
+						if _, err := repo.ListTranscodeFiles(ctx, job.ID); err != nil {
+							b.Fatalf("ListTranscodeFiles() error = %v", err)
+						}
+					}
+				}
+			}
+		}
+	})
+}
+
+// seedActiveItems creates n active items, alternating TV shows with two
+// seasons and a job each, and movies with a finished rip and remux, three
+// log lines on each, and a transcode running through three files
+func seedActiveItems(tb testing.TB, repo *SQLiteRepository, n int) {
+	tb.Helper()
+	ctx := context.Background()
+
+	err := repo.WithTx(ctx, func(tx Repository) error {
+		for i := 0; i < n; i++ {
+			if i%2 == 0 {
+				if err := seedShow(ctx, tx, i); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := seedMovie(ctx, tx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("seeding %d items: %v", n, err)
+	}
+}
+
+func seedShow(ctx context.Context, repo Repository, i int) error {
+	item := &model.MediaItem{
+		Type:       model.MediaTypeTV,
+		Name:       fmt.Sprintf("Show %d", i),
+		SafeName:   fmt.Sprintf("Show_%d", i),
+		ItemStatus: model.ItemStatusActive,
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		return err
+	}
+	for number := 1; number <= 2; number++ {
+		season := &model.Season{ItemID: item.ID, Number: number, CurrentStage: model.StageRip, StageStatus: model.StatusInProgress}
+		if err := repo.CreateSeason(ctx, season); err != nil {
+			return err
+		}
+		job := &model.Job{MediaItemID: item.ID, SeasonID: &season.ID, Stage: model.StageRip, Status: model.JobStatusInProgress}
+		if err := repo.CreateJob(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func seedMovie(ctx context.Context, repo Repository, i int) error {
+	item := &model.MediaItem{
+		Type:         model.MediaTypeMovie,
+		Name:         fmt.Sprintf("Movie %d", i),
+		SafeName:     fmt.Sprintf("Movie_%d", i),
+		ItemStatus:   model.ItemStatusActive,
+		CurrentStage: model.StageTranscode,
+		StageStatus:  model.StatusInProgress,
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		return err
+	}
+
+	for _, stage := range []model.Stage{model.StageRip, model.StageRemux} {
+		job := &model.Job{MediaItemID: item.ID, Stage: stage, Status: model.JobStatusCompleted}
+		if err := repo.CreateJob(ctx, job); err != nil {
+			return err
+		}
+		for line := 1; line <= 3; line++ {
+			event := &model.LogEvent{JobID: job.ID, Level: "info", Message: fmt.Sprintf("line %d", line)}
+			if err := repo.CreateLogEvent(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+
+	job := &model.Job{MediaItemID: item.ID, Stage: model.StageTranscode, Status: model.JobStatusInProgress}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		return err
+	}
+	files := []model.TranscodeFile{
+		{RelativePath: "main.mkv", Status: model.TranscodeFileStatusCompleted, Progress: 100},
+		{RelativePath: "extras/b.mkv", Status: model.TranscodeFileStatusInProgress, Progress: 40},
+		{RelativePath: "extras/c.mkv", Status: model.TranscodeFileStatusPending},
+	}
+	for _, file := range files {
+		file.JobID = job.ID
+		if err := repo.CreateTranscodeFile(ctx, &file); err != nil {
+			return err
+		}
+		if err := repo.UpdateTranscodeFileProgress(ctx, file.ID, file.Progress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+const (
+	stressWriters = 4
+	stressUpdates = 100
+)
+
+// TestConcurrentWriters runs several processes writing progress and log
+// events to one database file at once, as the stage binaries do while the
+// TUI reads it, and checks that no write was lost
+func TestConcurrentWriters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns writer processes")
+	}
+	path := filepath.Join(t.TempDir(), "pipeline.db")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	item := &model.MediaItem{Type: model.MediaTypeMovie, Name: "Stress", SafeName: "Stress"}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	job := &model.Job{MediaItemID: item.ID, Stage: model.StageTranscode, Status: model.JobStatusInProgress}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	var fileIDs []int64
+	for i := 0; i < stressWriters; i++ {
+		file := &model.TranscodeFile{JobID: job.ID, RelativePath: fmt.Sprintf("file%d.mkv", i), Status: model.TranscodeFileStatusInProgress}
+		if err := repo.CreateTranscodeFile(ctx, file); err != nil {
+			t.Fatalf("CreateTranscodeFile() error = %v", err)
+		}
+		fileIDs = append(fileIDs, file.ID)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, stressWriters)
+	for _, id := range fileIDs {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestWriterProcess$")
+		cmd.Env = append(os.Environ(),
+			"PIPELINE_STRESS_DB="+path,
+			"PIPELINE_STRESS_JOB="+strconv.FormatInt(job.ID, 10),
+			"PIPELINE_STRESS_FILE="+strconv.FormatInt(id, 10))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("writer failed: %v\n%s", err, out)
+			}
+		}()
+	}
+
+	// Read as the TUI does while the writers run
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+			if _, err := repo.ListTranscodeFiles(ctx, job.ID); err != nil {
+				t.Errorf("ListTranscodeFiles() during writes error = %v", err)
+			}
+		}
+	}
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	files, err := repo.ListTranscodeFiles(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("ListTranscodeFiles() error = %v", err)
+	}
+	for _, f := range files {
+		if f.Progress != stressUpdates {
+			t.Errorf("%s progress = %d, want %d", f.RelativePath, f.Progress, stressUpdates)
+		}
+	}
+	events, err := repo.ListLogEvents(ctx, job.ID, stressWriters*stressUpdates+1)
+	if err != nil {
+		t.Fatalf("ListLogEvents() error = %v", err)
+	}
+	if len(events) != stressWriters*stressUpdates {
+		t.Errorf("log events = %d, want %d", len(events), stressWriters*stressUpdates)
+	}
+}
+
+// TestWriterProcess is a writer process of TestConcurrentWriters
+func TestWriterProcess(t *testing.T) {
+	path := os.Getenv("PIPELINE_STRESS_DB")
+	if path == "" {
+		t.Skip("run by TestConcurrentWriters")
+	}
+	jobID, _ := strconv.ParseInt(os.Getenv("PIPELINE_STRESS_JOB"), 10, 64)
+	fileID, _ := strconv.ParseInt(os.Getenv("PIPELINE_STRESS_FILE"), 10, 64)
+
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	for i := 1; i <= stressUpdates; i++ {
+		if err := repo.UpdateTranscodeFileProgress(ctx, fileID, i); err != nil {
+			t.Fatalf("UpdateTranscodeFileProgress() error = %v", err)
+		}
+		if err := repo.UpdateJobProgress(ctx, jobID, i); err != nil {
+			t.Fatalf("UpdateJobProgress() error = %v", err)
+		}
+		event := &model.LogEvent{JobID: jobID, Level: "info", Message: fmt.Sprintf("file %d at %d%%", fileID, i)}
+		if err := repo.CreateLogEvent(ctx, event); err != nil {
+			t.Fatalf("CreateLogEvent() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// AddToolLog links a tool's log file to a job. Linking a file again, e.g.
+// when a retried step reopens it, keeps the first link.
+func (r *SQLiteRepository) AddToolLog(ctx context.Context, toolLog *model.ToolLog) error {
+	query := `
+		INSERT INTO job_tool_logs (job_id, tool, path, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(job_id, path) DO NOTHING
+	`
+	now := time.Now().UTC()
+	if _, err := r.exec(ctx, query, toolLog.JobID, toolLog.Tool, toolLog.Path, now.Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to add tool log: %w", err)
+	}
+
+	err := r.conn().QueryRowContext(ctx, `SELECT id FROM job_tool_logs WHERE job_id = ? AND path = ?`,
+		toolLog.JobID, toolLog.Path).Scan(&toolLog.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read tool log id: %w", err)
+	}
+	toolLog.CreatedAt = now.Truncate(time.Second)
+	return nil
+}
+
+// ListToolLogs returns the tool logs of a job in the order they were opened
+func (r *SQLiteRepository) ListToolLogs(ctx context.Context, jobID int64) ([]model.ToolLog, error) {
+	rows, err := r.conn().QueryContext(ctx,
+		`SELECT id, job_id, tool, path, created_at FROM job_tool_logs WHERE job_id = ? ORDER BY id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tool logs: %w", err)
+	}
+	defer rows.Close()
+
+	var toolLogs []model.ToolLog
+	for rows.Next() {
+		var toolLog model.ToolLog
+		var createdAt string
+		if err := rows.Scan(&toolLog.ID, &toolLog.JobID, &toolLog.Tool, &toolLog.Path, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tool log: %w", err)
+		}
+		toolLog.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		toolLogs = append(toolLogs, toolLog)
+	}
+	return toolLogs, rows.Err()
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// WithActor returns a repository that records actor as the source of the
+// transitions it makes: tui, cli, or the host of a worker or stage binary
+func (r *SQLiteRepository) WithActor(actor string) *SQLiteRepository {
+	return &SQLiteRepository{db: r.db, actor: actor, tx: r.tx}
+}
+
+// moveOwner moves a media item ("media_items") or season ("seasons") to a
+// new stage and status if the state machine allows it, and records the
+// move. jobID is the job behind the move, if the caller knows it. With
+// fromPendingOnly an owner whose stage status is not pending is left alone.
+func (r *SQLiteRepository) moveOwner(ctx context.Context, tx *sql.Tx, table string, id int64, to model.StageState, jobID *int64, fromPendingOnly bool) error {
+	t := model.Transition{Kind: model.TransitionItem, MediaItemID: id, JobID: jobID}
+	query := `SELECT id, current_stage, stage_status FROM media_items WHERE id = ?`
+	if table == "seasons" {
+		t.Kind = model.TransitionSeason
+		t.SeasonID = &id
+		query = `SELECT item_id, current_stage, stage_status FROM seasons WHERE id = ?`
+	}
+
+	var stage, status string
+	err := tx.QueryRowContext(ctx, query, id).Scan(&t.MediaItemID, &stage, &status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%s %d not found", table, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s stage: %w", table, err)
+	}
+
+	from := model.StageState{Stage: parseStage(stage), Status: model.Status(status)}
+	if from == to || (fromPendingOnly && from.Status != model.StatusPending) {
+		return nil
+	}
+	if err := model.CheckStageTransition(from, to); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	update := `UPDATE ` + table + ` SET current_stage = ?, stage_status = ?, updated_at = ?, version = version + 1 WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, update, to.Stage.String(), to.Status, now, id); err != nil {
+		return fmt.Errorf("failed to update %s stage: %w", table, err)
+	}
+
+	if t.JobID == nil {
+		if t.JobID, err = latestJob(ctx, tx, t.MediaItemID, t.SeasonID, to.Stage); err != nil {
+			return err
+		}
+	}
+	t.FromStage, t.FromStatus = from.Stage, string(from.Status)
+	t.ToStage, t.ToStatus = to.Stage, string(to.Status)
+	return r.recordTransition(ctx, tx, &t)
+}
+
+// latestJob returns the newest job of an owner's stage, nil if it has none
+func latestJob(ctx context.Context, tx *sql.Tx, itemID int64, seasonID *int64, stage model.Stage) (*int64, error) {
+	query := `SELECT id FROM jobs WHERE media_item_id = ? AND stage = ? AND season_id IS NULL ORDER BY id DESC LIMIT 1`
+	args := []interface{}{itemID, stage.String()}
+	if seasonID != nil {
+		query = `SELECT id FROM jobs WHERE media_item_id = ? AND stage = ? AND season_id = ? ORDER BY id DESC LIMIT 1`
+		args = append(args, *seasonID)
+	}
+
+	var id int64
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job of stage: %w", err)
+	}
+	return &id, nil
+}
+
+// jobState reads what recording a job's status change needs, and the job's
+// status. The transition is nil if there is no such job.
+func jobState(ctx context.Context, tx *sql.Tx, id int64) (*model.Transition, model.JobStatus, error) {
+	t := &model.Transition{Kind: model.TransitionJob, JobID: &id}
+	var stage, status string
+	var seasonID sql.NullInt64
+	err := tx.QueryRowContext(ctx, `SELECT media_item_id, season_id, stage, status FROM jobs WHERE id = ?`, id).
+		Scan(&t.MediaItemID, &seasonID, &stage, &status)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read job status: %w", err)
+	}
+	if seasonID.Valid {
+		t.SeasonID = &seasonID.Int64
+	}
+	t.FromStage, t.ToStage = parseStage(stage), parseStage(stage)
+	t.FromStatus = status
+	return t, model.JobStatus(status), nil
+}
+
+// recordJobTransition records a job's status change from t's status
+func (r *SQLiteRepository) recordJobTransition(ctx context.Context, tx *sql.Tx, t *model.Transition, to model.JobStatus) error {
+	if t.FromStatus == string(to) {
+		return nil
+	}
+	t.ToStatus = string(to)
+	return r.recordTransition(ctx, tx, t)
+}
+
+// recordTransition inserts a row into stage_transitions
+func (r *SQLiteRepository) recordTransition(ctx context.Context, tx *sql.Tx, t *model.Transition) error {
+	query := `
+		INSERT INTO stage_transitions (kind, media_item_id, season_id, job_id, from_stage, from_status, to_stage, to_status, actor)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := tx.ExecContext(ctx, query, t.Kind, t.MediaItemID, t.SeasonID, t.JobID,
+		t.FromStage.String(), t.FromStatus, t.ToStage.String(), t.ToStatus, r.actor)
+	if err != nil {
+		return fmt.Errorf("failed to record transition: %w", err)
+	}
+	return nil
+}
+
+// ListTransitions returns the recorded transitions of a media item, its
+// seasons and its jobs, oldest first
+func (r *SQLiteRepository) ListTransitions(ctx context.Context, mediaItemID int64) ([]model.Transition, error) {
+	query := `
+		SELECT id, kind, media_item_id, season_id, job_id, from_stage, from_status, to_stage, to_status, actor, created_at
+		FROM stage_transitions
+		WHERE media_item_id = ?
+		ORDER BY id
+	`
+	rows, err := r.conn().QueryContext(ctx, query, mediaItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var transitions []model.Transition
+	for rows.Next() {
+		var t model.Transition
+		var seasonID, jobID sql.NullInt64
+		var fromStage, toStage, createdAt string
+		if err := rows.Scan(&t.ID, &t.Kind, &t.MediaItemID, &seasonID, &jobID, &fromStage, &t.FromStatus,
+			&toStage, &t.ToStatus, &t.Actor, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transition: %w", err)
+		}
+		if seasonID.Valid {
+			t.SeasonID = &seasonID.Int64
+		}
+		if jobID.Valid {
+			t.JobID = &jobID.Int64
+		}
+		t.FromStage, t.ToStage = parseStage(fromStage), parseStage(toStage)
+		t.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestSQLiteRepository_Transitions(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := NewSQLiteRepository(database).WithActor("worker-1")
+	ctx := context.Background()
+
+	item := &model.MediaItem{
+		Type:         model.MediaTypeMovie,
+		Name:         "Test Movie",
+		SafeName:     "Test_Movie",
+		CurrentStage: model.StageOrganize,
+		StageStatus:  model.StatusCompleted,
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+
+	t.Run("rejects a skipped stage", func(t *testing.T) {
+		err := repo.UpdateMediaItemStage(ctx, item.ID, model.StageTranscode, model.StatusInProgress)
+		var transitionErr *model.StageTransitionError
+		if !errors.As(err, &transitionErr) {
+			t.Fatalf("UpdateMediaItemStage() error = %v, want *StageTransitionError", err)
+		}
+
+		got, _ := repo.GetMediaItem(ctx, item.ID)
+		if got.CurrentStage != model.StageOrganize || got.StageStatus != model.StatusCompleted {
+			t.Errorf("item = %s/%s, want organize/completed", got.CurrentStage, got.StageStatus)
+		}
+	})
+
+	job := &model.Job{MediaItemID: item.ID, Stage: model.StageRemux, Status: model.JobStatusPending}
+	if err := repo.EnqueueJob(ctx, job, false); err != nil {
+		t.Fatalf("EnqueueJob() error = %v", err)
+	}
+	if claimed, err := repo.ClaimJob(ctx, job.ID, "worker-1", 0, 0); err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+	if err := repo.UpdateJobStatus(ctx, job.ID, model.JobStatusCompleted, ""); err != nil {
+		t.Fatalf("UpdateJobStatus() error = %v", err)
+	}
+	if err := repo.UpdateMediaItemStage(ctx, item.ID, model.StageRemux, model.StatusCompleted); err != nil {
+		t.Fatalf("UpdateMediaItemStage() error = %v", err)
+	}
+
+	t.Run("rejects reopening a finished job", func(t *testing.T) {
+		err := repo.UpdateJobStatus(ctx, job.ID, model.JobStatusInProgress, "")
+		if !errors.Is(err, model.ErrIllegalTransition) {
+			t.Fatalf("UpdateJobStatus() error = %v, want ErrIllegalTransition", err)
+		}
+		if failed, err := repo.FailJob(ctx, job.ID, model.FailureKindCrash, "gone"); err != nil || failed {
+			t.Errorf("FailJob() = %v, %v; want false for a completed job", failed, err)
+		}
+	})
+
+	t.Run("records every change", func(t *testing.T) {
+		transitions, err := repo.ListTransitions(ctx, item.ID)
+		if err != nil {
+			t.Fatalf("ListTransitions() error = %v", err)
+		}
+
+		want := []struct {
+			kind     model.TransitionKind
+			from, to string
+		}{
+			{model.TransitionItem, "organize/completed", "remux/in_progress"},
+			{model.TransitionJob, "remux/pending", "remux/in_progress"},
+			{model.TransitionJob, "remux/in_progress", "remux/completed"},
+			{model.TransitionItem, "remux/in_progress", "remux/completed"},
+		}
+		if len(transitions) != len(want) {
+			t.Fatalf("ListTransitions() = %d transitions, want %d: %+v", len(transitions), len(want), transitions)
+		}
+		for i, tr := range transitions {
+			from := tr.FromStage.String() + "/" + tr.FromStatus
+			to := tr.ToStage.String() + "/" + tr.ToStatus
+			if tr.Kind != want[i].kind || from != want[i].from || to != want[i].to {
+				t.Errorf("transition %d = %s %s -> %s, want %s %s -> %s", i, tr.Kind, from, to, want[i].kind, want[i].from, want[i].to)
+			}
+			if tr.JobID == nil || *tr.JobID != job.ID {
+				t.Errorf("transition %d job = %v, want %d", i, tr.JobID, job.ID)
+			}
+			if tr.Actor != "worker-1" {
+				t.Errorf("transition %d actor = %q, want worker-1", i, tr.Actor)
+			}
+			if tr.CreatedAt.IsZero() {
+				t.Errorf("transition %d has no timestamp", i)
+			}
+		}
+	})
+}
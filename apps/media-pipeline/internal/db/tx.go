@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// WithTx runs fn with a repository whose writes are committed together when
+// fn returns nil, and rolled back otherwise. Calls on the repository fn gets
+// must use the ctx passed to WithTx. A WithTx nested inside fn joins the
+// outer transaction. The whole of fn is run again if the database is busy,
+// so it should only change the database.
+func (r *SQLiteRepository) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return fn(&SQLiteRepository{db: r.db, actor: r.actor, tx: tx})
+	})
+}
+
+// inTx runs fn in a transaction, retrying while the database is busy. Inside
+// WithTx, fn runs in its transaction instead.
+func (r *SQLiteRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return retryBusy(ctx, func() error {
+		tx, err := r.db.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+		return nil
+	})
+}
+
+// conn returns the transaction the repository runs in, or the database
+func (r *SQLiteRepository) conn() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db.db
+}
+
+// exec runs a write statement. Outside a transaction it is retried while
+// the database is busy; inside one, the transaction already holds the lock.
+func (r *SQLiteRepository) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if r.tx != nil {
+		return r.tx.ExecContext(ctx, query, args...)
+	}
+	return r.db.exec(ctx, query, args...)
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestSQLiteRepository_WithTx(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	newItem := func(name string) *model.MediaItem {
+		return &model.MediaItem{Type: model.MediaTypeMovie, Name: name, SafeName: name}
+	}
+	count := func() int {
+		items, err := repo.ListMediaItems(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("ListMediaItems() error = %v", err)
+		}
+		return len(items)
+	}
+
+	t.Run("commits", func(t *testing.T) {
+		err := repo.WithTx(ctx, func(tx Repository) error {
+			item := newItem("Committed")
+			if err := tx.CreateMediaItem(ctx, item); err != nil {
+				return err
+			}
+			// Reads inside the transaction see its writes
+			got, err := tx.GetMediaItem(ctx, item.ID)
+			if err != nil || got == nil {
+				t.Errorf("GetMediaItem() in transaction = %v, %v", got, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithTx() error = %v", err)
+		}
+		if n := count(); n != 1 {
+			t.Errorf("got %d items, want 1", n)
+		}
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		injected := errors.New("injected failure")
+		err := repo.WithTx(ctx, func(tx Repository) error {
+			if err := tx.CreateMediaItem(ctx, newItem("Rolled_Back")); err != nil {
+				return err
+			}
+			return injected
+		})
+		if !errors.Is(err, injected) {
+			t.Fatalf("WithTx() error = %v, want injected failure", err)
+		}
+		if n := count(); n != 1 {
+			t.Errorf("got %d items, want 1", n)
+		}
+	})
+
+	t.Run("nested joins the outer transaction", func(t *testing.T) {
+		injected := errors.New("injected failure")
+		err := repo.WithTx(ctx, func(tx Repository) error {
+			err := tx.WithTx(ctx, func(inner Repository) error {
+				return inner.CreateMediaItem(ctx, newItem("Nested"))
+			})
+			if err != nil {
+				return err
+			}
+			return injected
+		})
+		if !errors.Is(err, injected) {
+			t.Fatalf("WithTx() error = %v, want injected failure", err)
+		}
+		if n := count(); n != 1 {
+			t.Errorf("got %d items, want 1: the nested write should roll back with the outer one", n)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrConflict is matched by every *ConflictError
+var ErrConflict = errors.New("row was changed by someone else")
+
+// ConflictError is returned by a compare-and-swap update of a row that has
+// been changed since it was read. Read the row again and reapply the change.
+type ConflictError struct {
+	Table   string
+	ID      int64
+	Version int64 // The version the caller read
+	Current int64 // The version in the database
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %d was changed by someone else (read version %d, now %d)", e.Table, e.ID, e.Version, e.Current)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// checkVersion returns a *ConflictError unless the row of table is still at
+// version. A missing row is not a conflict.
+func checkVersion(ctx context.Context, tx *sql.Tx, table string, id, version int64) error {
+	current, err := rowVersion(ctx, tx, table, id)
+	if err != nil || current == 0 || current == version {
+		return err
+	}
+	return &ConflictError{Table: table, ID: id, Version: version, Current: current}
+}
+
+// rowVersion returns the version of a row of table, 0 if there is no such row
+func rowVersion(ctx context.Context, tx *sql.Tx, table string, id int64) (int64, error) {
+	var version int64
+	err := tx.QueryRowContext(ctx, `SELECT version FROM `+table+` WHERE id = ?`, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s version: %w", table, err)
+	}
+	return version, nil
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestSQLiteRepository_Versions(t *testing.T) {
+	database, err := OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	item := &model.MediaItem{
+		Type:         model.MediaTypeTV,
+		Name:         "Test Show",
+		SafeName:     "Test_Show",
+		CurrentStage: model.StageOrganize,
+		StageStatus:  model.StatusCompleted,
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	season := &model.Season{ItemID: item.ID, Number: 1, CurrentStage: model.StageOrganize, StageStatus: model.StatusCompleted}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason() error = %v", err)
+	}
+	job := &model.Job{MediaItemID: item.ID, SeasonID: &season.ID, Stage: model.StageRemux, Status: model.JobStatusPending}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	t.Run("stale job update conflicts", func(t *testing.T) {
+		stale, _ := repo.GetJob(ctx, job.ID)
+		if err := repo.SetJobPriority(ctx, job.ID, 5); err != nil {
+			t.Fatalf("SetJobPriority() error = %v", err)
+		}
+
+		stale.LogPath = "/tmp/stale.log"
+		err := repo.UpdateJob(ctx, stale)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+			t.Fatalf("UpdateJob() error = %v, want *ConflictError", err)
+		}
+
+		got, _ := repo.GetJob(ctx, job.ID)
+		if got.Priority != 5 || got.LogPath != "" {
+			t.Errorf("job priority = %d, log path = %q; want the priority kept and no log path", got.Priority, got.LogPath)
+		}
+
+		got.LogPath = "/tmp/fresh.log"
+		version := got.Version
+		if err := repo.UpdateJob(ctx, got); err != nil {
+			t.Fatalf("UpdateJob() of a fresh read error = %v", err)
+		}
+		if got.Version != version+1 {
+			t.Errorf("Version = %d, want %d", got.Version, version+1)
+		}
+	})
+
+	t.Run("start job keeps other fields", func(t *testing.T) {
+		if err := repo.UpdateJobProgress(ctx, job.ID, 40); err != nil {
+			t.Fatalf("UpdateJobProgress() error = %v", err)
+		}
+		if err := repo.StartJob(ctx, job.ID, "worker-1", 123, "", "/staging/out"); err != nil {
+			t.Fatalf("StartJob() error = %v", err)
+		}
+
+		got, _ := repo.GetJob(ctx, job.ID)
+		if got.Status != model.JobStatusInProgress || got.WorkerID != "worker-1" || got.PID != 123 || got.StartedAt == nil {
+			t.Errorf("job = %s on %q pid %d, want in_progress on worker-1 pid 123", got.Status, got.WorkerID, got.PID)
+		}
+		if got.OutputDir != "/staging/out" || got.LogPath != "/tmp/fresh.log" || got.Progress != 40 || got.Priority != 5 {
+			t.Errorf("job = %+v, want output dir set and log path, progress and priority kept", got)
+		}
+
+		if err := repo.StartJob(ctx, 9999, "worker-1", 1, "", ""); err == nil {
+			t.Error("StartJob() of a missing job succeeded, want error")
+		}
+	})
+
+	t.Run("stale season update conflicts", func(t *testing.T) {
+		stale, _ := repo.GetSeason(ctx, season.ID)
+		if err := repo.SetSeasonAutopilot(ctx, season.ID, true); err != nil {
+			t.Fatalf("SetSeasonAutopilot() error = %v", err)
+		}
+
+		stale.CurrentStage, stale.StageStatus = model.StageRemux, model.StatusInProgress
+		if err := repo.UpdateSeason(ctx, stale); !errors.Is(err, ErrConflict) {
+			t.Fatalf("UpdateSeason() error = %v, want ErrConflict", err)
+		}
+
+		fresh, _ := repo.GetSeason(ctx, season.ID)
+		fresh.CurrentStage, fresh.StageStatus = model.StageRemux, model.StatusInProgress
+		if err := repo.UpdateSeason(ctx, fresh); err != nil {
+			t.Fatalf("UpdateSeason() of a fresh read error = %v", err)
+		}
+		got, _ := repo.GetSeason(ctx, season.ID)
+		if !got.Autopilot || got.CurrentStage != model.StageRemux || got.Version != fresh.Version {
+			t.Errorf("season = %+v, want autopilot kept, remux and version %d", got, fresh.Version)
+		}
+	})
+}
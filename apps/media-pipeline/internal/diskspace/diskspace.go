@@ -0,0 +1,158 @@
+// Package diskspace checks that a stage's output fits on the filesystem it
+// writes to before the stage starts, so it fails up front instead of halfway
+// through with ENOSPC.
+package diskspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Headroom is kept free on top of a stage's estimate, for logs, the
+// database and estimates that come out low
+const Headroom int64 = 1 << 30
+
+// InsufficientError reports that a stage's output does not fit
+type InsufficientError struct {
+	Dir  string // Directory the stage writes to
+	Need int64  // Estimated bytes the stage still has to write, plus Headroom
+	Free int64  // Bytes available on the filesystem
+}
+
+func (e *InsufficientError) Error() string {
+	return fmt.Sprintf("not enough disk space for %s: need %s, %s free",
+		e.Dir, FormatBytes(e.Need), FormatBytes(e.Free))
+}
+
+// FailureKind returns the kind of failure to record for a preflight error:
+// space if the output does not fit, so it can be retried once space frees up
+func FailureKind(err error) model.FailureKind {
+	var insufficient *InsufficientError
+	if errors.As(err, &insufficient) {
+		return model.FailureKindSpace
+	}
+	return model.FailureKindStage
+}
+
+// Check returns an *InsufficientError if dir's filesystem does not have room
+// for need more bytes plus Headroom. dir does not have to exist yet.
+func Check(dir string, need int64) error {
+	free, err := Free(dir)
+	if err != nil {
+		return err
+	}
+	if need+Headroom > free {
+		return &InsufficientError{Dir: dir, Need: need + Headroom, Free: free}
+	}
+	return nil
+}
+
+// Preflight checks that outputDir's filesystem has room for the rest of an
+// estimated output, counting what an earlier attempt already wrote there
+func Preflight(outputDir string, estimate int64) error {
+	need, err := Remaining(estimate, outputDir)
+	if err != nil {
+		return err
+	}
+	return Check(outputDir, need)
+}
+
+// Estimate returns the expected size of an output written from inputDir,
+// given the ratio of output to input size
+func Estimate(inputDir string, ratio float64) (int64, error) {
+	size, err := DirSize(inputDir)
+	if err != nil {
+		return 0, err
+	}
+	return int64(float64(size) * ratio), nil
+}
+
+// Free returns the bytes available to unprivileged users on the filesystem
+// dir is on, or would be on once created
+func Free(dir string) (int64, error) {
+	path, err := existingAncestor(dir)
+	if err != nil {
+		return 0, err
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem of %s: %w", path, err)
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+// existingAncestor returns dir or its nearest parent that exists
+func existingAncestor(dir string) (string, error) {
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(path)
+		if !errors.Is(err, fs.ErrNotExist) || parent == path {
+			return "", fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		path = parent
+	}
+}
+
+// DirSize returns the total size of the regular files under dir, 0 if it
+// does not exist
+func DirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure %s: %w", dir, err)
+	}
+	return total, nil
+}
+
+// Remaining returns how much of an estimated output still has to be written,
+// given what an earlier attempt already left in outputDir
+func Remaining(estimate int64, outputDir string) (int64, error) {
+	written, err := DirSize(outputDir)
+	if err != nil {
+		return 0, err
+	}
+	return max(estimate-written, 0), nil
+}
+
+// FormatBytes formats a byte count for humans, e.g. "4.2 GB"
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,109 @@
+package diskspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "_main", "movie.mkv"), 3000)
+	writeFile(t, filepath.Join(dir, "_extras", "trailers", "trailer.mkv"), 500)
+
+	got, err := DirSize(dir)
+	if err != nil {
+		t.Fatalf("DirSize() error = %v", err)
+	}
+	if got != 3500 {
+		t.Errorf("DirSize() = %d, want 3500", got)
+	}
+
+	missing, err := DirSize(filepath.Join(dir, "missing"))
+	if err != nil || missing != 0 {
+		t.Errorf("DirSize(missing) = %d, %v; want 0, nil", missing, err)
+	}
+}
+
+func TestEstimateAndRemaining(t *testing.T) {
+	input := t.TempDir()
+	output := filepath.Join(t.TempDir(), "out")
+	writeFile(t, filepath.Join(input, "movie.mkv"), 1000)
+
+	estimate, err := Estimate(input, 0.5)
+	if err != nil || estimate != 500 {
+		t.Fatalf("Estimate() = %d, %v; want 500, nil", estimate, err)
+	}
+
+	if got, _ := Remaining(estimate, output); got != 500 {
+		t.Errorf("Remaining() before output = %d, want 500", got)
+	}
+	writeFile(t, filepath.Join(output, "movie.mkv"), 200)
+	if got, _ := Remaining(estimate, output); got != 300 {
+		t.Errorf("Remaining() after partial output = %d, want 300", got)
+	}
+	writeFile(t, filepath.Join(output, "extra.mkv"), 400)
+	if got, _ := Remaining(estimate, output); got != 0 {
+		t.Errorf("Remaining() past estimate = %d, want 0", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// Need not exist yet: the check runs against the nearest parent
+	dir := filepath.Join(t.TempDir(), "staging", "1-ripped", "movies", "Movie")
+
+	free, err := Free(dir)
+	if err != nil {
+		t.Fatalf("Free() error = %v", err)
+	}
+
+	if free > Headroom {
+		if err := Check(dir, 0); err != nil {
+			t.Errorf("Check(0) error = %v, want nil", err)
+		}
+	}
+
+	err = Check(dir, free)
+	var insufficient *InsufficientError
+	if !errors.As(err, &insufficient) {
+		t.Fatalf("Check(free) error = %v, want InsufficientError", err)
+	}
+	if insufficient.Need != free+Headroom || insufficient.Dir != dir {
+		t.Errorf("InsufficientError = %+v, want need %d in %s", insufficient, free+Headroom, dir)
+	}
+	if kind := FailureKind(err); kind != model.FailureKindSpace {
+		t.Errorf("FailureKind() = %q, want %q", kind, model.FailureKindSpace)
+	}
+	if kind := FailureKind(errors.New("stat failed")); kind != model.FailureKindStage {
+		t.Errorf("FailureKind(other) = %q, want %q", kind, model.FailureKindStage)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KB"},
+		{40 << 30, "40.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.in); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
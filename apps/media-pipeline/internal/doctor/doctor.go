@@ -0,0 +1,419 @@
+// Package doctor checks that this host and the dispatch targets have what
+// the pipeline needs: the external tools, hardware encoding, the config,
+// the database and SSH or agent access. It reports every problem at once
+// instead of a stage failing on the first one hours into a run.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/agent"
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/daemon"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"github.com/cuivienor/media-pipeline/internal/transcode"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // Not needed on this host or with this config
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Check  string `json:"check"`
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"` // Version, path or error
+	Hint   string `json:"hint,omitempty"`   // How to fix a failure
+}
+
+// Tool is an external program a stage runs
+type Tool struct {
+	Name        string
+	VersionArgs []string    // Arguments that print the version on the first line
+	Stage       model.Stage // Stage that runs it
+	Hint        string      // How to install it
+}
+
+// Tools are the external programs the stages run
+var Tools = []Tool{
+	{"makemkvcon", nil, model.StageRip, "install MakeMKV (makemkv-bin) and register it with a key"},
+	{"mkvmerge", []string{"--version"}, model.StageRemux, "install MKVToolNix (mkvtoolnix)"},
+	{"ffmpeg", []string{"-version"}, model.StageTranscode, "install ffmpeg"},
+	{"ffprobe", []string{"-version"}, model.StageTranscode, "install ffmpeg, which ships ffprobe"},
+	{"filebot", []string{"-version"}, model.StagePublish, "install FileBot and activate its license"},
+}
+
+// commandTimeout bounds each external command, so an unreachable host does
+// not hang the report
+const commandTimeout = 15 * time.Second
+
+// Doctor runs the checks
+type Doctor struct {
+	lookPath      func(file string) (string, error)
+	output        func(ctx context.Context, name string, args ...string) ([]byte, error)
+	checkHardware func() error
+	client        *http.Client
+}
+
+// New creates a doctor that checks the real host
+func New() *Doctor {
+	return &Doctor{
+		lookPath: exec.LookPath,
+		output: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return exec.CommandContext(ctx, name, args...).CombinedOutput()
+		},
+		checkHardware: transcode.CheckHardwareSupport,
+		client:        &http.Client{Timeout: commandTimeout},
+	}
+}
+
+// Run runs every check, loading the config from $MEDIA_BASE. Checks that
+// need the config are skipped if it does not load.
+func (d *Doctor) Run(ctx context.Context) []Result {
+	var results []Result
+
+	cfg, configResults := d.checkConfig()
+	results = append(results, d.checkTools(ctx, cfg)...)
+	results = append(results, d.checkHardwareEncoding(cfg))
+	results = append(results, configResults...)
+	results = append(results, d.checkDatabase(cfg))
+	results = append(results, d.checkTargets(ctx, cfg)...)
+	return results
+}
+
+// Failed counts the failed results
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
+
+// checkTools checks that the tools of stages that run on this host are
+// installed, and reports their versions
+func (d *Doctor) checkTools(ctx context.Context, cfg *config.Config) []Result {
+	var results []Result
+	for _, tool := range Tools {
+		check := "tool " + tool.Name
+		path, err := d.lookPath(tool.Name)
+		if err != nil {
+			if cfg != nil && !cfg.IsLocal(tool.Stage.String()) {
+				results = append(results, Result{
+					Check:  check,
+					Status: StatusSkip,
+					Detail: fmt.Sprintf("not installed here; %s runs on %s", tool.Stage, cfg.DispatchTarget(tool.Stage.String())),
+				})
+				continue
+			}
+			results = append(results, Result{
+				Check:  check,
+				Status: StatusFail,
+				Detail: fmt.Sprintf("not found in PATH (needed by %s)", tool.Stage),
+				Hint:   tool.Hint,
+			})
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+		// Some tools exit non-zero after printing their version
+		out, _ := d.output(ctx, path, tool.VersionArgs...)
+		cancel()
+
+		detail := path
+		if version := firstLine(string(out)); version != "" {
+			detail = fmt.Sprintf("%s: %s", path, version)
+		}
+		results = append(results, Result{Check: check, Status: StatusPass, Detail: detail})
+	}
+	return results
+}
+
+// checkHardwareEncoding checks that QSV works when transcodes use it here
+func (d *Doctor) checkHardwareEncoding(cfg *config.Config) Result {
+	result := Result{Check: "hardware encoding"}
+	switch {
+	case cfg == nil:
+		result.Status = StatusSkip
+		result.Detail = "config did not load"
+	case cfg.TranscodeMode() != "hardware":
+		result.Status = StatusSkip
+		result.Detail = fmt.Sprintf("transcode mode is %s", cfg.TranscodeMode())
+	case !cfg.IsLocal("transcode"):
+		result.Status = StatusSkip
+		result.Detail = fmt.Sprintf("transcode runs on %s", cfg.DispatchTarget("transcode"))
+	default:
+		if err := d.checkHardware(); err != nil {
+			result.Status = StatusFail
+			result.Detail = firstLine(err.Error())
+			result.Hint = "pass /dev/dri through to this host (render group access), or set transcode.mode: software"
+		} else {
+			result.Status = StatusPass
+			result.Detail = "QSV encodes HEVC"
+		}
+	}
+	return result
+}
+
+// checkConfig loads the config and checks that the directories it names
+// exist and are writable
+func (d *Doctor) checkConfig() (*config.Config, []Result) {
+	path := config.MediaBaseConfigPath()
+	cfg, err := config.LoadFromMediaBase()
+	if err != nil {
+		return nil, []Result{{
+			Check:  "config",
+			Status: StatusFail,
+			Detail: err.Error(),
+			Hint:   fmt.Sprintf("create %s, or point MEDIA_BASE at the directory holding pipeline/config.yaml", path),
+		}}
+	}
+
+	results := []Result{{Check: "config", Status: StatusPass, Detail: path}}
+	dirs := []struct {
+		check, dir string
+	}{
+		{"staging_base", cfg.StagingBase},
+		{"library_base", cfg.LibraryBase},
+		{"data dir", cfg.DataDir()},
+	}
+	for _, dir := range dirs {
+		result := Result{Check: "path " + dir.check}
+		if dir.dir == "" {
+			result.Status = StatusFail
+			result.Detail = "not set"
+			result.Hint = fmt.Sprintf("set %s in %s", dir.check, path)
+		} else if err := checkWritable(dir.dir); err != nil {
+			result.Status = StatusFail
+			result.Detail = err.Error()
+			result.Hint = fmt.Sprintf("create %s and make it writable by this user", dir.dir)
+		} else {
+			result.Status = StatusPass
+			result.Detail = dir.dir
+		}
+		results = append(results, result)
+	}
+	return cfg, results
+}
+
+// checkWritable checks that dir is a directory this process can create
+// files in
+func checkWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, errors.Unwrap(err))
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkDatabase opens the database, which applies pending migrations
+func (d *Doctor) checkDatabase(cfg *config.Config) Result {
+	result := Result{Check: "database"}
+	if cfg == nil {
+		result.Status = StatusSkip
+		result.Detail = "config did not load"
+		return result
+	}
+
+	path := cfg.DatabasePath()
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		result.Status = StatusFail
+		result.Detail = err.Error()
+		result.Hint = fmt.Sprintf("create %s", filepath.Dir(path))
+		return result
+	}
+	database, err := db.Open(path)
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = err.Error()
+		result.Hint = "check the file is a pipeline database and not locked by a stuck process; restore it from backup if it is corrupt"
+		return result
+	}
+	defer database.Close()
+
+	applied, latest, err := database.SchemaVersion()
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = err.Error()
+		return result
+	}
+	if applied > latest {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("schema at %s, this binary expects %s", applied, latest)
+		result.Hint = "the database was migrated by a newer build; upgrade media-pipeline"
+		return result
+	}
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("%s (schema %s)", path, strings.TrimSuffix(applied, ".sql"))
+	return result
+}
+
+// checkTargets checks that every dispatch target is reachable, and over SSH
+// that it has the stage binaries and tools of the stages routed to it
+func (d *Doctor) checkTargets(ctx context.Context, cfg *config.Config) []Result {
+	if cfg == nil {
+		return nil
+	}
+
+	stagesByTarget := make(map[string][]model.Stage)
+	for name, target := range cfg.Dispatch {
+		stage, err := model.ParseStage(name)
+		if err != nil || target == "" {
+			continue
+		}
+		stagesByTarget[target] = append(stagesByTarget[target], stage)
+	}
+	targets := make([]string, 0, len(stagesByTarget))
+	for target := range stagesByTarget {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	var results []Result
+	for _, target := range targets {
+		stages := stagesByTarget[target]
+		slices.Sort(stages)
+		if cfg.Agent.Enabled {
+			results = append(results, d.checkAgent(ctx, cfg, target))
+		} else {
+			results = append(results, d.checkSSH(ctx, target, stages))
+		}
+	}
+	return results
+}
+
+// checkAgent checks that the agent at target answers
+func (d *Doctor) checkAgent(ctx context.Context, cfg *config.Config, target string) Result {
+	result := Result{Check: "agent " + target}
+	url := cfg.AgentURL(target) + "/status"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = err.Error()
+		return result
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = err.Error()
+		result.Hint = fmt.Sprintf("start `media-pipeline agent` on %s and check the port is open", target)
+		return result
+	}
+	defer resp.Body.Close()
+
+	var status agent.Status
+	if resp.StatusCode != http.StatusOK {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("%s returned %s", url, resp.Status)
+		result.Hint = fmt.Sprintf("check that %s is a media-pipeline agent", target)
+		return result
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("invalid status from %s: %v", url, err)
+		return result
+	}
+
+	caps := make([]string, len(status.Capabilities))
+	for i, c := range status.Capabilities {
+		caps[i] = string(c)
+	}
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("%s, capabilities: %s", status.Hostname, strings.Join(caps, ", "))
+	return result
+}
+
+// sshUnreachable is the exit status ssh uses for its own errors, as opposed
+// to the remote command's
+const sshUnreachable = 255
+
+// checkSSH checks that target accepts SSH without a password prompt and has
+// the binaries of the given stages in its PATH
+func (d *Doctor) checkSSH(ctx context.Context, target string, stages []model.Stage) Result {
+	result := Result{Check: "ssh " + target}
+
+	var want []string
+	for _, stage := range stages {
+		if name, err := daemon.BinaryName(stage); err == nil {
+			want = append(want, name)
+		}
+		for _, tool := range Tools {
+			if tool.Stage == stage {
+				want = append(want, tool.Name)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	args := append([]string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10", target, "command", "-v"}, want...)
+	out, err := d.output(ctx, "ssh", args...)
+
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() == sshUnreachable) {
+		result.Status = StatusFail
+		result.Detail = firstLine(strings.TrimSpace(string(out)) + "\n" + err.Error())
+		result.Hint = fmt.Sprintf("set up key-based SSH from this host to %s (ssh-copy-id %s)", target, target)
+		return result
+	}
+
+	// command -v prints the path of each program it finds
+	found := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		found[filepath.Base(strings.TrimSpace(line))] = true
+	}
+	var missing []string
+	for _, name := range want {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		result.Status = StatusFail
+		result.Detail = "missing from PATH: " + strings.Join(missing, ", ")
+		result.Hint = fmt.Sprintf("install them on %s, in the PATH of non-interactive SSH sessions", target)
+		return result
+	}
+
+	result.Status = StatusPass
+	result.Detail = "has " + strings.Join(want, ", ")
+	return result
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
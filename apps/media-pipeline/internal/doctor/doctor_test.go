@@ -0,0 +1,176 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/agent"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// setupMediaBase writes a config to a fresh $MEDIA_BASE, with the staging
+// and library directories it names
+func setupMediaBase(t *testing.T, extra string) string {
+	t.Helper()
+	base := t.TempDir()
+	t.Setenv("MEDIA_BASE", base)
+	for _, dir := range []string{"pipeline", "staging", "library"} {
+		os.MkdirAll(filepath.Join(base, dir), 0755)
+	}
+	cfg := fmt.Sprintf("staging_base: %s\nlibrary_base: %s\n%s", filepath.Join(base, "staging"), filepath.Join(base, "library"), extra)
+	if err := os.WriteFile(filepath.Join(base, "pipeline", "config.yaml"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return base
+}
+
+// fakeDoctor finds the installed tools in /usr/bin and answers commands
+// from outputs, keyed by the command name
+func fakeDoctor(installed []string, outputs map[string]string, errs map[string]error) *Doctor {
+	return &Doctor{
+		lookPath: func(file string) (string, error) {
+			for _, name := range installed {
+				if name == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("executable file not found in $PATH")
+		},
+		output: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			name = filepath.Base(name)
+			return []byte(outputs[name]), errs[name]
+		},
+		checkHardware: func() error { return nil },
+		client:        http.DefaultClient,
+	}
+}
+
+func byCheck(results []Result) map[string]Result {
+	m := make(map[string]Result)
+	for _, r := range results {
+		m[r.Check] = r
+	}
+	return m
+}
+
+func TestDoctor_Run(t *testing.T) {
+	setupMediaBase(t, "dispatch:\n  rip: ripper\n  publish: ripper\ntranscode:\n  mode: hardware\n")
+
+	d := fakeDoctor(
+		[]string{"mkvmerge", "ffmpeg", "ffprobe"},
+		map[string]string{
+			"mkvmerge": "mkvmerge v80.0 ('Roundabout') 64-bit\n",
+			"ffmpeg":   "ffmpeg version 6.1.1\nbuilt with gcc\n",
+			"ssh":      "/usr/local/bin/ripper\n/usr/bin/makemkvcon\n/usr/local/bin/publish\n",
+		},
+		nil,
+	)
+	d.checkHardware = func() error { return errors.New("QSV not available: Device creation failed") }
+
+	results := byCheck(d.Run(context.Background()))
+
+	tests := []struct {
+		check      string
+		wantStatus Status
+		wantDetail string
+	}{
+		{"tool mkvmerge", StatusPass, "/usr/bin/mkvmerge: mkvmerge v80.0"},
+		{"tool ffmpeg", StatusPass, "ffmpeg version 6.1.1"},
+		// Not installed here, but rip is dispatched to another host
+		{"tool makemkvcon", StatusSkip, "rip runs on ripper"},
+		// Not installed here, and publish runs on ripper as well
+		{"tool filebot", StatusSkip, "publish runs on ripper"},
+		{"hardware encoding", StatusFail, "QSV not available"},
+		{"config", StatusPass, "config.yaml"},
+		{"path staging_base", StatusPass, "staging"},
+		{"path library_base", StatusPass, "library"},
+		{"database", StatusPass, "schema"},
+		{"ssh ripper", StatusFail, "missing from PATH: filebot"},
+	}
+	for _, tt := range tests {
+		got, ok := results[tt.check]
+		if !ok {
+			t.Errorf("no %q check in %+v", tt.check, results)
+			continue
+		}
+		if got.Status != tt.wantStatus || !strings.Contains(got.Detail, tt.wantDetail) {
+			t.Errorf("%s = %s %q, want %s containing %q", tt.check, got.Status, got.Detail, tt.wantStatus, tt.wantDetail)
+		}
+		if got.Status == StatusFail && got.Hint == "" {
+			t.Errorf("%s failed without a hint", tt.check)
+		}
+	}
+	if n := Failed(d.Run(context.Background())); n != 2 {
+		t.Errorf("Failed() = %d, want 2", n)
+	}
+}
+
+func TestDoctor_Run_LocalToolMissing(t *testing.T) {
+	setupMediaBase(t, "")
+	d := fakeDoctor(nil, nil, nil)
+
+	results := byCheck(d.Run(context.Background()))
+	got := results["tool mkvmerge"]
+	if got.Status != StatusFail || got.Hint == "" {
+		t.Errorf("tool mkvmerge = %+v, want a failure with a hint", got)
+	}
+	if results["hardware encoding"].Status != StatusSkip {
+		t.Errorf("hardware encoding = %+v, want skipped in software mode", results["hardware encoding"])
+	}
+}
+
+func TestDoctor_Run_NoConfig(t *testing.T) {
+	t.Setenv("MEDIA_BASE", t.TempDir())
+	d := fakeDoctor(nil, nil, nil)
+
+	results := byCheck(d.Run(context.Background()))
+	if got := results["config"]; got.Status != StatusFail || !strings.Contains(got.Hint, "config.yaml") {
+		t.Errorf("config = %+v, want a failure pointing at config.yaml", got)
+	}
+	if got := results["database"]; got.Status != StatusSkip {
+		t.Errorf("database = %+v, want skipped without a config", got)
+	}
+}
+
+func TestDoctor_Run_Unwritable(t *testing.T) {
+	base := setupMediaBase(t, "")
+	os.RemoveAll(filepath.Join(base, "library"))
+
+	results := byCheck(fakeDoctor(nil, nil, nil).Run(context.Background()))
+	if got := results["path library_base"]; got.Status != StatusFail {
+		t.Errorf("path library_base = %+v, want a failure for a missing directory", got)
+	}
+}
+
+func TestDoctor_CheckSSH_Unreachable(t *testing.T) {
+	d := fakeDoctor(nil, map[string]string{"ssh": "ssh: connect to host ripper port 22: No route to host\n"},
+		map[string]error{"ssh": errors.New("exec: signal: killed")})
+
+	got := d.checkSSH(context.Background(), "ripper", []model.Stage{model.StageRip})
+	if got.Status != StatusFail || !strings.Contains(got.Detail, "No route to host") || !strings.Contains(got.Hint, "ssh-copy-id") {
+		t.Errorf("checkSSH() = %+v, want an unreachable failure", got)
+	}
+}
+
+func TestDoctor_Run_Agents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(agent.Status{Hostname: "ripper", Capabilities: []model.Capability{model.CapabilityOpticalDrive}})
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	setupMediaBase(t, fmt.Sprintf("agent:\n  enabled: true\ndispatch:\n  rip: %s\n", addr))
+
+	results := byCheck(fakeDoctor(nil, nil, nil).Run(context.Background()))
+	if got := results["agent "+addr]; got.Status != StatusPass || !strings.Contains(got.Detail, "optical_drive") {
+		t.Errorf("agent check = %+v, want the agent's capabilities", got)
+	}
+}
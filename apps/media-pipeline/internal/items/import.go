@@ -0,0 +1,144 @@
+package items
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// ImportAction is what importing a manifest entry did, or would do on a
+// dry run
+type ImportAction string
+
+const (
+	ImportCreated      ImportAction = "created"       // New item
+	ImportSeasonsAdded ImportAction = "seasons_added" // Existing TV show, new seasons
+	ImportDuplicate    ImportAction = "duplicate"     // Item and seasons already exist
+	ImportConflict     ImportAction = "conflict"      // Name taken by an item of the other type
+)
+
+// ImportResult reports what happened to one manifest entry
+type ImportResult struct {
+	Entry   Entry
+	Action  ImportAction
+	ItemID  int64 // 0 for an item a dry run would create
+	Seasons []int // Seasons created, or a dry run would create
+	Reason  string
+}
+
+// imported is an item the import has seen, in the database or earlier in
+// the manifest
+type imported struct {
+	id        int64
+	mediaType model.MediaType
+	item      *model.MediaItem // nil until created or loaded
+	seasons   map[int]bool
+	inFile    bool // First seen in the manifest rather than the database
+}
+
+// Import creates the items and seasons of a manifest that do not exist yet.
+// Items are matched by safe name, so importing the same manifest twice only
+// reports duplicates. With dryRun nothing is written.
+func Import(ctx context.Context, repo db.Repository, entries []Entry, dryRun bool) ([]ImportResult, error) {
+	seen := make(map[string]*imported)
+	results := make([]ImportResult, 0, len(entries))
+
+	for _, entry := range entries {
+		safeName := SafeName(entry.Name)
+		known, ok := seen[safeName]
+		if !ok {
+			existing, err := lookup(ctx, repo, safeName)
+			if err != nil {
+				return results, err
+			}
+			known = existing
+			seen[safeName] = known
+		}
+
+		result := ImportResult{Entry: entry}
+		switch {
+		case known == nil:
+			result.Action = ImportCreated
+			for _, s := range entry.Seasons {
+				result.Seasons = append(result.Seasons, s.Number)
+			}
+			known = &imported{mediaType: entry.Type, seasons: make(map[int]bool), inFile: true}
+			for _, s := range entry.Seasons {
+				known.seasons[s.Number] = true
+			}
+			if !dryRun {
+				item, err := create(ctx, repo, entry)
+				if err != nil {
+					return results, fmt.Errorf("line %d: %w", entry.Line, err)
+				}
+				known.id = item.ID
+				known.item = item
+				result.ItemID = item.ID
+			}
+			seen[safeName] = known
+
+		case known.mediaType != entry.Type:
+			result.Action = ImportConflict
+			result.ItemID = known.id
+			result.Reason = fmt.Sprintf("%s is already a %s", safeName, known.mediaType)
+
+		default:
+			result.ItemID = known.id
+			var missing []SeasonEntry
+			for _, s := range entry.Seasons {
+				if !known.seasons[s.Number] {
+					missing = append(missing, s)
+				}
+			}
+			if len(missing) == 0 {
+				result.Action = ImportDuplicate
+				result.Reason = "already in the database"
+				if known.inFile {
+					result.Reason = "repeats an earlier entry"
+				}
+				break
+			}
+
+			result.Action = ImportSeasonsAdded
+			for _, s := range missing {
+				result.Seasons = append(result.Seasons, s.Number)
+				known.seasons[s.Number] = true
+				if dryRun {
+					continue
+				}
+				if _, err := createSeason(ctx, repo, known.item, s); err != nil {
+					return results, fmt.Errorf("line %d: %w", entry.Line, err)
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// lookup loads an existing item and its seasons by safe name, nil if there
+// is none
+func lookup(ctx context.Context, repo db.Repository, safeName string) (*imported, error) {
+	item, err := repo.GetMediaItemBySafeName(ctx, safeName, nil)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, nil
+	}
+
+	known := &imported{id: item.ID, mediaType: item.Type, item: item, seasons: make(map[int]bool)}
+	if item.Type == model.MediaTypeTV {
+		seasons, err := repo.ListSeasonsForItem(ctx, item.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list seasons of %s: %w", safeName, err)
+		}
+		for _, s := range seasons {
+			known.seasons[s.Number] = true
+		}
+	}
+	return known, nil
+}
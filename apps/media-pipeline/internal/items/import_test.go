@@ -0,0 +1,108 @@
+package items
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestImport(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	// Already in the database before the import
+	if _, err := Create(ctx, repo, model.MediaTypeTV, "Breaking Bad", 81189, []int{1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := Create(ctx, repo, model.MediaTypeMovie, "Lost", 0, nil); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	entries := []Entry{
+		{Line: 2, Type: model.MediaTypeMovie, Name: "The Matrix", Year: 1999, TmdbID: 603},
+		{Line: 3, Type: model.MediaTypeTV, Name: "Breaking Bad", TvdbID: 81189,
+			Seasons: []SeasonEntry{{1, 3}, {2, 4}}},
+		{Line: 4, Type: model.MediaTypeMovie, Name: "The Matrix"},
+		{Line: 5, Type: model.MediaTypeTV, Name: "Lost", Seasons: []SeasonEntry{{1, 7}}},
+	}
+	wantActions := []ImportAction{ImportCreated, ImportSeasonsAdded, ImportDuplicate, ImportConflict}
+
+	// A dry run reports what would happen without writing anything
+	results, err := Import(ctx, repo, entries, true)
+	if err != nil {
+		t.Fatalf("Import(dry run) error = %v", err)
+	}
+	if got := actions(results); !reflect.DeepEqual(got, wantActions) {
+		t.Errorf("Import(dry run) actions = %v, want %v", got, wantActions)
+	}
+	if item, _ := repo.GetMediaItemBySafeName(ctx, "The_Matrix", nil); item != nil {
+		t.Fatal("Import(dry run) created The Matrix")
+	}
+
+	results, err = Import(ctx, repo, entries, false)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if got := actions(results); !reflect.DeepEqual(got, wantActions) {
+		t.Errorf("Import() actions = %v, want %v", got, wantActions)
+	}
+	if !reflect.DeepEqual(results[1].Seasons, []int{2}) {
+		t.Errorf("Import() seasons added = %v, want [2]", results[1].Seasons)
+	}
+
+	matrix, _ := repo.GetMediaItem(ctx, results[0].ItemID)
+	if matrix == nil || matrix.Year == nil || *matrix.Year != 1999 || matrix.DatabaseID() != 603 {
+		t.Errorf("Import() created %+v, want The Matrix (1999) with TMDB 603", matrix)
+	}
+	seasons, _ := repo.ListSeasonsForItem(ctx, results[1].ItemID)
+	if len(seasons) != 2 || seasons[1].Number != 2 || seasons[1].DiscCount != 4 {
+		t.Errorf("Import() seasons = %+v, want season 2 with 4 discs added", seasons)
+	}
+
+	// Importing again only finds duplicates, and the conflict
+	results, err = Import(ctx, repo, entries, false)
+	if err != nil {
+		t.Fatalf("Import(again) error = %v", err)
+	}
+	want := []ImportAction{ImportDuplicate, ImportDuplicate, ImportDuplicate, ImportConflict}
+	if got := actions(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("Import(again) actions = %v, want %v", got, want)
+	}
+}
+
+func TestImport_MergesSeasonsOfRepeatedShow(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	// One row per season, as a CSV export of a shelf would have
+	entries := []Entry{
+		{Line: 2, Type: model.MediaTypeTV, Name: "The Wire", Seasons: []SeasonEntry{{1, 4}}},
+		{Line: 3, Type: model.MediaTypeTV, Name: "The Wire", Seasons: []SeasonEntry{{2, 3}}},
+	}
+	for _, dryRun := range []bool{true, false} {
+		results, err := Import(ctx, repo, entries, dryRun)
+		if err != nil {
+			t.Fatalf("Import(dryRun=%v) error = %v", dryRun, err)
+		}
+		want := []ImportAction{ImportCreated, ImportSeasonsAdded}
+		if got := actions(results); !reflect.DeepEqual(got, want) {
+			t.Errorf("Import(dryRun=%v) actions = %v, want %v", dryRun, got, want)
+		}
+	}
+
+	item, _ := repo.GetMediaItemBySafeName(ctx, "The_Wire", nil)
+	seasons, _ := repo.ListSeasonsForItem(ctx, item.ID)
+	if len(seasons) != 2 {
+		t.Errorf("Import() created %d seasons, want 2", len(seasons))
+	}
+}
+
+func actions(results []ImportResult) []ImportAction {
+	var out []ImportAction
+	for _, r := range results {
+		out = append(out, r.Action)
+	}
+	return out
+}
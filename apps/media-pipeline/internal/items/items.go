@@ -0,0 +1,179 @@
+// Package items creates media items and their seasons, shared by the TUI
+// and the command line.
+package items
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Create adds a media item waiting to be ripped. databaseID is the TMDB ID
+// for movies and the TVDB ID for TV shows, 0 if not known yet. TV shows get a
+// season for each of seasons.
+func Create(ctx context.Context, repo db.Repository, mediaType model.MediaType, name string, databaseID int, seasons []int) (*model.MediaItem, error) {
+	entry := Entry{Type: mediaType, Name: name}
+	if mediaType == model.MediaTypeMovie {
+		entry.TmdbID = databaseID
+	} else {
+		entry.TvdbID = databaseID
+	}
+	for _, num := range seasons {
+		entry.Seasons = append(entry.Seasons, SeasonEntry{Number: num})
+	}
+	return create(ctx, repo, entry)
+}
+
+// create adds the item an entry describes, with its seasons
+func create(ctx context.Context, repo db.Repository, entry Entry) (*model.MediaItem, error) {
+	item := &model.MediaItem{
+		Type:       entry.Type,
+		Name:       entry.Name,
+		SafeName:   SafeName(entry.Name),
+		ItemStatus: model.ItemStatusNotStarted,
+	}
+
+	if entry.Year != 0 {
+		year := entry.Year
+		item.Year = &year
+	}
+	if databaseID := entry.DatabaseID(); databaseID != 0 {
+		if entry.Type == model.MediaTypeMovie {
+			item.TmdbID = &databaseID
+		} else {
+			item.TvdbID = &databaseID
+		}
+	}
+
+	// For movies, set initial stage
+	if entry.Type == model.MediaTypeMovie {
+		item.CurrentStage = model.StageRip
+		item.StageStatus = model.StatusPending
+	}
+
+	// The item and its seasons are created together or not at all
+	err := repo.WithTx(ctx, func(tx db.Repository) error {
+		if err := tx.CreateMediaItem(ctx, item); err != nil {
+			return err
+		}
+
+		// For TV shows, create seasons
+		if entry.Type == model.MediaTypeTV {
+			item.Seasons = nil
+			for _, s := range entry.Seasons {
+				season, err := createSeason(ctx, tx, item, s)
+				if err != nil {
+					return err
+				}
+				item.Seasons = append(item.Seasons, *season)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// AddSeason adds the season after the show's last one
+func AddSeason(ctx context.Context, repo db.Repository, item *model.MediaItem) (*model.Season, error) {
+	next := 1
+	for _, s := range item.Seasons {
+		if s.Number >= next {
+			next = s.Number + 1
+		}
+	}
+	return createSeason(ctx, repo, item, SeasonEntry{Number: next})
+}
+
+func createSeason(ctx context.Context, repo db.Repository, item *model.MediaItem, entry SeasonEntry) (*model.Season, error) {
+	season := &model.Season{
+		ItemID:       item.ID,
+		Number:       entry.Number,
+		CurrentStage: model.StageRip,
+		StageStatus:  model.StatusPending,
+		DiscCount:    entry.Discs,
+	}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		return nil, fmt.Errorf("failed to create season %d: %w", entry.Number, err)
+	}
+	return season, nil
+}
+
+// SafeName derives the directory-safe name of an item from its display name
+func SafeName(name string) string {
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// ParseSeasons parses a season string like "1-5" or "1,2,3" into a slice of ints
+func ParseSeasons(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("seasons cannot be empty")
+	}
+
+	seen := make(map[int]bool)
+
+	// Handle range: "1-5"
+	if strings.Contains(s, "-") {
+		parts := strings.Split(s, "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid range format, use '1-5'")
+		}
+		start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid start number")
+		}
+		end, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid end number")
+		}
+		if start < 1 {
+			return nil, fmt.Errorf("season numbers must be positive (1 or greater)")
+		}
+		if start > end {
+			return nil, fmt.Errorf("start must be less than or equal to end")
+		}
+		var seasons []int
+		for i := start; i <= end; i++ {
+			seasons = append(seasons, i)
+		}
+		return seasons, nil
+	}
+
+	// Handle comma-separated: "1,2,3"
+	if strings.Contains(s, ",") {
+		parts := strings.Split(s, ",")
+		var seasons []int
+		for _, p := range parts {
+			n, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return nil, fmt.Errorf("invalid season number: %s", p)
+			}
+			if n < 1 {
+				return nil, fmt.Errorf("season numbers must be positive (1 or greater)")
+			}
+			if seen[n] {
+				return nil, fmt.Errorf("duplicate season number: %d", n)
+			}
+			seasons = append(seasons, n)
+			seen[n] = true
+		}
+		return seasons, nil
+	}
+
+	// Single number
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid season number")
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("season numbers must be positive (1 or greater)")
+	}
+	return []int{n}, nil
+}
@@ -0,0 +1,128 @@
+package items
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func newTestRepo(t *testing.T) *db.SQLiteRepository {
+	t.Helper()
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return db.NewSQLiteRepository(database)
+}
+
+func TestCreate_Movie(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	item, err := Create(ctx, repo, model.MediaTypeMovie, "The Matrix", 603, nil)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	got, _ := repo.GetMediaItem(ctx, item.ID)
+	if got.SafeName != "The_Matrix" || got.DatabaseID() != 603 {
+		t.Errorf("Create() = %q with ID %d, want The_Matrix with 603", got.SafeName, got.DatabaseID())
+	}
+	if got.CurrentStage != model.StageRip || got.StageStatus != model.StatusPending {
+		t.Errorf("Create() stage = %s %s, want rip pending", got.CurrentStage, got.StageStatus)
+	}
+}
+
+func TestCreate_TVAndAddSeason(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	item, err := Create(ctx, repo, model.MediaTypeTV, "Breaking Bad", 81189, []int{1, 2})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if item.TvdbID == nil || *item.TvdbID != 81189 {
+		t.Errorf("Create() TvdbID = %v, want 81189", item.TvdbID)
+	}
+
+	season, err := AddSeason(ctx, repo, item)
+	if err != nil {
+		t.Fatalf("AddSeason() error = %v", err)
+	}
+	if season.Number != 3 {
+		t.Errorf("AddSeason() number = %d, want 3", season.Number)
+	}
+
+	seasons, _ := repo.ListSeasonsForItem(ctx, item.ID)
+	if len(seasons) != 3 {
+		t.Errorf("got %d seasons, want 3", len(seasons))
+	}
+}
+
+// failSecondSeason fails creating a show's second season
+type failSecondSeason struct {
+	db.Repository
+}
+
+func (f *failSecondSeason) WithTx(ctx context.Context, fn func(tx db.Repository) error) error {
+	return f.Repository.WithTx(ctx, func(tx db.Repository) error {
+		return fn(&failSecondSeason{Repository: tx})
+	})
+}
+
+func (f *failSecondSeason) CreateSeason(ctx context.Context, season *model.Season) error {
+	if season.Number == 2 {
+		return errors.New("injected failure")
+	}
+	return f.Repository.CreateSeason(ctx, season)
+}
+
+func TestCreate_RollsBackWhenASeasonFails(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	if _, err := Create(ctx, &failSecondSeason{Repository: repo}, model.MediaTypeTV, "Breaking Bad", 0, []int{1, 2}); err == nil {
+		t.Fatal("Create() succeeded, want the injected failure")
+	}
+
+	items, err := repo.ListMediaItems(ctx, db.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListMediaItems() error = %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("got %d items, want none", len(items))
+	}
+}
+
+func TestParseSeasons(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{"1", []int{1}, false},
+		{"1-3", []int{1, 2, 3}, false},
+		{"1, 3", []int{1, 3}, false},
+		{"", nil, true},
+		{"3-1", nil, true},
+		{"1,1", nil, true},
+		{"0", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSeasons(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeasons(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSeasons(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
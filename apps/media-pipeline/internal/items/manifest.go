@@ -0,0 +1,263 @@
+package items
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is one item of an import manifest
+type Entry struct {
+	Line    int             `yaml:"-"` // Line of the manifest the entry starts on
+	Type    model.MediaType `yaml:"type"`
+	Name    string          `yaml:"name"`
+	Year    int             `yaml:"year"`
+	TmdbID  int             `yaml:"tmdb_id"`
+	TvdbID  int             `yaml:"tvdb_id"`
+	Seasons []SeasonEntry   `yaml:"seasons"` // TV shows only
+}
+
+// SeasonEntry is a season of a TV show in an import manifest
+type SeasonEntry struct {
+	Number int `yaml:"number"`
+	Discs  int `yaml:"discs"` // 0 if not known
+}
+
+// csvColumns are the columns a CSV manifest may have. type and name are
+// required; seasons takes "1-5" or "1,2,3", and discs either one count for
+// every season or one per season ("3,4,2").
+var csvColumns = []string{"type", "name", "year", "tmdb_id", "tvdb_id", "seasons", "discs"}
+
+// ReadManifest reads a CSV or YAML manifest, picked by the file extension
+func ReadManifest(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(f)
+	case ".yaml", ".yml":
+		return ParseYAML(f)
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q: want .csv, .yaml or .yml", filepath.Ext(path))
+	}
+}
+
+// ParseYAML parses a manifest written as a YAML list of entries
+func ParseYAML(r io.Reader) ([]Entry, error) {
+	var nodes []yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&nodes); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(nodes))
+	for i := range nodes {
+		var entry Entry
+		if err := nodes[i].Decode(&entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", nodes[i].Line, err)
+		}
+		entry.Line = nodes[i].Line
+		if err := entry.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", entry.Line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ParseCSV parses a manifest written as CSV with a header row naming the
+// columns (see csvColumns). Lines starting with # are skipped.
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown column %q: want %s", name, strings.Join(csvColumns, ", "))
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"type", "name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("manifest has no %s column", required)
+		}
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		entry, err := parseCSVRecord(field)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entry.Line = line
+		if err := entry.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func parseCSVRecord(field func(name string) string) (Entry, error) {
+	entry := Entry{
+		Type: model.MediaType(strings.ToLower(field("type"))),
+		Name: field("name"),
+	}
+
+	var err error
+	if entry.Year, err = parseOptionalInt("year", field("year")); err != nil {
+		return Entry{}, err
+	}
+	if entry.TmdbID, err = parseOptionalInt("tmdb_id", field("tmdb_id")); err != nil {
+		return Entry{}, err
+	}
+	if entry.TvdbID, err = parseOptionalInt("tvdb_id", field("tvdb_id")); err != nil {
+		return Entry{}, err
+	}
+
+	discs := field("discs")
+	if field("seasons") == "" {
+		if discs != "" {
+			return Entry{}, fmt.Errorf("discs are counted per season, but no seasons are given")
+		}
+		return entry, nil
+	}
+	numbers, err := ParseSeasons(field("seasons"))
+	if err != nil {
+		return Entry{}, err
+	}
+	counts, err := parseDiscCounts(discs, len(numbers))
+	if err != nil {
+		return Entry{}, err
+	}
+	for i, number := range numbers {
+		entry.Seasons = append(entry.Seasons, SeasonEntry{Number: number, Discs: counts[i]})
+	}
+	return entry, nil
+}
+
+// parseDiscCounts parses one disc count for every season, or one per season
+func parseDiscCounts(s string, seasons int) ([]int, error) {
+	counts := make([]int, seasons)
+	if s == "" {
+		return counts, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 1 && len(parts) != seasons {
+		return nil, fmt.Errorf("got %d disc counts for %d seasons", len(parts), seasons)
+	}
+	for i := range counts {
+		part := parts[0]
+		if len(parts) > 1 {
+			part = parts[i]
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid disc count %q", part)
+		}
+		counts[i] = n
+	}
+	return counts, nil
+}
+
+func parseOptionalInt(name, s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return n, nil
+}
+
+// Validate checks that an entry describes a movie or TV show that can be
+// created
+func (e *Entry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if e.Year < 0 || e.TmdbID < 0 || e.TvdbID < 0 {
+		return fmt.Errorf("%s: year and IDs cannot be negative", e.Name)
+	}
+
+	switch e.Type {
+	case model.MediaTypeMovie:
+		if e.TvdbID != 0 {
+			return fmt.Errorf("%s: movies are matched by tmdb_id, not tvdb_id", e.Name)
+		}
+		if len(e.Seasons) > 0 {
+			return fmt.Errorf("%s: seasons are only for TV shows", e.Name)
+		}
+	case model.MediaTypeTV:
+		if e.TmdbID != 0 {
+			return fmt.Errorf("%s: TV shows are matched by tvdb_id, not tmdb_id", e.Name)
+		}
+		if len(e.Seasons) == 0 {
+			return fmt.Errorf("%s: TV shows need at least one season", e.Name)
+		}
+		seen := make(map[int]bool)
+		for _, s := range e.Seasons {
+			if s.Number < 1 {
+				return fmt.Errorf("%s: season numbers must be positive (1 or greater)", e.Name)
+			}
+			if s.Discs < 0 {
+				return fmt.Errorf("%s: season %d: disc count cannot be negative", e.Name, s.Number)
+			}
+			if seen[s.Number] {
+				return fmt.Errorf("%s: duplicate season number: %d", e.Name, s.Number)
+			}
+			seen[s.Number] = true
+		}
+	default:
+		return fmt.Errorf("%s: invalid type %q: want movie or tv", e.Name, e.Type)
+	}
+	return nil
+}
+
+// DatabaseID returns the TMDB ID of a movie or the TVDB ID of a TV show
+func (e *Entry) DatabaseID() int {
+	if e.Type == model.MediaTypeMovie {
+		return e.TmdbID
+	}
+	return e.TvdbID
+}
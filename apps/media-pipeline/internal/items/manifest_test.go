@@ -0,0 +1,116 @@
+package items
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestParseCSV(t *testing.T) {
+	manifest := `type,name,year,tmdb_id,tvdb_id,seasons,discs
+# Movies
+movie,The Matrix,1999,603,,,
+tv,"Breaking Bad",2008,,81189,1-3,"3,4,4"
+TV,The Wire,,,79126,"1,2",5
+`
+	entries, err := ParseCSV(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+
+	want := []Entry{
+		{Line: 3, Type: model.MediaTypeMovie, Name: "The Matrix", Year: 1999, TmdbID: 603},
+		{Line: 4, Type: model.MediaTypeTV, Name: "Breaking Bad", Year: 2008, TvdbID: 81189,
+			Seasons: []SeasonEntry{{1, 3}, {2, 4}, {3, 4}}},
+		{Line: 5, Type: model.MediaTypeTV, Name: "The Wire", TvdbID: 79126,
+			Seasons: []SeasonEntry{{1, 5}, {2, 5}}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseCSV() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{"unknown column", "type,name,director\nmovie,Alien,Scott\n", "unknown column"},
+		{"missing name column", "type,year\nmovie,1979\n", "no name column"},
+		{"bad year", "type,name,year\nmovie,Alien,soon\n", "line 2: invalid year"},
+		{"bad type", "type,name\nshort,Alien\n", "invalid type"},
+		{"movie with seasons", "type,name,seasons\nmovie,Alien,1\n", "only for TV shows"},
+		{"tv without seasons", "type,name\ntv,Lost\n", "at least one season"},
+		{"disc count mismatch", "type,name,seasons,discs\ntv,Lost,1-3,\"2,2\"\n", "2 disc counts for 3 seasons"},
+		{"discs without seasons", "type,name,discs\nmovie,Alien,2\n", "no seasons"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(tt.manifest))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCSV() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	manifest := `- type: movie
+  name: The Matrix
+  year: 1999
+  tmdb_id: 603
+- type: tv
+  name: Breaking Bad
+  tvdb_id: 81189
+  seasons:
+    - number: 1
+      discs: 3
+    - number: 2
+`
+	entries, err := ParseYAML(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("ParseYAML() error = %v", err)
+	}
+
+	want := []Entry{
+		{Line: 1, Type: model.MediaTypeMovie, Name: "The Matrix", Year: 1999, TmdbID: 603},
+		{Line: 5, Type: model.MediaTypeTV, Name: "Breaking Bad", TvdbID: 81189,
+			Seasons: []SeasonEntry{{1, 3}, {2, 0}}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseYAML() = %+v, want %+v", entries, want)
+	}
+
+	_, err = ParseYAML(strings.NewReader("- type: tv\n  name: Lost\n  tmdb_id: 4607\n  seasons: [{number: 1}]\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("ParseYAML() error = %v, want a line 1 error for a TV show with a TMDB ID", err)
+	}
+}
+
+func TestReadManifest_PicksFormatByExtension(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "shelf.csv")
+	yamlPath := filepath.Join(dir, "shelf.yml")
+	os.WriteFile(csvPath, []byte("type,name\nmovie,Alien\n"), 0644)
+	os.WriteFile(yamlPath, []byte("- {type: movie, name: Alien}\n"), 0644)
+
+	for _, path := range []string{csvPath, yamlPath} {
+		entries, err := ReadManifest(path)
+		if err != nil {
+			t.Fatalf("ReadManifest(%s) error = %v", path, err)
+		}
+		if len(entries) != 1 || entries[0].Name != "Alien" {
+			t.Errorf("ReadManifest(%s) = %+v, want Alien", path, entries)
+		}
+	}
+
+	if _, err := ReadManifest(filepath.Join(dir, "shelf.json")); err == nil {
+		t.Error("ReadManifest(.json) error = nil, want unknown format")
+	}
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Advance queues the next stage after a completed job when autopilot is on
+// for its item or season. Rip is never advanced: organizing is done by hand,
+// and finishing it hands the item back to autopilot.
+// Returns the queued job, or nil if nothing was queued.
+func Advance(ctx context.Context, repo db.Repository, job *model.Job) (*model.Job, error) {
+	if job.Status != model.JobStatusCompleted {
+		return nil, nil
+	}
+	switch job.Stage {
+	case model.StageOrganize, model.StageRemux, model.StageTranscode:
+	default:
+		return nil, nil
+	}
+
+	item, err := repo.GetMediaItem(ctx, job.MediaItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media item: %w", err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("media item %d not found", job.MediaItemID)
+	}
+
+	var season *model.Season
+	if job.SeasonID != nil {
+		season, err = repo.GetSeason(ctx, *job.SeasonID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get season: %w", err)
+		}
+		if season == nil {
+			return nil, fmt.Errorf("season %d not found", *job.SeasonID)
+		}
+	}
+
+	if !item.Autopilot && (season == nil || !season.Autopilot) {
+		return nil, nil
+	}
+
+	return Enqueue(ctx, repo, item, season, job.Stage.NextStage(), nil)
+}
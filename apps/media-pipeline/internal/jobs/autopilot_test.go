@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestAdvance(t *testing.T) {
+	tests := []struct {
+		name       string
+		autopilot  bool
+		stage      model.Stage
+		status     model.JobStatus
+		wantQueued bool
+	}{
+		{"remux completed", true, model.StageRemux, model.JobStatusCompleted, true},
+		{"transcode completed", true, model.StageTranscode, model.JobStatusCompleted, true},
+		{"organize completed", true, model.StageOrganize, model.JobStatusCompleted, true},
+		{"rip completed stays for organize", true, model.StageRip, model.JobStatusCompleted, false},
+		{"publish is the last stage", true, model.StagePublish, model.JobStatusCompleted, false},
+		{"failed job", true, model.StageRemux, model.JobStatusFailed, false},
+		{"autopilot off", false, model.StageRemux, model.JobStatusCompleted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			ctx := context.Background()
+			job := &model.Job{Stage: tt.stage, Status: tt.status}
+			item := createMovieAt(t, repo, tt.stage, job.StageStatus())
+			if err := repo.SetMediaItemAutopilot(ctx, item.ID, tt.autopilot); err != nil {
+				t.Fatalf("SetMediaItemAutopilot() error = %v", err)
+			}
+			job.MediaItemID = item.ID
+			next, err := Advance(ctx, repo, job)
+			if err != nil {
+				t.Fatalf("Advance() error = %v", err)
+			}
+			if (next != nil) != tt.wantQueued {
+				t.Fatalf("Advance() queued = %v, want %v", next != nil, tt.wantQueued)
+			}
+			if next == nil {
+				return
+			}
+			if next.Stage != tt.stage.NextStage() || next.Status != model.JobStatusPending {
+				t.Errorf("queued %s/%s, want %s/pending", next.Stage, next.Status, tt.stage.NextStage())
+			}
+		})
+	}
+}
+
+func TestAdvance_SeasonAutopilot(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, season := createSeasonAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	job := &model.Job{MediaItemID: item.ID, SeasonID: &season.ID, Stage: model.StageRemux, Status: model.JobStatusCompleted}
+
+	// Neither the show nor the season has autopilot
+	if next, _ := Advance(ctx, repo, job); next != nil {
+		t.Fatal("Advance() queued a job without autopilot")
+	}
+
+	if err := repo.SetSeasonAutopilot(ctx, season.ID, true); err != nil {
+		t.Fatalf("SetSeasonAutopilot() error = %v", err)
+	}
+	next, err := Advance(ctx, repo, job)
+	if err != nil {
+		t.Fatalf("Advance() error = %v", err)
+	}
+	if next == nil || next.SeasonID == nil || *next.SeasonID != season.ID {
+		t.Fatalf("Advance() = %+v, want transcode job for the season", next)
+	}
+
+	got, _ := repo.GetSeason(ctx, season.ID)
+	if got.CurrentStage != model.StageTranscode || got.StageStatus != model.StatusInProgress {
+		t.Errorf("season = %s/%s, want transcode/in_progress", got.CurrentStage, got.StageStatus)
+	}
+}
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Cancel stops a job.
+// Pending jobs are cancelled directly. A job whose stage process has not
+// recorded a PID yet is left a cancel request, which the daemon running it
+// acts on. Other running jobs are sent SIGTERM, on this host, through the
+// agent running them, or over SSH to their worker; the stage binary then
+// records the cancelled outcome itself.
+func Cancel(ctx context.Context, repo db.Repository, cfg *config.Config, job *model.Job) error {
+	if job.Status == model.JobStatusPending {
+		outcome := *job
+		outcome.Status = model.JobStatusCancelled
+		var cancelled bool
+		err := repo.WithTx(ctx, func(tx db.Repository) error {
+			var err error
+			if cancelled, err = tx.CancelPendingJob(ctx, job.ID); err != nil || !cancelled {
+				return err
+			}
+			return RecordOutcome(ctx, tx, &outcome)
+		})
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			job.Status = model.JobStatusCancelled
+			return nil
+		}
+
+		// Claimed in the meantime - reload and signal it instead
+		current, err := repo.GetJob(ctx, job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reload job: %w", err)
+		}
+		if current == nil {
+			return fmt.Errorf("job %d not found", job.ID)
+		}
+		job = current
+	}
+
+	if job.Status != model.JobStatusInProgress {
+		return fmt.Errorf("job %d is %s, not running", job.ID, job.Status)
+	}
+	if job.PID <= 0 {
+		requested, err := repo.RequestJobCancel(ctx, job.ID)
+		if err != nil {
+			return err
+		}
+		if !requested {
+			return fmt.Errorf("job %d is no longer running", job.ID)
+		}
+		return nil
+	}
+
+	if job.WorkerID == LocalWorkerID() {
+		err := syscall.Kill(job.PID, syscall.SIGTERM)
+		if errors.Is(err, syscall.ESRCH) {
+			// The process is already gone, so nothing else will record the outcome
+			return Finish(ctx, repo, job, model.JobStatusCancelled, "cancelled")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to signal process %d: %w", job.PID, err)
+		}
+		return nil
+	}
+
+	// Jobs run by an agent have the agent's target as their worker
+	if cfg.Agent.Enabled {
+		return cancelOnAgent(ctx, cfg, job)
+	}
+
+	if job.WorkerID == "" {
+		return fmt.Errorf("job %d has no recorded worker to signal", job.ID)
+	}
+
+	cmd := exec.CommandContext(ctx, "ssh", job.WorkerID, "kill", "-TERM", strconv.Itoa(job.PID))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to signal process %d on %s: %w: %s", job.PID, job.WorkerID, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// cancelOnAgent asks the agent running a job to stop it
+func cancelOnAgent(ctx context.Context, cfg *config.Config, job *model.Job) error {
+	url := fmt.Sprintf("%s/jobs/%d/cancel", cfg.AgentURL(job.WorkerID), job.ID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach agent %s: %w", job.WorkerID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("agent %s could not cancel job %d: %s", job.WorkerID, job.ID, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestCancel_PendingJob(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	job, err := Enqueue(ctx, repo, item, nil, model.StageTranscode, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if err := Cancel(ctx, repo, &config.Config{}, job); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusCancelled {
+		t.Errorf("job status = %q, want cancelled", got.Status)
+	}
+
+	items, _ := repo.ListActiveItems(ctx)
+	if items[0].CurrentStage != model.StageTranscode || items[0].StageStatus != model.StatusPending {
+		t.Errorf("item = %s/%s, want transcode/pending", items[0].CurrentStage, items[0].StageStatus)
+	}
+}
+
+func TestCancel_StartingJob(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageOrganize, model.StatusCompleted)
+
+	job, _ := Enqueue(ctx, repo, item, nil, model.StageRemux, nil)
+	if claimed, err := repo.ClaimJob(ctx, job.ID, LocalWorkerID(), 0, 0); err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+
+	// The caller still holds the pending copy; Cancel must notice the claim
+	if err := Cancel(ctx, repo, &config.Config{}, job); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusInProgress || !got.CancelRequested {
+		t.Errorf("job = %s, cancel requested %v; want in_progress with a cancel request", got.Status, got.CancelRequested)
+	}
+}
+
+func TestCancel_SignalsLocalProcess(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+
+	job, _ := Enqueue(ctx, repo, item, nil, model.StageTranscode, nil)
+	if claimed, err := repo.ClaimJob(ctx, job.ID, LocalWorkerID(), cmd.Process.Pid, 0); err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+	job, _ = repo.GetJob(ctx, job.ID)
+
+	if err := Cancel(ctx, repo, &config.Config{}, job); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Wait() error = %v, want exit error", err)
+	}
+	status := exitErr.Sys().(syscall.WaitStatus)
+	if !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("process exit = %v, want killed by SIGTERM", status)
+	}
+}
+
+func TestCancel_RemoteJobWithoutWorker(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	job, _ := Enqueue(ctx, repo, item, nil, model.StageTranscode, nil)
+	if claimed, err := repo.ClaimJob(ctx, job.ID, "", 4242, 0); err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+	job, _ = repo.GetJob(ctx, job.ID)
+
+	if err := Cancel(ctx, repo, &config.Config{}, job); err == nil {
+		t.Error("Cancel() error = nil, want missing worker error")
+	}
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusInProgress {
+		t.Errorf("job status = %q, want in_progress (untouched)", got.Status)
+	}
+}
+
+func TestCancel_ReroutedAgentJob(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	var cancelled string
+	spare := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancelled = r.URL.Path
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer spare.Close()
+
+	// Transcode is configured for another host, but was rerouted to spare
+	cfg := &config.Config{
+		Dispatch: map[string]string{"transcode": "transcoder.invalid:7300"},
+		Agent:    config.AgentConfig{Enabled: true},
+	}
+	job, _ := Enqueue(ctx, repo, item, nil, model.StageTranscode, nil)
+	spareTarget := strings.TrimPrefix(spare.URL, "http://")
+	if claimed, err := repo.ClaimJob(ctx, job.ID, spareTarget, 4242, 0); err != nil || !claimed {
+		t.Fatalf("ClaimJob() = %v, %v", claimed, err)
+	}
+	job, _ = repo.GetJob(ctx, job.ID)
+
+	if err := Cancel(ctx, repo, cfg, job); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if want := fmt.Sprintf("/jobs/%d/cancel", job.ID); cancelled != want {
+		t.Errorf("spare agent got %q, want %q", cancelled, want)
+	}
+}
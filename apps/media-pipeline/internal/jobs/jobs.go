@@ -0,0 +1,144 @@
+// Package jobs holds the job lifecycle rules shared by the TUI and the
+// pipeline daemon: creating pending jobs and mirroring job outcomes onto
+// the owning media item or season.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Enqueue creates a pending job for a stage and marks the owner as in progress.
+// Rip only moves an owner that is still pending, so queueing another disc does
+// not reset a season's rip status.
+// season is nil for movies. disc is only set for TV rip jobs.
+// The job is executed later by the pipeline daemon.
+func Enqueue(ctx context.Context, repo db.Repository, item *model.MediaItem, season *model.Season, stage model.Stage, disc *int) (*model.Job, error) {
+	job := &model.Job{
+		MediaItemID: item.ID,
+		Stage:       stage,
+		Status:      model.JobStatusPending,
+		Disc:        disc,
+	}
+	if season != nil {
+		job.SeasonID = &season.ID
+	}
+
+	// A season that is already part-way through ripping stays in its current
+	// state when another disc is queued.
+	fromPendingOnly := stage == model.StageRip
+	if err := repo.EnqueueJob(ctx, job, fromPendingOnly); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Fail marks an active job as failed and mirrors the failure onto its owner.
+// Returns false, leaving job untouched, if the job had already finished; only
+// the caller that failed the job should retry it.
+func Fail(ctx context.Context, repo db.Repository, job *model.Job, kind model.FailureKind, errMsg string) (bool, error) {
+	failed := *job
+	failed.Status = model.JobStatusFailed
+	failed.FailureKind = kind
+	failed.ErrorMessage = errMsg
+
+	var ok bool
+	err := repo.WithTx(ctx, func(tx db.Repository) error {
+		var err error
+		if ok, err = tx.FailJob(ctx, job.ID, kind, errMsg); err != nil || !ok {
+			return err
+		}
+		return RecordOutcome(ctx, tx, &failed)
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+	*job = failed
+	return true, nil
+}
+
+// Finish records a job's final status and mirrors it onto its owner, together
+func Finish(ctx context.Context, repo db.Repository, job *model.Job, status model.JobStatus, errMsg string) error {
+	finished := *job
+	finished.Status = status
+	err := repo.WithTx(ctx, func(tx db.Repository) error {
+		if err := tx.UpdateJobStatus(ctx, job.ID, status, errMsg); err != nil {
+			return fmt.Errorf("failed to update job status: %w", err)
+		}
+		return RecordOutcome(ctx, tx, &finished)
+	})
+	if err != nil {
+		return err
+	}
+	job.Status = status
+	return nil
+}
+
+// RecordOutcome mirrors a finished job's status onto the owning item or season.
+// TV rip jobs are skipped: a season stays in the rip stage until the user
+// marks ripping done, regardless of how individual discs went.
+func RecordOutcome(ctx context.Context, repo db.Repository, job *model.Job) error {
+	if job.IsActive() {
+		return nil
+	}
+
+	status := job.StageStatus()
+
+	if job.SeasonID != nil {
+		if job.Stage == model.StageRip {
+			return nil
+		}
+		if err := repo.UpdateSeasonStage(ctx, *job.SeasonID, job.Stage, status); err != nil {
+			return fmt.Errorf("failed to update season stage: %w", err)
+		}
+		return nil
+	}
+
+	if err := repo.UpdateMediaItemStage(ctx, job.MediaItemID, job.Stage, status); err != nil {
+		return fmt.Errorf("failed to update item stage: %w", err)
+	}
+	return nil
+}
+
+// LocalWorkerID identifies this host in a job's worker_id
+func LocalWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	return host
+}
+
+// inputStages maps a stage to the stage whose output it reads
+var inputStages = map[model.Stage]model.Stage{
+	model.StageRemux:     model.StageOrganize,
+	model.StageTranscode: model.StageRemux,
+	model.StagePublish:   model.StageTranscode,
+}
+
+// InputDir finds the directory a job reads: the output of the most recent
+// completed job of the stage before it. Returns "" for stages without one.
+func InputDir(ctx context.Context, repo db.Repository, job *model.Job) (string, error) {
+	stage, ok := inputStages[job.Stage]
+	if !ok {
+		return "", nil
+	}
+
+	jobs, err := repo.ListJobsForMedia(ctx, job.MediaItemID)
+	if err != nil {
+		return "", err
+	}
+	for i := len(jobs) - 1; i >= 0; i-- {
+		j := jobs[i]
+		if j.Stage == stage && j.Status == model.JobStatusCompleted && j.OutputDir != "" {
+			return j.OutputDir, nil
+		}
+	}
+
+	return "", fmt.Errorf("no completed %s job found for media item %d", stage, job.MediaItemID)
+}
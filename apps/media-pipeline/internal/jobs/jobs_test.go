@@ -0,0 +1,329 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func newTestRepo(t *testing.T) *db.SQLiteRepository {
+	t.Helper()
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return db.NewSQLiteRepository(database)
+}
+
+// errInjected is returned by failingRepo
+var errInjected = errors.New("injected failure")
+
+// failingRepo fails one method, named by failOn, to interrupt a flow part
+// way through. The repository it passes into a transaction fails the same way.
+type failingRepo struct {
+	db.Repository
+	failOn string
+}
+
+func (f *failingRepo) WithTx(ctx context.Context, fn func(tx db.Repository) error) error {
+	return f.Repository.WithTx(ctx, func(tx db.Repository) error {
+		return fn(&failingRepo{Repository: tx, failOn: f.failOn})
+	})
+}
+
+func (f *failingRepo) UpdateMediaItemStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error {
+	if f.failOn == "UpdateMediaItemStage" {
+		return errInjected
+	}
+	return f.Repository.UpdateMediaItemStage(ctx, id, stage, status)
+}
+
+func (f *failingRepo) UpdateSeasonStage(ctx context.Context, id int64, stage model.Stage, status model.Status) error {
+	if f.failOn == "UpdateSeasonStage" {
+		return errInjected
+	}
+	return f.Repository.UpdateSeasonStage(ctx, id, stage, status)
+}
+
+func (f *failingRepo) EnqueueJob(ctx context.Context, job *model.Job, fromPendingOnly bool) error {
+	if f.failOn == "EnqueueJob" {
+		return errInjected
+	}
+	return f.Repository.EnqueueJob(ctx, job, fromPendingOnly)
+}
+
+func createMovie(t *testing.T, repo db.Repository) *model.MediaItem {
+	t.Helper()
+	return createMovieAt(t, repo, model.StageRip, model.StatusPending)
+}
+
+// createMovieAt creates a movie already at a stage, which the state machine
+// would only let it reach through the stages before
+func createMovieAt(t *testing.T, repo db.Repository, stage model.Stage, status model.Status) *model.MediaItem {
+	t.Helper()
+	item := &model.MediaItem{
+		Type:         model.MediaTypeMovie,
+		Name:         "The Matrix",
+		SafeName:     "The_Matrix",
+		CurrentStage: stage,
+		StageStatus:  status,
+	}
+	if err := repo.CreateMediaItem(context.Background(), item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	return item
+}
+
+func createSeason(t *testing.T, repo db.Repository) (*model.MediaItem, *model.Season) {
+	t.Helper()
+	return createSeasonAt(t, repo, model.StageOrganize, model.StatusCompleted)
+}
+
+func createSeasonAt(t *testing.T, repo db.Repository, stage model.Stage, status model.Status) (*model.MediaItem, *model.Season) {
+	t.Helper()
+	ctx := context.Background()
+	item := &model.MediaItem{
+		Type:     model.MediaTypeTV,
+		Name:     "Breaking Bad",
+		SafeName: "Breaking_Bad",
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	season := &model.Season{
+		ItemID:       item.ID,
+		Number:       1,
+		CurrentStage: stage,
+		StageStatus:  status,
+	}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason() error = %v", err)
+	}
+	return item, season
+}
+
+func TestEnqueue_Movie(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageOrganize, model.StatusCompleted)
+
+	job, err := Enqueue(ctx, repo, item, nil, model.StageRemux, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	got, err := repo.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if got.Status != model.JobStatusPending {
+		t.Errorf("job status = %q, want pending", got.Status)
+	}
+	if got.SeasonID != nil {
+		t.Errorf("job SeasonID = %v, want nil", *got.SeasonID)
+	}
+
+	items, err := repo.ListActiveItems(ctx)
+	if err != nil {
+		t.Fatalf("ListActiveItems() error = %v", err)
+	}
+	if items[0].CurrentStage != model.StageRemux || items[0].StageStatus != model.StatusInProgress {
+		t.Errorf("item = %s/%s, want remux/in_progress", items[0].CurrentStage, items[0].StageStatus)
+	}
+}
+
+func TestEnqueue_Season(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, season := createSeason(t, repo)
+
+	job, err := Enqueue(ctx, repo, item, season, model.StageRemux, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if job.SeasonID == nil || *job.SeasonID != season.ID {
+		t.Errorf("job SeasonID = %v, want %d", job.SeasonID, season.ID)
+	}
+
+	got, err := repo.GetSeason(ctx, season.ID)
+	if err != nil {
+		t.Fatalf("GetSeason() error = %v", err)
+	}
+	if got.CurrentStage != model.StageRemux || got.StageStatus != model.StatusInProgress {
+		t.Errorf("season = %s/%s, want remux/in_progress", got.CurrentStage, got.StageStatus)
+	}
+}
+
+func TestEnqueue_RipOnlyStartsPendingOwner(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     model.Status
+		wantStatus model.Status
+	}{
+		{"pending season starts ripping", model.StatusPending, model.StatusInProgress},
+		{"completed discs are kept", model.StatusCompleted, model.StatusCompleted},
+		{"failed discs are kept", model.StatusFailed, model.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			ctx := context.Background()
+			item, season := createSeasonAt(t, repo, model.StageRip, tt.status)
+
+			disc := 2
+			if _, err := Enqueue(ctx, repo, item, season, model.StageRip, &disc); err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+
+			got, err := repo.GetSeason(ctx, season.ID)
+			if err != nil {
+				t.Fatalf("GetSeason() error = %v", err)
+			}
+			if got.CurrentStage != model.StageRip || got.StageStatus != tt.wantStatus {
+				t.Errorf("season = %s/%s, want rip/%s", got.CurrentStage, got.StageStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestFail_UpdatesJobAndOwner(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, season := createSeasonAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	job, err := Enqueue(ctx, repo, item, season, model.StageTranscode, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if failed, err := Fail(ctx, repo, job, model.FailureKindStage, "ffmpeg exploded"); err != nil || !failed {
+		t.Fatalf("Fail() = %v, %v", failed, err)
+	}
+
+	gotJob, _ := repo.GetJob(ctx, job.ID)
+	if gotJob.Status != model.JobStatusFailed || gotJob.ErrorMessage != "ffmpeg exploded" {
+		t.Errorf("job = %s %q, want failed with message", gotJob.Status, gotJob.ErrorMessage)
+	}
+	if gotJob.FailureKind != model.FailureKindStage {
+		t.Errorf("FailureKind = %q, want %q", gotJob.FailureKind, model.FailureKindStage)
+	}
+
+	gotSeason, _ := repo.GetSeason(ctx, season.ID)
+	if gotSeason.StageStatus != model.StatusFailed {
+		t.Errorf("season status = %q, want failed", gotSeason.StageStatus)
+	}
+}
+
+func TestFail_FinishedJob(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	job, err := Enqueue(ctx, repo, item, nil, model.StageTranscode, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := repo.UpdateJobStatus(ctx, job.ID, model.JobStatusCompleted, ""); err != nil {
+		t.Fatalf("UpdateJobStatus() error = %v", err)
+	}
+
+	failed, err := Fail(ctx, repo, job, model.FailureKindVanished, "worker vanished")
+	if err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	if failed {
+		t.Error("Fail() = true for a completed job, want false")
+	}
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusCompleted {
+		t.Errorf("job status = %q, want completed", got.Status)
+	}
+}
+
+func TestRecordOutcome_SkipsTVRipJobs(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, season := createSeason(t, repo)
+	if err := repo.UpdateSeasonStage(ctx, season.ID, model.StageRip, model.StatusInProgress); err != nil {
+		t.Fatalf("UpdateSeasonStage() error = %v", err)
+	}
+
+	disc := 1
+	job := &model.Job{
+		MediaItemID: item.ID,
+		SeasonID:    &season.ID,
+		Stage:       model.StageRip,
+		Status:      model.JobStatusCompleted,
+		Disc:        &disc,
+	}
+	if err := RecordOutcome(ctx, repo, job); err != nil {
+		t.Fatalf("RecordOutcome() error = %v", err)
+	}
+
+	got, _ := repo.GetSeason(ctx, season.ID)
+	if got.StageStatus != model.StatusInProgress {
+		t.Errorf("season status = %q, want in_progress (multi-disc rip continues)", got.StageStatus)
+	}
+}
+
+func TestInputDir(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovie(t, repo)
+
+	for _, j := range []*model.Job{
+		{MediaItemID: item.ID, Stage: model.StageRemux, Status: model.JobStatusCompleted, OutputDir: "/staging/2-remuxed/old"},
+		{MediaItemID: item.ID, Stage: model.StageRemux, Status: model.JobStatusCompleted, OutputDir: "/staging/2-remuxed/new"},
+		{MediaItemID: item.ID, Stage: model.StageRemux, Status: model.JobStatusFailed, OutputDir: "/staging/2-remuxed/failed"},
+	} {
+		if err := repo.CreateJob(ctx, j); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+	}
+
+	dir, err := InputDir(ctx, repo, &model.Job{MediaItemID: item.ID, Stage: model.StageTranscode})
+	if err != nil {
+		t.Fatalf("InputDir() error = %v", err)
+	}
+	if dir != "/staging/2-remuxed/new" {
+		t.Errorf("InputDir() = %q, want the latest completed remux output", dir)
+	}
+
+	if _, err := InputDir(ctx, repo, &model.Job{MediaItemID: item.ID, Stage: model.StagePublish}); err == nil {
+		t.Error("InputDir() for publish without a transcode = nil error, want error")
+	}
+
+	if dir, err := InputDir(ctx, repo, &model.Job{MediaItemID: item.ID, Stage: model.StageRip}); err != nil || dir != "" {
+		t.Errorf("InputDir() for rip = %q, %v, want no input", dir, err)
+	}
+}
+
+func TestFail_RollsBackWhenOwnerUpdateFails(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageOrganize, model.StatusCompleted)
+
+	job, err := Enqueue(ctx, repo, item, nil, model.StageRemux, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	failing := &failingRepo{Repository: repo, failOn: "UpdateMediaItemStage"}
+	if _, err := Fail(ctx, failing, job, model.FailureKindStage, "mkvmerge failed"); !errors.Is(err, errInjected) {
+		t.Fatalf("Fail() error = %v, want injected failure", err)
+	}
+	if job.Status != model.JobStatusPending {
+		t.Errorf("job status = %s after a failed Fail(), want pending", job.Status)
+	}
+
+	got, _ := repo.GetJob(ctx, job.ID)
+	if got.Status != model.JobStatusPending {
+		t.Errorf("stored job status = %s, want pending", got.Status)
+	}
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// LogEvents returns a logging.Options.EventFn that records a job's
+// significant log lines in log_events, where the TUI shows them. Lines that
+// can't be recorded are dropped: they are in the job's log file either way.
+func LogEvents(ctx context.Context, repo db.Repository, jobID int64) func(level, msg string) {
+	return func(level, msg string) {
+		event := &model.LogEvent{JobID: jobID, Level: eventLevel(level), Message: msg}
+		_ = repo.CreateLogEvent(ctx, event)
+	}
+}
+
+// eventLevel maps a logging level name to the levels log_events keeps
+func eventLevel(level string) string {
+	switch level {
+	case "WARN":
+		return "warn"
+	case "ERROR":
+		return "error"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/logging"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestLogEvents(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	item := createMovieAt(t, repo, model.StageRemux, model.StatusInProgress)
+	job := &model.Job{MediaItemID: item.ID, Stage: model.StageRemux, Status: model.JobStatusInProgress}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	logger := logging.New(logging.Options{MinLevel: logging.LevelInfo, EventFn: LogEvents(ctx, repo, job.ID)})
+	logger.Event(logging.LevelInfo, "Starting remux")
+	logger.Info("Input directory: /tmp/in")
+	logger.Warn("Failed to record progress")
+	logger.Error("Remux failed: exit status 2")
+
+	events, err := repo.ListLogEvents(ctx, job.ID, 0)
+	if err != nil {
+		t.Fatalf("ListLogEvents() error = %v", err)
+	}
+
+	want := []string{"info Starting remux", "warn Failed to record progress", "error Remux failed: exit status 2"}
+	if len(events) != len(want) {
+		t.Fatalf("ListLogEvents() = %+v, want %v", events, want)
+	}
+	got := make(map[string]bool)
+	for _, event := range events {
+		got[event.Level+" "+event.Message] = true
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("events missing %q: %+v", w, events)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// MaxBackoff caps the delay between two attempts
+const MaxBackoff = time.Hour
+
+// RetryPolicy decides whether and when a failed job is attempted again
+type RetryPolicy struct {
+	MaxAttempts int // Total attempts including the first
+	Backoff     time.Duration
+	Retryable   []model.FailureKind
+}
+
+// RetryPolicies maps a stage to its retry policy.
+// Stages without an entry are never retried.
+type RetryPolicies map[model.Stage]RetryPolicy
+
+// RetryPoliciesFromConfig builds retry policies from the retry section of the config
+func RetryPoliciesFromConfig(cfg *config.Config) RetryPolicies {
+	policies := make(RetryPolicies)
+	for _, stage := range []model.Stage{model.StageRip, model.StageRemux, model.StageTranscode, model.StagePublish} {
+		c := cfg.RetryPolicy(stage.String())
+		policy := RetryPolicy{MaxAttempts: c.MaxAttempts, Backoff: c.Backoff}
+		for _, kind := range c.Retryable {
+			policy.Retryable = append(policy.Retryable, model.FailureKind(kind))
+		}
+		policies[stage] = policy
+	}
+	return policies
+}
+
+// ShouldRetry reports whether a failed job gets another attempt
+func (p RetryPolicy) ShouldRetry(job *model.Job) bool {
+	if job.Status != model.JobStatusFailed || job.Attempt >= p.MaxAttempts {
+		return false
+	}
+	for _, kind := range p.Retryable {
+		if kind == job.FailureKind {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns how long to wait after the given attempt failed:
+// Backoff after the first, doubling each time, capped at MaxBackoff
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > MaxBackoff {
+		delay = MaxBackoff
+	}
+	return delay
+}
+
+// Retry queues the next attempt of a failed job if its stage's policy allows it.
+// The new job belongs to the same run and is not dispatched before the backoff
+// has passed. Returns nil if no retry was queued.
+func Retry(ctx context.Context, repo db.Repository, policies RetryPolicies, job *model.Job, now time.Time) (*model.Job, error) {
+	policy, ok := policies[job.Stage]
+	if !ok || !policy.ShouldRetry(job) {
+		return nil, nil
+	}
+
+	notBefore := now.Add(policy.Delay(job.Attempt)).UTC().Truncate(time.Second)
+	return queueAttempt(ctx, repo, job, &notBefore)
+}
+
+// RetryNow queues another attempt of a failed or cancelled job straight away,
+// regardless of its stage's retry policy. The attempt belongs to the same run.
+func RetryNow(ctx context.Context, repo db.Repository, job *model.Job) (*model.Job, error) {
+	if job.Status != model.JobStatusFailed && job.Status != model.JobStatusCancelled {
+		return nil, fmt.Errorf("job %d is %s: only failed or cancelled jobs can be retried", job.ID, job.Status)
+	}
+	return queueAttempt(ctx, repo, job, nil)
+}
+
+// queueAttempt queues the attempt after job, not dispatched before notBefore if set
+func queueAttempt(ctx context.Context, repo db.Repository, job *model.Job, notBefore *time.Time) (*model.Job, error) {
+	next := &model.Job{
+		MediaItemID: job.MediaItemID,
+		SeasonID:    job.SeasonID,
+		Stage:       job.Stage,
+		Status:      model.JobStatusPending,
+		Disc:        job.Disc,
+		Priority:    job.Priority,
+		Attempt:     job.Attempt + 1,
+		RunID:       job.RunID,
+		NotBefore:   notBefore,
+	}
+	if next.RunID == 0 {
+		next.RunID = job.ID
+	}
+	err := repo.WithTx(ctx, func(tx db.Repository) error {
+		if err := tx.CreateJob(ctx, next); err != nil {
+			return fmt.Errorf("failed to create retry job: %w", err)
+		}
+
+		options, err := tx.GetJobOptions(ctx, job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get job options: %w", err)
+		}
+		if len(options) > 0 {
+			if err := tx.SetJobOptions(ctx, next.ID, options); err != nil {
+				return fmt.Errorf("failed to copy job options: %w", err)
+			}
+		}
+
+		// The stage is queued again; TV rip jobs never touch the season (see RecordOutcome)
+		if job.SeasonID != nil {
+			if job.Stage != model.StageRip {
+				if err := tx.UpdateSeasonStage(ctx, *job.SeasonID, job.Stage, model.StatusInProgress); err != nil {
+					return fmt.Errorf("failed to update season stage: %w", err)
+				}
+			}
+			return nil
+		}
+		if err := tx.UpdateMediaItemStage(ctx, job.MediaItemID, job.Stage, model.StatusInProgress); err != nil {
+			return fmt.Errorf("failed to update item stage: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		Retryable:   []model.FailureKind{model.FailureKindCrash, model.FailureKindVanished},
+	}
+
+	tests := []struct {
+		name string
+		job  model.Job
+		want bool
+	}{
+		{"retryable failure", model.Job{Status: model.JobStatusFailed, Attempt: 1, FailureKind: model.FailureKindCrash}, true},
+		{"second attempt", model.Job{Status: model.JobStatusFailed, Attempt: 2, FailureKind: model.FailureKindVanished}, true},
+		{"attempts exhausted", model.Job{Status: model.JobStatusFailed, Attempt: 3, FailureKind: model.FailureKindCrash}, false},
+		{"not retryable", model.Job{Status: model.JobStatusFailed, Attempt: 1, FailureKind: model.FailureKindStage}, false},
+		{"cancelled", model.Job{Status: model.JobStatusCancelled, Attempt: 1, FailureKind: model.FailureKindCrash}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.ShouldRetry(&tt.job); got != tt.want {
+				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{Backoff: 10 * time.Minute}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Minute},
+		{2, 20 * time.Minute},
+		{3, 40 * time.Minute},
+		{4, MaxBackoff},
+		{10, MaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetry_QueuesNextAttempt(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageRemux, model.StatusCompleted)
+
+	first, err := Enqueue(ctx, repo, item, nil, model.StageTranscode, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := repo.SetJobOptions(ctx, first.ID, map[string]interface{}{"mode": "hardware"}); err != nil {
+		t.Fatalf("SetJobOptions() error = %v", err)
+	}
+	if _, err := Fail(ctx, repo, first, model.FailureKindCrash, "killed"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+
+	policies := RetryPolicies{
+		model.StageTranscode: {MaxAttempts: 3, Backoff: time.Minute, Retryable: []model.FailureKind{model.FailureKindCrash}},
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	second, err := Retry(ctx, repo, policies, first, now)
+	if err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	if second == nil {
+		t.Fatal("Retry() queued nothing")
+	}
+
+	got, _ := repo.GetJob(ctx, second.ID)
+	if got.Status != model.JobStatusPending || got.Attempt != 2 || got.RunID != first.ID {
+		t.Errorf("retry = %s attempt %d run %d, want pending attempt 2 run %d", got.Status, got.Attempt, got.RunID, first.ID)
+	}
+	if got.NotBefore == nil || !got.NotBefore.Equal(now.Add(time.Minute)) {
+		t.Errorf("NotBefore = %v, want %v", got.NotBefore, now.Add(time.Minute))
+	}
+	opts, _ := repo.GetJobOptions(ctx, second.ID)
+	if opts["mode"] != "hardware" {
+		t.Errorf("options = %v, want copied from the failed attempt", opts)
+	}
+
+	items, _ := repo.ListActiveItems(ctx)
+	if len(items) != 1 || items[0].StageStatus != model.StatusInProgress {
+		t.Errorf("item status = %v, want in_progress while the retry is queued", items)
+	}
+
+	// The third attempt still belongs to the first attempt's run and waits twice as long
+	if _, err := Fail(ctx, repo, got, model.FailureKindCrash, "killed again"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	third, err := Retry(ctx, repo, policies, got, now)
+	if err != nil || third == nil {
+		t.Fatalf("Retry() = %v, %v", third, err)
+	}
+	if third.Attempt != 3 || third.RunID != first.ID || !third.NotBefore.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("third = attempt %d run %d not before %v", third.Attempt, third.RunID, third.NotBefore)
+	}
+
+	// Out of attempts
+	if _, err := Fail(ctx, repo, third, model.FailureKindCrash, "killed for good"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	fourth, err := Retry(ctx, repo, policies, third, now)
+	if err != nil || fourth != nil {
+		t.Errorf("Retry() after last attempt = %v, %v, want nil", fourth, err)
+	}
+}
+
+func TestRetry_NoPolicyForStage(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageOrganize, model.StatusCompleted)
+
+	job, _ := Enqueue(ctx, repo, item, nil, model.StageRemux, nil)
+	if _, err := Fail(ctx, repo, job, model.FailureKindCrash, "killed"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+
+	next, err := Retry(ctx, repo, nil, job, time.Now())
+	if err != nil || next != nil {
+		t.Errorf("Retry() = %v, %v, want nil", next, err)
+	}
+}
@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Start queues a stage for a movie, or a TV season when season is set.
+// A season's rip gets the disc after the last one queued. Organize is done
+// by hand and completed with CompleteOrganize instead.
+func Start(ctx context.Context, repo db.Repository, item *model.MediaItem, season *model.Season, stage model.Stage) (*model.Job, error) {
+	if stage == model.StageOrganize {
+		return nil, fmt.Errorf("organize is done by hand, not queued")
+	}
+	if item.Type == model.MediaTypeTV && season == nil {
+		return nil, fmt.Errorf("%s is a TV show: pick a season", item.Name)
+	}
+
+	// The disc is numbered in the same transaction, so two rips queued at
+	// once cannot get the same one
+	var job *model.Job
+	err := repo.WithTx(ctx, func(tx db.Repository) error {
+		var disc *int
+		if stage == model.StageRip && season != nil {
+			next, err := NextDisc(ctx, tx, item, season)
+			if err != nil {
+				return err
+			}
+			disc = &next
+		}
+
+		var err error
+		job, err = Enqueue(ctx, tx, item, season, stage, disc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// NextDisc returns the disc number after the last rip queued for a season
+func NextDisc(ctx context.Context, repo db.Repository, item *model.MediaItem, season *model.Season) (int, error) {
+	existing, err := repo.ListJobsForMedia(ctx, item.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	discNum := 1
+	for _, job := range existing {
+		if job.Stage == model.StageRip && job.SeasonID != nil && *job.SeasonID == season.ID {
+			if job.Disc != nil && *job.Disc >= discNum {
+				discNum = *job.Disc + 1
+			}
+		}
+	}
+	return discNum, nil
+}
+
+// MarkRipsDone completes the rip stage of a season once at least one of its
+// discs has been ripped
+func MarkRipsDone(ctx context.Context, repo db.Repository, item *model.MediaItem, season *model.Season) error {
+	existing, err := repo.ListJobsForMedia(ctx, item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	hasCompletedRip := false
+	for _, job := range existing {
+		if job.Stage == model.StageRip && job.SeasonID != nil && *job.SeasonID == season.ID {
+			if job.Status == model.JobStatusCompleted {
+				hasCompletedRip = true
+				break
+			}
+		}
+	}
+	if !hasCompletedRip {
+		return fmt.Errorf("no completed rip jobs for this season")
+	}
+
+	if err := repo.UpdateSeasonStage(ctx, season.ID, model.StageRip, model.StatusCompleted); err != nil {
+		return fmt.Errorf("failed to update season status: %w", err)
+	}
+	return nil
+}
+
+// RipOutput finds what is organized after ripping: the rip output directory
+// of a movie, or for a TV season the season directory and the disc
+// directories ripped into it
+func RipOutput(ctx context.Context, repo db.Repository, item *model.MediaItem, season *model.Season) (string, []string, error) {
+	existing, err := repo.ListJobsForMedia(ctx, item.ID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	if season == nil {
+		for _, job := range existing {
+			if job.Stage == model.StageRip && job.Status == model.JobStatusCompleted && job.OutputDir != "" {
+				return job.OutputDir, nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("could not find rip output for %s", item.Name)
+	}
+
+	var discPaths []string
+	for _, job := range existing {
+		if job.Stage == model.StageRip && job.Status == model.JobStatusCompleted {
+			if job.SeasonID != nil && *job.SeasonID == season.ID && job.OutputDir != "" {
+				discPaths = append(discPaths, job.OutputDir)
+			}
+		}
+	}
+	if len(discPaths) == 0 {
+		return "", nil, fmt.Errorf("no completed rip jobs found for %s Season %d", item.Name, season.Number)
+	}
+
+	// Season base path is the parent of the disc directories
+	return filepath.Dir(discPaths[0]), discPaths, nil
+}
+
+// CompleteOrganize records that the rip output at path has been organized by
+// hand, and with autopilot on queues remux straight away. Nothing is recorded
+// unless all of it is.
+func CompleteOrganize(ctx context.Context, repo db.Repository, item *model.MediaItem, season *model.Season, path string) (*model.Job, error) {
+	now := time.Now()
+	job := &model.Job{
+		MediaItemID: item.ID,
+		Stage:       model.StageOrganize,
+		Status:      model.JobStatusCompleted,
+		OutputDir:   path,
+		StartedAt:   &now,
+		CompletedAt: &now,
+	}
+	if season != nil {
+		job.SeasonID = &season.ID
+	}
+
+	err := repo.WithTx(ctx, func(tx db.Repository) error {
+		if err := tx.CreateJob(ctx, job); err != nil {
+			return err
+		}
+
+		if season != nil {
+			if err := tx.UpdateSeasonStage(ctx, season.ID, model.StageOrganize, model.StatusCompleted); err != nil {
+				return fmt.Errorf("failed to update season stage: %w", err)
+			}
+		} else {
+			if err := tx.UpdateMediaItemStage(ctx, item.ID, model.StageOrganize, model.StatusCompleted); err != nil {
+				return fmt.Errorf("failed to update item stage: %w", err)
+			}
+		}
+
+		_, err := Advance(ctx, tx, job)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
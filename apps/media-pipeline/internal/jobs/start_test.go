@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestStart_SeasonRipTakesNextDisc(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item, season := createSeason(t, repo)
+
+	for want := 1; want <= 2; want++ {
+		job, err := Start(ctx, repo, item, season, model.StageRip)
+		if err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		if job.Disc == nil || *job.Disc != want {
+			t.Errorf("Start() disc = %v, want %d", job.Disc, want)
+		}
+	}
+}
+
+func TestStart_Rejects(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	movie := createMovie(t, repo)
+	show, _ := createSeason(t, repo)
+
+	tests := []struct {
+		name  string
+		item  *model.MediaItem
+		stage model.Stage
+	}{
+		{"organize is manual", movie, model.StageOrganize},
+		{"TV show without a season", show, model.StageRemux},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if job, err := Start(ctx, repo, tt.item, nil, tt.stage); err == nil {
+				t.Errorf("Start() = job %d, want error", job.ID)
+			}
+		})
+	}
+}
+
+func TestCompleteOrganize_AdvancesOnAutopilot(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageRip, model.StatusCompleted)
+	if err := repo.SetMediaItemAutopilot(ctx, item.ID, true); err != nil {
+		t.Fatalf("SetMediaItemAutopilot() error = %v", err)
+	}
+
+	job, err := CompleteOrganize(ctx, repo, item, nil, "/staging/1-ripped/movies/The_Matrix")
+	if err != nil {
+		t.Fatalf("CompleteOrganize() error = %v", err)
+	}
+	if job.Status != model.JobStatusCompleted || job.Stage != model.StageOrganize {
+		t.Errorf("CompleteOrganize() job = %s %s, want completed organize", job.Stage, job.Status)
+	}
+
+	history, _ := repo.ListJobsForMedia(ctx, item.ID)
+	if len(history) != 2 || history[1].Stage != model.StageRemux || history[1].Status != model.JobStatusPending {
+		t.Errorf("jobs after CompleteOrganize = %+v, want organize then pending remux", history)
+	}
+}
+
+func TestCompleteOrganize_RollsBack(t *testing.T) {
+	tests := []struct {
+		name   string
+		failOn string
+	}{
+		{"stage update fails", "UpdateSeasonStage"},
+		{"queueing remux fails", "EnqueueJob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			ctx := context.Background()
+			item, season := createSeasonAt(t, repo, model.StageRip, model.StatusCompleted)
+			if err := repo.SetSeasonAutopilot(ctx, season.ID, true); err != nil {
+				t.Fatalf("SetSeasonAutopilot() error = %v", err)
+			}
+
+			failing := &failingRepo{Repository: repo, failOn: tt.failOn}
+			if _, err := CompleteOrganize(ctx, failing, item, season, "/staging/1-ripped/tv/Breaking_Bad/S01"); !errors.Is(err, errInjected) {
+				t.Fatalf("CompleteOrganize() error = %v, want injected failure", err)
+			}
+
+			if history, _ := repo.ListJobsForMedia(ctx, item.ID); len(history) != 0 {
+				t.Errorf("jobs = %+v, want none", history)
+			}
+			got, _ := repo.GetSeason(ctx, season.ID)
+			if got.CurrentStage != model.StageRip || got.StageStatus != model.StatusCompleted {
+				t.Errorf("season = %s/%s, want rip/completed", got.CurrentStage, got.StageStatus)
+			}
+		})
+	}
+}
+
+func TestRetryNow(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageOrganize, model.StatusCompleted)
+
+	job, err := Enqueue(ctx, repo, item, nil, model.StageRemux, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if _, err := RetryNow(ctx, repo, job); err == nil {
+		t.Error("RetryNow() on a pending job succeeded, want error")
+	}
+
+	if _, err := Fail(ctx, repo, job, model.FailureKindStage, "mkvmerge failed"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	next, err := RetryNow(ctx, repo, job)
+	if err != nil {
+		t.Fatalf("RetryNow() error = %v", err)
+	}
+	if next.Status != model.JobStatusPending || next.Attempt != job.Attempt+1 || next.RunID != job.ID || next.NotBefore != nil {
+		t.Errorf("RetryNow() = %+v, want an immediate pending attempt %d of run %d", next, job.Attempt+1, job.ID)
+	}
+
+	got, _ := repo.GetMediaItem(ctx, item.ID)
+	if got.StageStatus != model.StatusInProgress {
+		t.Errorf("item stage status = %q, want in_progress", got.StageStatus)
+	}
+}
+
+func TestRetryNow_RollsBackWhenOwnerUpdateFails(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	item := createMovieAt(t, repo, model.StageOrganize, model.StatusCompleted)
+
+	job, err := Enqueue(ctx, repo, item, nil, model.StageRemux, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if _, err := Fail(ctx, repo, job, model.FailureKindStage, "mkvmerge failed"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+
+	failing := &failingRepo{Repository: repo, failOn: "UpdateMediaItemStage"}
+	if _, err := RetryNow(ctx, failing, job); !errors.Is(err, errInjected) {
+		t.Fatalf("RetryNow() error = %v, want injected failure", err)
+	}
+
+	if history, _ := repo.ListJobsForMedia(ctx, item.ID); len(history) != 1 {
+		t.Errorf("got %d jobs, want only the failed one", len(history))
+	}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/logging"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// ToolLogs returns a logging.ToolLogOpener that writes each external tool's
+// output to its own size-capped file in the job's log directory, and links
+// the file to the job so the TUI can open it. A file that can't be linked is
+// still written.
+func ToolLogs(ctx context.Context, repo db.Repository, cfg *config.Config, jobID int64) logging.ToolLogOpener {
+	return func(tool string) (io.WriteCloser, error) {
+		tool = toolLogName(tool)
+		path := cfg.ToolLogPath(jobID, tool)
+		f, err := logging.OpenRotating(path, logging.ToolLogMaxSize, logging.ToolLogBackups)
+		if err != nil {
+			return nil, err
+		}
+		_ = repo.AddToolLog(ctx, &model.ToolLog{JobID: jobID, Tool: tool, Path: path})
+		return f, nil
+	}
+}
+
+// toolLogName makes a tool log name safe to use as a file name, e.g. for
+// "ffmpeg-<file>" where the file is in a subdirectory
+func toolLogName(tool string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(tool)
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestToolLogs(t *testing.T) {
+	t.Setenv("MEDIA_BASE", t.TempDir())
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	cfg := &config.Config{}
+
+	item := createMovieAt(t, repo, model.StageTranscode, model.StatusInProgress)
+	job := &model.Job{MediaItemID: item.ID, Stage: model.StageTranscode, Status: model.JobStatusInProgress}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	open := ToolLogs(ctx, repo, cfg, job.ID)
+	for _, tool := range []string{"ffmpeg-extras/Trailer", "ffmpeg-extras/Trailer"} {
+		w, err := open(tool)
+		if err != nil {
+			t.Fatalf("open(%q) error = %v", tool, err)
+		}
+		w.Write([]byte("frame=1\n"))
+		w.Close()
+	}
+
+	toolLogs, err := repo.ListToolLogs(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("ListToolLogs() error = %v", err)
+	}
+	wantPath := filepath.Join(cfg.JobLogDir(job.ID), "ffmpeg-extras_Trailer.log")
+	if len(toolLogs) != 1 || toolLogs[0].Tool != "ffmpeg-extras_Trailer" || toolLogs[0].Path != wantPath {
+		t.Fatalf("ListToolLogs() = %+v, want one link to %s", toolLogs, wantPath)
+	}
+
+	data, err := os.ReadFile(wantPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "frame=1\nframe=1\n" {
+		t.Errorf("tool log = %q, want both writes", data)
+	}
+}
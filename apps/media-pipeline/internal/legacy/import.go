@@ -0,0 +1,321 @@
+package legacy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/items"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Action is what importing a state directory did, or would do on a dry run
+type Action string
+
+const (
+	ActionImported Action = "imported" // Recorded as a job
+	ActionExists   Action = "exists"   // The item already has a job for this stage (and disc)
+	ActionConflict Action = "conflict" // The name is taken by an item of the other type
+)
+
+// Result reports what happened to one state directory
+type Result struct {
+	State       StateDir
+	Action      Action
+	ItemID      int64 // 0 for an item a dry run would create
+	JobID       int64 // 0 on a dry run
+	CreatedItem bool  // The item, or the season of a TV show, was created
+	Reason      string
+}
+
+// owner tracks an item, or a season of a TV show, across the import: the
+// stages it already has jobs for, and the furthest stage imported
+type owner struct {
+	item    *model.MediaItem
+	season  *model.Season
+	jobs    map[string]bool // Keys from jobKey
+	stage   model.Stage
+	status  model.Status
+	touched bool
+}
+
+// Import records each state directory as a completed or failed job of its
+// item, creating the item and season where needed, and moves items to the
+// furthest stage imported. State directories whose job is already recorded
+// are skipped, so the import can be run again. With dryRun nothing is written.
+func Import(ctx context.Context, repo db.Repository, states []StateDir, dryRun bool) ([]Result, error) {
+	imp := &importer{
+		repo:   repo,
+		dryRun: dryRun,
+		items:  make(map[string]*model.MediaItem),
+		owners: make(map[string]*owner),
+	}
+
+	results := make([]Result, 0, len(states))
+	for _, state := range states {
+		result, err := imp.importState(ctx, state)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", state.Path, err)
+		}
+		results = append(results, result)
+	}
+
+	if !dryRun {
+		if err := imp.updateStages(ctx); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+type importer struct {
+	repo   db.Repository
+	dryRun bool
+	items  map[string]*model.MediaItem // By safe name; ID 0 on a dry run
+	owners map[string]*owner           // By safe name and season
+	order  []*owner
+}
+
+func (imp *importer) importState(ctx context.Context, state StateDir) (Result, error) {
+	result := Result{State: state}
+
+	item, created, err := imp.findItem(ctx, state)
+	if err != nil {
+		return result, err
+	}
+	result.ItemID = item.ID
+	result.CreatedItem = created
+	if item.Type != state.Type {
+		result.Action = ActionConflict
+		result.Reason = fmt.Sprintf("%s is already a %s", item.SafeName, item.Type)
+		return result, nil
+	}
+
+	o, created, err := imp.findOwner(ctx, item, state)
+	if err != nil {
+		return result, err
+	}
+	result.CreatedItem = result.CreatedItem || created
+
+	key := jobKey(state.Stage, state.Disc)
+	if o.jobs[key] {
+		result.Action = ActionExists
+		result.Reason = fmt.Sprintf("%s already has a %s job", item.Name, state.Stage)
+		return result, nil
+	}
+	o.jobs[key] = true
+	result.Action = ActionImported
+
+	if !o.touched || state.Stage > o.stage {
+		o.stage, o.status = state.Stage, model.StatusCompleted
+	}
+	o.touched = true
+	if state.Stage == o.stage && state.Status == model.JobStatusFailed {
+		o.status = model.StatusFailed
+	}
+
+	if imp.dryRun {
+		return result, nil
+	}
+
+	job := &model.Job{
+		MediaItemID:  item.ID,
+		Stage:        state.Stage,
+		Status:       state.Status,
+		Disc:         state.Disc,
+		InputDir:     state.InputDir,
+		OutputDir:    state.OutputDir,
+		LogPath:      state.LogPath,
+		ErrorMessage: state.Error,
+		StartedAt:    state.StartedAt,
+		CompletedAt:  state.EndedAt,
+	}
+	if o.season != nil {
+		job.SeasonID = &o.season.ID
+	}
+	if state.Status == model.JobStatusCompleted {
+		job.Progress = 100
+	} else {
+		job.FailureKind = model.FailureKindStage
+		if state.EndedAt == nil {
+			job.FailureKind = model.FailureKindCrash
+		}
+	}
+	if state.StartedAt != nil {
+		job.CreatedAt = *state.StartedAt
+	}
+	if err := imp.repo.CreateJob(ctx, job); err != nil {
+		return result, err
+	}
+	result.JobID = job.ID
+	return result, nil
+}
+
+// findItem returns the item a state directory belongs to, creating it if
+// needed. The scripts stripped punctuation from safe names, so items created
+// since are also matched by the safe name of their display name.
+func (imp *importer) findItem(ctx context.Context, state StateDir) (*model.MediaItem, bool, error) {
+	if item, ok := imp.items[state.SafeName]; ok {
+		return item, false, nil
+	}
+
+	for _, safeName := range []string{state.SafeName, items.SafeName(state.Name)} {
+		named, err := imp.repo.GetMediaItemBySafeName(ctx, safeName, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		if named == nil {
+			continue
+		}
+		// Only the ID lookup loads the item's stage and status
+		item, err := imp.repo.GetMediaItem(ctx, named.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		imp.items[state.SafeName] = item
+		return item, false, nil
+	}
+
+	item := &model.MediaItem{
+		Type:       state.Type,
+		Name:       state.Name,
+		SafeName:   state.SafeName,
+		ItemStatus: model.ItemStatusActive,
+	}
+	if state.Type == model.MediaTypeMovie {
+		item.CurrentStage = model.StageRip
+		item.StageStatus = model.StatusPending
+	}
+	if !imp.dryRun {
+		if err := imp.repo.CreateMediaItem(ctx, item); err != nil {
+			return nil, false, err
+		}
+	}
+	imp.items[state.SafeName] = item
+	return item, true, nil
+}
+
+// findOwner returns the movie, or the TV season, a state directory's job
+// belongs to, creating the season if needed
+func (imp *importer) findOwner(ctx context.Context, item *model.MediaItem, state StateDir) (*owner, bool, error) {
+	key := item.SafeName
+	if state.Season != nil {
+		key = fmt.Sprintf("%s/%d", item.SafeName, *state.Season)
+	}
+	if o, ok := imp.owners[key]; ok {
+		return o, false, nil
+	}
+
+	o := &owner{item: item, jobs: make(map[string]bool)}
+	created := false
+	var seasonID *int64
+
+	if state.Season != nil {
+		var seasons []model.Season
+		if item.ID != 0 {
+			var err error
+			if seasons, err = imp.repo.ListSeasonsForItem(ctx, item.ID); err != nil {
+				return nil, false, err
+			}
+		}
+		for i := range seasons {
+			if seasons[i].Number == *state.Season {
+				o.season = &seasons[i]
+			}
+		}
+		if o.season == nil {
+			o.season = &model.Season{
+				ItemID:       item.ID,
+				Number:       *state.Season,
+				CurrentStage: model.StageRip,
+				StageStatus:  model.StatusPending,
+			}
+			created = true
+			if !imp.dryRun {
+				if err := imp.repo.CreateSeason(ctx, o.season); err != nil {
+					return nil, false, fmt.Errorf("failed to create season %d: %w", *state.Season, err)
+				}
+			}
+		}
+		seasonID = &o.season.ID
+	}
+
+	if item.ID != 0 && (seasonID == nil || *seasonID != 0) {
+		existing, err := imp.repo.ListJobsForMedia(ctx, item.ID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		for _, job := range existing {
+			if sameOwner(job.SeasonID, seasonID) {
+				o.jobs[jobKey(job.Stage, job.Disc)] = true
+			}
+		}
+	}
+
+	imp.owners[key] = o
+	imp.order = append(imp.order, o)
+	return o, created, nil
+}
+
+// updateStages moves each item or season that got jobs to the furthest stage
+// imported, unless it has moved past it since
+func (imp *importer) updateStages(ctx context.Context) error {
+	for _, o := range imp.order {
+		if !o.touched {
+			continue
+		}
+
+		current, status := o.item.CurrentStage, o.item.StageStatus
+		if o.season != nil {
+			current, status = o.season.CurrentStage, o.season.StageStatus
+		}
+		if current > o.stage || (current == o.stage && status == model.StatusCompleted) {
+			continue
+		}
+
+		// The scripts ran the stages in between, so they are replayed as
+		// legal moves rather than jumped over
+		path, err := model.StagePath(model.StageState{Stage: current, Status: status}, model.StageState{Stage: o.stage, Status: o.status})
+		if err != nil {
+			return err
+		}
+		for _, step := range path {
+			if o.season != nil {
+				if err := imp.repo.UpdateSeasonStage(ctx, o.season.ID, step.Stage, step.Status); err != nil {
+					return fmt.Errorf("failed to update season stage: %w", err)
+				}
+			} else {
+				if err := imp.repo.UpdateMediaItemStage(ctx, o.item.ID, step.Stage, step.Status); err != nil {
+					return fmt.Errorf("failed to update item stage: %w", err)
+				}
+			}
+		}
+
+		itemStatus := model.ItemStatusActive
+		if o.season == nil && o.stage == model.StagePublish && o.status == model.StatusCompleted {
+			itemStatus = model.ItemStatusCompleted
+		}
+		if o.item.ItemStatus != itemStatus {
+			if err := imp.repo.UpdateMediaItemStatus(ctx, o.item.ID, itemStatus); err != nil {
+				return fmt.Errorf("failed to update item status: %w", err)
+			}
+			o.item.ItemStatus = itemStatus
+		}
+	}
+	return nil
+}
+
+// jobKey identifies the job of a stage, and disc for TV rips
+func jobKey(stage model.Stage, disc *int) string {
+	if disc == nil {
+		return stage.String()
+	}
+	return fmt.Sprintf("%s/%d", stage, *disc)
+}
+
+func sameOwner(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
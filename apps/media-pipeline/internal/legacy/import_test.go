@@ -0,0 +1,127 @@
+package legacy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/items"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func newTestRepo(t *testing.T) *db.SQLiteRepository {
+	t.Helper()
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return db.NewSQLiteRepository(database)
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestImport(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	started := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	ended := started.Add(time.Hour)
+	states := []StateDir{
+		{Path: "/s/1-ripped/movies/Alien/.rip", Stage: model.StageRip, Type: model.MediaTypeMovie, Name: "Alien", SafeName: "Alien",
+			OutputDir: "/s/1-ripped/movies/Alien", Status: model.JobStatusCompleted, LogPath: "/s/1-ripped/movies/Alien/.rip/rip.log",
+			StartedAt: &started, EndedAt: &ended},
+		{Path: "/s/2-remuxed/movies/Alien/.remux", Stage: model.StageRemux, Type: model.MediaTypeMovie, Name: "Alien", SafeName: "Alien",
+			Status: model.JobStatusFailed, Error: "Exit code: 1", StartedAt: &started},
+		{Path: "/s/1-ripped/tv/Lost/S01/Disc1/.rip", Stage: model.StageRip, Type: model.MediaTypeTV, Name: "Lost", SafeName: "Lost",
+			Season: intPtr(1), Disc: intPtr(1), Status: model.JobStatusCompleted},
+		{Path: "/s/1-ripped/tv/Lost/S01/Disc2/.rip", Stage: model.StageRip, Type: model.MediaTypeTV, Name: "Lost", SafeName: "Lost",
+			Season: intPtr(1), Disc: intPtr(2), Status: model.JobStatusCompleted},
+	}
+
+	// A dry run writes nothing
+	results, err := Import(ctx, repo, states, true)
+	if err != nil {
+		t.Fatalf("Import(dry run) error = %v", err)
+	}
+	for _, r := range results {
+		if r.Action != ActionImported {
+			t.Errorf("Import(dry run) %s = %s, want imported", r.State.Path, r.Action)
+		}
+	}
+	if list, _ := repo.ListMediaItems(ctx, db.ListOptions{}); len(list) != 0 {
+		t.Fatalf("Import(dry run) created %d items", len(list))
+	}
+
+	results, err = Import(ctx, repo, states, false)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if !results[0].CreatedItem || results[1].CreatedItem || !results[2].CreatedItem {
+		t.Errorf("Import() CreatedItem = %v %v %v, want true false true", results[0].CreatedItem, results[1].CreatedItem, results[2].CreatedItem)
+	}
+
+	alien, _ := repo.GetMediaItem(ctx, results[0].ItemID)
+	if alien.CurrentStage != model.StageRemux || alien.StageStatus != model.StatusFailed || alien.ItemStatus != model.ItemStatusActive {
+		t.Errorf("Alien = %s %s %s, want remux failed, active", alien.CurrentStage, alien.StageStatus, alien.ItemStatus)
+	}
+	jobs, _ := repo.ListJobsForMedia(ctx, alien.ID)
+	if len(jobs) != 2 {
+		t.Fatalf("Alien has %d jobs, want 2", len(jobs))
+	}
+	for _, job := range jobs {
+		if job.Stage != model.StageRip {
+			continue
+		}
+		if job.LogPath != states[0].LogPath || job.CompletedAt == nil || !job.CompletedAt.Equal(ended) || !job.CreatedAt.Equal(started) {
+			t.Errorf("rip job = %+v, want the log path and timestamps of the state directory", job)
+		}
+	}
+
+	lost, _ := repo.GetMediaItem(ctx, results[2].ItemID)
+	seasons, _ := repo.ListSeasonsForItem(ctx, lost.ID)
+	if len(seasons) != 1 || seasons[0].CurrentStage != model.StageRip || seasons[0].StageStatus != model.StatusCompleted {
+		t.Errorf("Lost seasons = %+v, want season 1 ripped", seasons)
+	}
+	if jobs, _ := repo.ListJobsForMedia(ctx, lost.ID); len(jobs) != 2 {
+		t.Errorf("Lost has %d jobs, want one per disc", len(jobs))
+	}
+
+	// Running it again imports nothing
+	results, err = Import(ctx, repo, states, false)
+	if err != nil {
+		t.Fatalf("Import(again) error = %v", err)
+	}
+	for _, r := range results {
+		if r.Action != ActionExists {
+			t.Errorf("Import(again) %s = %s, want exists", r.State.Path, r.Action)
+		}
+	}
+}
+
+func TestImport_MatchesItemsAndConflicts(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	// Created since with the Go safe name, which keeps punctuation
+	movie, _ := items.Create(ctx, repo, model.MediaTypeMovie, "Alien: Covenant", 0, nil)
+	items.Create(ctx, repo, model.MediaTypeTV, "Heat", 0, []int{1})
+
+	states := []StateDir{
+		{Path: "/a/.rip", Stage: model.StageRip, Type: model.MediaTypeMovie, Name: "Alien: Covenant", SafeName: "Alien_Covenant",
+			Status: model.JobStatusCompleted},
+		{Path: "/b/.rip", Stage: model.StageRip, Type: model.MediaTypeMovie, Name: "Heat", SafeName: "Heat",
+			Status: model.JobStatusCompleted},
+	}
+	results, err := Import(ctx, repo, states, false)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if results[0].Action != ActionImported || results[0].ItemID != movie.ID {
+		t.Errorf("Import() = %s item %d, want imported into item %d", results[0].Action, results[0].ItemID, movie.ID)
+	}
+	if results[1].Action != ActionConflict {
+		t.Errorf("Import() = %s, want a conflict with the Heat TV show", results[1].Action)
+	}
+}
@@ -0,0 +1,219 @@
+// Package legacy imports the history of items processed by the old bash
+// scripts (rip-disc.sh, remux.sh, transcode.sh and filebot.sh) into the
+// database. Each script left a state directory (.rip, .remux, .transcode or
+// .filebot) holding metadata.json, a status file, timestamps and its log.
+package legacy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// stateDirs maps the state directory of each script to its stage, and the
+// name of the log it wrote there
+var stateDirs = map[string]struct {
+	stage model.Stage
+	log   string
+}{
+	".rip":       {model.StageRip, "rip.log"},
+	".remux":     {model.StageRemux, "remux.log"},
+	".transcode": {model.StageTranscode, "transcode.log"},
+	".filebot":   {model.StagePublish, "filebot.log"},
+}
+
+// metadata is the metadata.json the scripts wrote. Numbers were written as
+// strings.
+type metadata struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	SafeName  string `json:"safe_name"`
+	Season    string `json:"season"`
+	Disc      string `json:"disc"`
+	InputDir  string `json:"input_dir"`
+	OutputDir string `json:"output_dir"`
+}
+
+// StateDir is one run of a script, as recorded in its state directory
+type StateDir struct {
+	Path      string // The state directory itself
+	Stage     model.Stage
+	Type      model.MediaType
+	Name      string
+	SafeName  string
+	Season    *int // TV shows only
+	Disc      *int // TV rips only
+	InputDir  string
+	OutputDir string
+	Status    model.JobStatus // Completed or failed; runs that never finished count as failed
+	Error     string
+	LogPath   string // Empty if the script left no log
+	StartedAt *time.Time
+	EndedAt   *time.Time
+}
+
+// Scan finds the state directories under the staging tree, ordered by stage
+// so an item's rip comes before its later stages
+func Scan(stagingBase string) ([]StateDir, []error) {
+	var found []StateDir
+	var errs []error
+
+	err := filepath.WalkDir(stagingBase, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if _, ok := stateDirs[d.Name()]; !ok {
+			return nil
+		}
+		state, err := Read(path)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			found = append(found, *state)
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to walk %s: %w", stagingBase, err))
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Stage != found[j].Stage {
+			return found[i].Stage < found[j].Stage
+		}
+		return found[i].Path < found[j].Path
+	})
+	return found, errs
+}
+
+// Read reads a state directory in the format test/validate-state checks
+func Read(path string) (*StateDir, error) {
+	kind, ok := stateDirs[filepath.Base(path)]
+	if !ok {
+		return nil, fmt.Errorf("%s: not a state directory", path)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, "metadata.json"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read metadata: %w", path, err)
+	}
+	var meta metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("%s: invalid metadata.json: %w", path, err)
+	}
+	if meta.Name == "" || meta.SafeName == "" {
+		return nil, fmt.Errorf("%s: metadata.json has no name or safe_name", path)
+	}
+
+	state := &StateDir{
+		Path:     path,
+		Stage:    kind.stage,
+		Name:     meta.Name,
+		SafeName: meta.SafeName,
+		InputDir: meta.InputDir,
+	}
+
+	// The scripts wrote into the directory holding the state directory,
+	// except filebot, which copied its input to the library
+	state.OutputDir = meta.OutputDir
+	if state.OutputDir == "" && kind.stage != model.StagePublish {
+		state.OutputDir = filepath.Dir(path)
+	}
+	if state.InputDir == "" && kind.stage == model.StagePublish {
+		state.InputDir = filepath.Dir(path)
+	}
+
+	switch meta.Type {
+	case "movie":
+		state.Type = model.MediaTypeMovie
+	case "tv", "show":
+		state.Type = model.MediaTypeTV
+		season, err := strconv.Atoi(strings.TrimSpace(meta.Season))
+		if err != nil || season < 1 {
+			return nil, fmt.Errorf("%s: TV show without a valid season (%q)", path, meta.Season)
+		}
+		state.Season = &season
+		if disc, err := strconv.Atoi(strings.TrimSpace(meta.Disc)); err == nil && kind.stage == model.StageRip {
+			state.Disc = &disc
+		}
+	default:
+		return nil, fmt.Errorf("%s: metadata.json type must be movie or tv, got %q", path, meta.Type)
+	}
+
+	status, err := readTrimmed(filepath.Join(path, "status"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read status: %w", path, err)
+	}
+	switch status {
+	case "completed":
+		state.Status = model.JobStatusCompleted
+	case "failed":
+		state.Status = model.JobStatusFailed
+		state.Error, _ = readTrimmed(filepath.Join(path, "error"))
+		if state.Error == "" {
+			state.Error = "legacy script failed"
+		}
+	case "pending", "in_progress":
+		state.Status = model.JobStatusFailed
+		state.Error = "legacy script never finished"
+	default:
+		return nil, fmt.Errorf("%s: invalid status %q", path, status)
+	}
+
+	if state.StartedAt, err = readTime(filepath.Join(path, "started_at")); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, name := range []string{"completed_at", "failed_at"} {
+		if state.EndedAt != nil {
+			break
+		}
+		if state.EndedAt, err = readTime(filepath.Join(path, name)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	logPath := filepath.Join(path, kind.log)
+	if _, err := os.Stat(logPath); err == nil {
+		state.LogPath = logPath
+	}
+	return state, nil
+}
+
+// readTrimmed reads a one-line state file
+func readTrimmed(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readTime reads a timestamp written by date -Iseconds, nil if the file
+// does not exist
+func readTime(path string) (*time.Time, error) {
+	s, err := readTrimmed(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp in %s: %q", filepath.Base(path), s)
+	}
+	return &t, nil
+}
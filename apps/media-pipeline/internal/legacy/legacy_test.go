@@ -0,0 +1,138 @@
+package legacy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// writeState writes a state directory as the bash scripts did
+func writeState(t *testing.T, dir, metadata string, files map[string]string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "metadata.json"), []byte(metadata), 0644)
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0644)
+	}
+	return dir
+}
+
+func TestRead_TVRip(t *testing.T) {
+	staging := t.TempDir()
+	dir := writeState(t, filepath.Join(staging, "1-ripped/tv/Breaking_Bad/S02/Disc3/.rip"),
+		`{"type": "show", "name": "Breaking Bad", "safe_name": "Breaking_Bad", "season": "2", "disc": "3", "pid": 1234}`,
+		map[string]string{
+			"status":       "completed",
+			"started_at":   "2024-03-01T20:00:00+01:00",
+			"completed_at": "2024-03-01T20:45:00+01:00",
+			"rip.log":      "done",
+		})
+
+	state, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if state.Stage != model.StageRip || state.Type != model.MediaTypeTV || state.SafeName != "Breaking_Bad" {
+		t.Errorf("Read() = %s %s %s, want a TV rip of Breaking_Bad", state.Stage, state.Type, state.SafeName)
+	}
+	if state.Season == nil || *state.Season != 2 || state.Disc == nil || *state.Disc != 3 {
+		t.Errorf("Read() season %v disc %v, want season 2 disc 3", state.Season, state.Disc)
+	}
+	if state.OutputDir != filepath.Dir(dir) {
+		t.Errorf("Read() OutputDir = %q, want the directory holding .rip", state.OutputDir)
+	}
+	if state.LogPath != filepath.Join(dir, "rip.log") {
+		t.Errorf("Read() LogPath = %q, want rip.log", state.LogPath)
+	}
+	wantEnd := time.Date(2024, 3, 1, 19, 45, 0, 0, time.UTC)
+	if state.Status != model.JobStatusCompleted || state.EndedAt == nil || !state.EndedAt.Equal(wantEnd) {
+		t.Errorf("Read() = %s ended %v, want completed at %v", state.Status, state.EndedAt, wantEnd)
+	}
+}
+
+func TestRead_Statuses(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		want      model.JobStatus
+		wantError string
+	}{
+		{"failed with exit code", map[string]string{"status": "failed", "error": "Exit code: 2", "failed_at": "2024-03-01T20:00:00Z"},
+			model.JobStatusFailed, "Exit code: 2"},
+		{"failed without error file", map[string]string{"status": "failed"}, model.JobStatusFailed, "legacy script failed"},
+		{"never finished", map[string]string{"status": "in_progress", "pid": "4321"}, model.JobStatusFailed, "never finished"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeState(t, filepath.Join(t.TempDir(), "Alien/.remux"),
+				`{"type": "movie", "name": "Alien", "safe_name": "Alien", "input_dir": "/in", "output_dir": "/out"}`, tt.files)
+			state, err := Read(dir)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if state.Status != tt.want || !strings.Contains(state.Error, tt.wantError) {
+				t.Errorf("Read() = %s %q, want %s %q", state.Status, state.Error, tt.want, tt.wantError)
+			}
+			if state.InputDir != "/in" || state.OutputDir != "/out" {
+				t.Errorf("Read() dirs = %q -> %q, want the metadata's", state.InputDir, state.OutputDir)
+			}
+		})
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		files    map[string]string
+		wantErr  string
+	}{
+		{"bad JSON", `{`, map[string]string{"status": "completed"}, "invalid metadata.json"},
+		{"no safe name", `{"type": "movie", "name": "Alien"}`, map[string]string{"status": "completed"}, "no name or safe_name"},
+		{"bad type", `{"type": "short", "name": "A", "safe_name": "A"}`, map[string]string{"status": "completed"}, "movie or tv"},
+		{"TV without season", `{"type": "tv", "name": "A", "safe_name": "A"}`, map[string]string{"status": "completed"}, "season"},
+		{"bad status", `{"type": "movie", "name": "A", "safe_name": "A"}`, map[string]string{"status": "done"}, "invalid status"},
+		{"no status", `{"type": "movie", "name": "A", "safe_name": "A"}`, nil, "failed to read status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeState(t, filepath.Join(t.TempDir(), ".transcode"), tt.metadata, tt.files)
+			if _, err := Read(dir); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	staging := t.TempDir()
+	movie := `{"type": "movie", "name": "Alien", "safe_name": "Alien"}`
+	done := map[string]string{"status": "completed"}
+	writeState(t, filepath.Join(staging, "3-transcoded/movies/Alien/.filebot"), movie, done)
+	writeState(t, filepath.Join(staging, "1-ripped/movies/Alien/.rip"), movie, done)
+	writeState(t, filepath.Join(staging, "2-remuxed/movies/Alien/.remux"), movie, done)
+	writeState(t, filepath.Join(staging, "2-remuxed/movies/Broken/.remux"), `{`, done)
+
+	states, errs := Scan(staging)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "Broken") {
+		t.Errorf("Scan() errors = %v, want one for Broken", errs)
+	}
+	var stages []model.Stage
+	for _, s := range states {
+		stages = append(stages, s.Stage)
+	}
+	if len(stages) != 3 || stages[0] != model.StageRip || stages[1] != model.StageRemux || stages[2] != model.StagePublish {
+		t.Errorf("Scan() stages = %v, want rip, remux, publish", stages)
+	}
+	if states[2].InputDir != filepath.Join(staging, "3-transcoded/movies/Alien") {
+		t.Errorf("Scan() publish InputDir = %q, want the directory holding .filebot", states[2].InputDir)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -36,6 +37,33 @@ func (l Level) String() string {
 	}
 }
 
+// timestampFormat is the timestamp at the start of every log line
+const timestampFormat = "2006-01-02 15:04:05"
+
+// ParseLine splits a line written by a Logger into its level and message.
+// ok is false for lines in any other format, e.g. raw tool output.
+func ParseLine(line string) (level Level, msg string, ok bool) {
+	if len(line) < len(timestampFormat)+2 || line[len(timestampFormat)] != ' ' {
+		return 0, "", false
+	}
+	if _, err := time.Parse(timestampFormat, line[:len(timestampFormat)]); err != nil {
+		return 0, "", false
+	}
+
+	rest := line[len(timestampFormat)+1:]
+	end := strings.Index(rest, "] ")
+	if !strings.HasPrefix(rest, "[") || end < 0 {
+		return 0, "", false
+	}
+
+	for l := LevelDebug; l <= LevelError; l++ {
+		if l.String() == rest[1:end] {
+			return l, rest[end+2:], true
+		}
+	}
+	return 0, "", false
+}
+
 // Logger provides multi-destination logging with level filtering
 type Logger struct {
 	mu         sync.Mutex
@@ -95,7 +123,8 @@ func NewForJob(logPath string, stdout bool, eventFn func(level, msg string)) (*L
 	}), nil
 }
 
-// log is the internal logging method
+// log is the internal logging method. Warnings and errors are significant
+// events, so they also go to the event callback.
 func (l *Logger) log(level Level, msg string, args ...any) {
 	if level < l.minLevel {
 		return
@@ -103,20 +132,23 @@ func (l *Logger) log(level Level, msg string, args ...any) {
 
 	formatted := fmt.Sprintf(msg, args...)
 	line := fmt.Sprintf("%s [%s] %s\n",
-		time.Now().Format("2006-01-02 15:04:05"),
+		time.Now().Format(timestampFormat),
 		level.String(),
 		formatted,
 	)
 
 	l.mu.Lock()
-	defer l.mu.Unlock()
-
 	if l.stdout != nil {
 		l.stdout.Write([]byte(line))
 	}
 	if l.file != nil {
 		l.file.Write([]byte(line))
 	}
+	l.mu.Unlock()
+
+	if level >= LevelWarn && l.eventFn != nil {
+		l.eventFn(level.String(), formatted)
+	}
 }
 
 // Debug logs a debug message
@@ -148,7 +180,7 @@ func (l *Logger) Error(msg string, args ...any) {
 func (l *Logger) Event(level Level, msg string) {
 	// Format the message directly since Event doesn't take variadic args
 	line := fmt.Sprintf("%s [%s] %s\n",
-		time.Now().Format("2006-01-02 15:04:05"),
+		time.Now().Format(timestampFormat),
 		level.String(),
 		msg,
 	)
@@ -191,6 +191,32 @@ func TestLogger_EventCallsCallback(t *testing.T) {
 	}
 }
 
+func TestLogger_WarningsAndErrorsAreEvents(t *testing.T) {
+	var eventCalls []string
+
+	logger := New(Options{
+		MinLevel: LevelInfo,
+		EventFn: func(level, msg string) {
+			eventCalls = append(eventCalls, level+":"+msg)
+		},
+	})
+
+	logger.Info("routine")
+	logger.Progress("50%%")
+	logger.Warn("disk %s", "low")
+	logger.Error("failed: %v", "exit status 1")
+
+	want := []string{"WARN:disk low", "ERROR:failed: exit status 1"}
+	if len(eventCalls) != len(want) {
+		t.Fatalf("eventFn calls = %v, want %v", eventCalls, want)
+	}
+	for i := range want {
+		if eventCalls[i] != want[i] {
+			t.Errorf("eventFn call %d = %q, want %q", i, eventCalls[i], want[i])
+		}
+	}
+}
+
 func TestLogger_EventWithoutCallback(t *testing.T) {
 	var buf bytes.Buffer
 
@@ -329,3 +355,25 @@ func TestNewForJob_WithEventFn(t *testing.T) {
 		t.Errorf("expected 1 event call, got %d", len(eventCalls))
 	}
 }
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantLevel Level
+		wantMsg   string
+		wantOK    bool
+	}{
+		{"2026-01-02 03:04:05 [WARN] disk almost full", LevelWarn, "disk almost full", true},
+		{"2026-01-02 03:04:05 [ERROR] mkvmerge failed: [x] bad", LevelError, "mkvmerge failed: [x] bad", true},
+		{"2026-01-02 03:04:05 [NOPE] message", 0, "", false},
+		{"frame=  100 fps=25", 0, "", false},
+		{"", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		level, msg, ok := ParseLine(tt.line)
+		if ok != tt.wantOK || level != tt.wantLevel || msg != tt.wantMsg {
+			t.Errorf("ParseLine(%q) = %v, %q, %v, want %v, %q, %v", tt.line, level, msg, ok, tt.wantLevel, tt.wantMsg, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// ToolLogMaxSize is the size a tool log is rotated at
+	ToolLogMaxSize = 10 << 20
+
+	// ToolLogBackups is how many rotated files of a tool log are kept, as
+	// <name>.log.1 (newest) to <name>.log.N
+	ToolLogBackups = 2
+)
+
+// ToolLogOpener opens the raw output log of an external tool run by a job,
+// e.g. "makemkv" or "ffmpeg-<file>"
+type ToolLogOpener func(tool string) (io.WriteCloser, error)
+
+// Open opens a tool's log. With no opener the tool's output is discarded.
+func (open ToolLogOpener) Open(tool string) (io.WriteCloser, error) {
+	if open == nil {
+		return nopCloser{io.Discard}, nil
+	}
+	w, err := open(tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s log: %w", tool, err)
+	}
+	return w, nil
+}
+
+// nopCloser is a writer with nothing to close
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// RotatingFile is a log file that is moved aside once it reaches a maximum
+// size, keeping a fixed number of older files
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+// OpenRotating opens a log file for appending, rotating it once it grows past
+// maxSize. A single write larger than maxSize is kept whole.
+func OpenRotating(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	r := &RotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the current file and reads its size
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if it would take the file past its size
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts <path>.1 .. <path>.N-1 up by one, dropping the oldest, moves
+// the current file to <path>.1 and starts a new one
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	if r.backups <= 0 {
+		if err := os.Remove(r.path); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+		return r.open()
+	}
+	for i := r.backups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", r.path, i)
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", r.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return r.open()
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// Ensure RotatingFile is a writer that can be closed
+var _ io.WriteCloser = (*RotatingFile)(nil)
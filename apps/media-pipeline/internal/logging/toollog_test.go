@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "makemkv.log")
+
+	r, err := OpenRotating(path, 10, 2)
+	if err != nil {
+		t.Fatalf("OpenRotating() error = %v", err)
+	}
+	for _, line := range []string{"aaaaaaa\n", "bbbbbbb\n", "ccccccc\n", "ddddddd\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) error = %v", line, err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := map[string]string{
+		path:        "ddddddd\n",
+		path + ".1": "ccccccc\n",
+		path + ".2": "bbbbbbb\n",
+	}
+	for file, content := range want {
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", filepath.Base(file), err)
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("kept more than 2 rotated files: %v", err)
+	}
+}
+
+func TestRotatingFile_AppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mkvmerge.log")
+	if err := os.WriteFile(path, []byte("first run\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	r, err := OpenRotating(path, 15, 1)
+	if err != nil {
+		t.Fatalf("OpenRotating() error = %v", err)
+	}
+	r.Write([]byte("second\n"))
+	r.Close()
+
+	// The file was already 10 bytes, so the second run starts a new one
+	got, _ := os.ReadFile(path)
+	if string(got) != "second\n" {
+		t.Errorf("log = %q, want the second run only", got)
+	}
+	old, _ := os.ReadFile(path + ".1")
+	if string(old) != "first run\n" {
+		t.Errorf("rotated log = %q, want the first run", old)
+	}
+}
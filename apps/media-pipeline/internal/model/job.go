@@ -10,6 +10,18 @@ const (
 	JobStatusInProgress JobStatus = "in_progress"
 	JobStatusCompleted  JobStatus = "completed"
 	JobStatusFailed     JobStatus = "failed"
+	JobStatusCancelled  JobStatus = "cancelled"
+)
+
+// FailureKind classifies why a job failed, to decide whether it is worth retrying
+type FailureKind string
+
+const (
+	FailureKindStage    FailureKind = "stage"    // The stage binary reported an error
+	FailureKindLaunch   FailureKind = "launch"   // The stage binary could not be started
+	FailureKindCrash    FailureKind = "crash"    // The stage binary exited without recording a result
+	FailureKindVanished FailureKind = "vanished" // The worker running the job died
+	FailureKindSpace    FailureKind = "space"    // Not enough disk space for the stage's output
 )
 
 // Job represents a single stage execution attempt
@@ -26,10 +38,23 @@ type Job struct {
 	OutputDir    string
 	LogPath      string
 	ErrorMessage string
-	Progress     int // 0-100 percentage
+	Progress     int        // 0-100 percentage
+	Priority     int        // Higher runs first; FIFO within the same priority
+	Attempt      int        // 1 for the first attempt of a stage run
+	RunID        int64      // ID of the first attempt; shared by all attempts of a run
+	NotBefore    *time.Time // A pending retry is not dispatched before this
+	FailureKind  FailureKind
 	StartedAt    *time.Time
 	CompletedAt  *time.Time
 	CreatedAt    time.Time
+
+	// CancelRequested is set when the job was cancelled before its stage
+	// process recorded a PID; the daemon running it stops it
+	CancelRequested bool
+
+	// Version is incremented by every update; UpdateJob only applies to the
+	// version the job was read at
+	Version int64
 }
 
 // IsActive returns true if the job is pending or in progress
@@ -37,6 +62,21 @@ func (j *Job) IsActive() bool {
 	return j.Status == JobStatusPending || j.Status == JobStatusInProgress
 }
 
+// StageStatus maps the job status onto the equivalent item/season stage status.
+// A cancelled job leaves the stage pending so it can be started again.
+func (j *Job) StageStatus() Status {
+	switch j.Status {
+	case JobStatusCompleted:
+		return StatusCompleted
+	case JobStatusInProgress:
+		return StatusInProgress
+	case JobStatusFailed:
+		return StatusFailed
+	default:
+		return StatusPending
+	}
+}
+
 // Duration returns the job duration, or zero if not completed
 func (j *Job) Duration() time.Duration {
 	if j.StartedAt == nil || j.CompletedAt == nil {
@@ -54,6 +94,15 @@ type LogEvent struct {
 	Timestamp time.Time
 }
 
+// ToolLog is the raw output file of an external tool a job ran
+type ToolLog struct {
+	ID        int64
+	JobID     int64
+	Tool      string // e.g. "makemkv", "mkvmerge", "ffmpeg-<file>"
+	Path      string
+	CreatedAt time.Time
+}
+
 // DiscProgress tracks rip status for a TV disc
 type DiscProgress struct {
 	Disc   int
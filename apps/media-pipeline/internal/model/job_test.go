@@ -14,6 +14,7 @@ func TestJob_IsActive(t *testing.T) {
 		{JobStatusInProgress, true},
 		{JobStatusCompleted, false},
 		{JobStatusFailed, false},
+		{JobStatusCancelled, false},
 	}
 	for _, tt := range tests {
 		job := Job{Status: tt.status}
@@ -23,6 +24,25 @@ func TestJob_IsActive(t *testing.T) {
 	}
 }
 
+func TestJob_StageStatus(t *testing.T) {
+	tests := []struct {
+		status JobStatus
+		want   Status
+	}{
+		{JobStatusPending, StatusPending},
+		{JobStatusInProgress, StatusInProgress},
+		{JobStatusCompleted, StatusCompleted},
+		{JobStatusFailed, StatusFailed},
+		{JobStatusCancelled, StatusPending},
+	}
+	for _, tt := range tests {
+		job := Job{Status: tt.status}
+		if got := job.StageStatus(); got != tt.want {
+			t.Errorf("Job{Status: %q}.StageStatus() = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
 func TestJob_Duration(t *testing.T) {
 	start := time.Now().Add(-1 * time.Hour)
 	end := time.Now()
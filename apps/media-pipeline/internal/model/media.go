@@ -33,6 +33,16 @@ func (s Stage) String() string {
 	}
 }
 
+// ParseStage converts a stage name as returned by String back to a Stage
+func ParseStage(name string) (Stage, error) {
+	for s := StageRip; s <= StagePublish; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown stage %q", name)
+}
+
 func (s Stage) DisplayName() string {
 	switch s {
 	case StageRip:
@@ -125,6 +135,7 @@ type MediaItem struct {
 	Type     MediaType // "movie" or "tv"
 	Name     string    // Human-readable name like "The Lion King"
 	SafeName string    // Filesystem-safe name like "The_Lion_King"
+	Year     *int      // Release year, if known
 
 	// Database IDs for FileBot matching
 	TmdbID *int // TheMovieDB ID (for movies)
@@ -137,6 +148,13 @@ type MediaItem struct {
 	CurrentStage Stage  // Only used for movies
 	StageStatus  Status // Only used for movies
 
+	// Autopilot queues the next stage automatically after remux and transcode.
+	// On a TV show it applies to every season.
+	Autopilot bool
+
+	// Version is incremented by every update of the item
+	Version int64
+
 	// For TV Shows: seasons contain the pipeline state
 	Seasons []Season // Populated for TV shows
 
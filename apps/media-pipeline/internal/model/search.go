@@ -0,0 +1,21 @@
+package model
+
+// SearchKind is what a search result matched
+type SearchKind string
+
+const (
+	SearchItem   SearchKind = "item"   // An item's name
+	SearchSeason SearchKind = "season" // A season's label, such as "Breaking Bad Season 2"
+	SearchJob    SearchKind = "job"    // A job's error message
+	SearchLog    SearchKind = "log"    // A job's log message
+)
+
+// SearchResult is an item, season, job error or log message matching a
+// search, with the item it belongs to
+type SearchResult struct {
+	Kind        SearchKind
+	MediaItemID int64
+	SeasonID    *int64
+	JobID       *int64
+	Snippet     string // The matched text, with matches in [brackets]
+}
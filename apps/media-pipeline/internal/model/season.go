@@ -9,6 +9,9 @@ type Season struct {
 	Number       int       // Season number (1, 2, 3...)
 	CurrentStage Stage     // Current pipeline stage
 	StageStatus  Status    // Status of current stage
+	Autopilot    bool      // Queue the next stage automatically (see MediaItem.Autopilot)
+	DiscCount    int       // Discs the season spans, 0 if not known
+	Version      int64     // Incremented by every update; UpdateSeason only applies to the version read
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
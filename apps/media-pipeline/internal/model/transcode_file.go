@@ -40,3 +40,12 @@ func (f *TranscodeFile) CompressionRatio() float64 {
 	}
 	return float64(f.OutputSize) / float64(f.InputSize)
 }
+
+// TranscodeSummary is how far through its files a transcode job is
+type TranscodeSummary struct {
+	JobID           int64
+	Files           int
+	Completed       int
+	CurrentFile     string // Relative path of the file being transcoded, empty between files
+	CurrentProgress int    // 0-100 percentage of the current file
+}
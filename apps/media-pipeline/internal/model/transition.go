@@ -0,0 +1,170 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StageState is where an item or season is in the pipeline
+type StageState struct {
+	Stage  Stage
+	Status Status
+}
+
+func (s StageState) String() string {
+	return fmt.Sprintf("%s/%s", s.Stage, s.Status)
+}
+
+// ErrIllegalTransition is matched by every transition error
+var ErrIllegalTransition = errors.New("illegal transition")
+
+// StageTransitionError is returned for a move of an item or season that
+// the state machine does not allow
+type StageTransitionError struct {
+	From, To StageState
+}
+
+func (e *StageTransitionError) Error() string {
+	return fmt.Sprintf("cannot move from %s to %s", e.From, e.To)
+}
+
+func (e *StageTransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// JobTransitionError is returned for a job status change the state machine
+// does not allow
+type JobTransitionError struct {
+	JobID    int64
+	From, To JobStatus
+}
+
+func (e *JobTransitionError) Error() string {
+	return fmt.Sprintf("job %d cannot go from %s to %s", e.JobID, e.From, e.To)
+}
+
+func (e *JobTransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// statusTransitions are the moves allowed within a stage. Pending to
+// completed is a stage done by hand, such as organize or marking rips done;
+// completed to in_progress runs the stage again.
+var statusTransitions = map[Status][]Status{
+	StatusPending:    {StatusInProgress, StatusCompleted, StatusFailed},
+	StatusInProgress: {StatusPending, StatusCompleted, StatusFailed},
+	StatusFailed:     {StatusPending, StatusInProgress},
+	StatusCompleted:  {StatusInProgress},
+}
+
+// jobTransitions are the job status changes allowed. Completed, failed and
+// cancelled are final: a retry is a new job.
+var jobTransitions = map[JobStatus][]JobStatus{
+	JobStatusPending:    {JobStatusInProgress, JobStatusCompleted, JobStatusFailed, JobStatusCancelled},
+	JobStatusInProgress: {JobStatusCompleted, JobStatusFailed, JobStatusCancelled},
+}
+
+// CheckStageTransition returns a *StageTransitionError unless an item or
+// season may move from one state to the other. Within a stage the status
+// follows statusTransitions. A completed stage moves on to the next one in
+// any status, and an earlier stage can be queued or run again; stages
+// cannot be skipped, nor earlier ones completed or failed out of turn.
+func CheckStageTransition(from, to StageState) error {
+	switch {
+	case from == to:
+		return nil
+	case to.Stage == from.Stage:
+		for _, next := range statusTransitions[from.Status] {
+			if next == to.Status {
+				return nil
+			}
+		}
+	case to.Stage == from.Stage+1:
+		if from.Status == StatusCompleted {
+			return nil
+		}
+	case to.Stage < from.Stage:
+		if to.Status == StatusPending || to.Status == StatusInProgress {
+			return nil
+		}
+	}
+	return &StageTransitionError{From: from, To: to}
+}
+
+// CheckJobTransition returns a *JobTransitionError unless a job may change
+// from one status to the other
+func CheckJobTransition(id int64, from, to JobStatus) error {
+	if from == to {
+		return nil
+	}
+	for _, next := range jobTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return &JobTransitionError{JobID: id, From: from, To: to}
+}
+
+// StagePath returns the legal steps from one state to a later one, for
+// recording history that happened outside the pipeline, such as a legacy
+// import. Each earlier stage passes through completed.
+func StagePath(from, to StageState) ([]StageState, error) {
+	if to.Stage < from.Stage {
+		return nil, &StageTransitionError{From: from, To: to}
+	}
+
+	var path []StageState
+	current := from
+	step := func(next StageState) {
+		path = append(path, next)
+		current = next
+	}
+
+	for current.Stage < to.Stage {
+		switch current.Status {
+		case StatusCompleted:
+			next := StageState{Stage: current.Stage + 1, Status: StatusCompleted}
+			if next.Stage == to.Stage {
+				next.Status = to.Status
+			}
+			step(next)
+		case StatusFailed:
+			step(StageState{Stage: current.Stage, Status: StatusInProgress})
+		default:
+			step(StageState{Stage: current.Stage, Status: StatusCompleted})
+		}
+	}
+	if current != to {
+		if CheckStageTransition(current, to) != nil {
+			step(StageState{Stage: current.Stage, Status: StatusInProgress})
+		}
+		step(to)
+	}
+	return path, nil
+}
+
+// TransitionKind is what a recorded transition moved
+type TransitionKind string
+
+const (
+	TransitionItem   TransitionKind = "item"   // A movie's stage
+	TransitionSeason TransitionKind = "season" // A TV season's stage
+	TransitionJob    TransitionKind = "job"    // A job's status
+)
+
+// Transition is a recorded stage or status change. A job keeps its stage,
+// so only its status changes.
+type Transition struct {
+	ID          int64
+	Kind        TransitionKind
+	MediaItemID int64
+	SeasonID    *int64
+	JobID       *int64 // The job changed, or for items and seasons the latest job of the new stage
+	FromStage   Stage
+	FromStatus  string // A Status for items and seasons, a JobStatus for jobs
+	ToStage     Stage
+	ToStatus    string
+	Actor       string // tui, cli, or the host of a worker or stage binary
+	CreatedAt   time.Time
+}
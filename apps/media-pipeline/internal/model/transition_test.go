@@ -0,0 +1,136 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckStageTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to StageState
+		wantOK   bool
+	}{
+		{"start stage", StageState{StageRip, StatusPending}, StageState{StageRip, StatusInProgress}, true},
+		{"finish stage", StageState{StageRemux, StatusInProgress}, StageState{StageRemux, StatusCompleted}, true},
+		{"fail stage", StageState{StageRemux, StatusInProgress}, StageState{StageRemux, StatusFailed}, true},
+		{"cancel stage", StageState{StageRemux, StatusInProgress}, StageState{StageRemux, StatusPending}, true},
+		{"retry failed stage", StageState{StageRemux, StatusFailed}, StageState{StageRemux, StatusInProgress}, true},
+		{"done by hand", StageState{StageRip, StatusPending}, StageState{StageRip, StatusCompleted}, true},
+		{"unchanged", StageState{StageTranscode, StatusFailed}, StageState{StageTranscode, StatusFailed}, true},
+		{"next stage", StageState{StageOrganize, StatusCompleted}, StageState{StageRemux, StatusInProgress}, true},
+		{"run earlier stage again", StageState{StagePublish, StatusCompleted}, StageState{StageRemux, StatusInProgress}, true},
+		{"completed back to pending", StageState{StageRemux, StatusCompleted}, StageState{StageRemux, StatusPending}, false},
+		{"failed to completed", StageState{StageRemux, StatusFailed}, StageState{StageRemux, StatusCompleted}, false},
+		{"next stage before completing", StageState{StageRemux, StatusInProgress}, StageState{StageTranscode, StatusInProgress}, false},
+		{"skip a stage", StageState{StageOrganize, StatusCompleted}, StageState{StageTranscode, StatusInProgress}, false},
+		{"complete earlier stage", StageState{StageTranscode, StatusPending}, StageState{StageRemux, StatusCompleted}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckStageTransition(tt.from, tt.to)
+			if tt.wantOK {
+				if err != nil {
+					t.Errorf("CheckStageTransition() error = %v, want nil", err)
+				}
+				return
+			}
+			var transitionErr *StageTransitionError
+			if !errors.As(err, &transitionErr) || !errors.Is(err, ErrIllegalTransition) {
+				t.Fatalf("CheckStageTransition() error = %v, want *StageTransitionError", err)
+			}
+			if transitionErr.From != tt.from || transitionErr.To != tt.to {
+				t.Errorf("error = %v, want %s to %s", err, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestCheckJobTransition(t *testing.T) {
+	tests := []struct {
+		from, to JobStatus
+		wantOK   bool
+	}{
+		{JobStatusPending, JobStatusInProgress, true},
+		{JobStatusPending, JobStatusCancelled, true},
+		{JobStatusPending, JobStatusCompleted, true},
+		{JobStatusInProgress, JobStatusCompleted, true},
+		{JobStatusInProgress, JobStatusFailed, true},
+		{JobStatusInProgress, JobStatusCancelled, true},
+		{JobStatusCompleted, JobStatusCompleted, true},
+		{JobStatusInProgress, JobStatusPending, false},
+		{JobStatusCompleted, JobStatusFailed, false},
+		{JobStatusFailed, JobStatusInProgress, false},
+		{JobStatusCancelled, JobStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		err := CheckJobTransition(7, tt.from, tt.to)
+		if tt.wantOK != (err == nil) {
+			t.Errorf("CheckJobTransition(%s, %s) error = %v, want ok %v", tt.from, tt.to, err, tt.wantOK)
+		}
+		if err != nil && !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("CheckJobTransition(%s, %s) error = %v, want ErrIllegalTransition", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestStagePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to StageState
+		want     []StageState
+	}{
+		{
+			"through each stage",
+			StageState{StageRip, StatusPending},
+			StageState{StageRemux, StatusCompleted},
+			[]StageState{{StageRip, StatusCompleted}, {StageOrganize, StatusCompleted}, {StageRemux, StatusCompleted}},
+		},
+		{
+			"failed stage is run again",
+			StageState{StageRip, StatusFailed},
+			StageState{StageOrganize, StatusFailed},
+			[]StageState{{StageRip, StatusInProgress}, {StageRip, StatusCompleted}, {StageOrganize, StatusFailed}},
+		},
+		{
+			"same stage",
+			StageState{StageRemux, StatusPending},
+			StageState{StageRemux, StatusFailed},
+			[]StageState{{StageRemux, StatusFailed}},
+		},
+		{
+			"failed to completed goes through in progress",
+			StageState{StageRemux, StatusFailed},
+			StageState{StageRemux, StatusCompleted},
+			[]StageState{{StageRemux, StatusInProgress}, {StageRemux, StatusCompleted}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StagePath(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("StagePath() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("StagePath() = %v, want %v", got, tt.want)
+			}
+			current := tt.from
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("step %d = %s, want %s", i, got[i], tt.want[i])
+				}
+				if err := CheckStageTransition(current, got[i]); err != nil {
+					t.Errorf("step %d: %v", i, err)
+				}
+				current = got[i]
+			}
+		})
+	}
+
+	if _, err := StagePath(StageState{StageRemux, StatusPending}, StageState{StageRip, StatusCompleted}); err == nil {
+		t.Error("StagePath() backwards succeeded, want error")
+	}
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Capability is something only some worker hosts can do
+type Capability string
+
+const (
+	CapabilityOpticalDrive Capability = "optical_drive" // Can rip discs
+	CapabilityQSV          Capability = "qsv"           // Has Intel Quick Sync for hardware transcodes
+	CapabilityFilebot      Capability = "filebot"       // Has filebot installed for publishing
+)
+
+// Worker is a host that runs stage binaries, as last reported by its heartbeat
+type Worker struct {
+	Hostname     string
+	Target       string // How the daemon dispatches to the host; "" if it never registered one
+	Capabilities []Capability
+	RunningJobs  int // In-progress jobs recorded against the host
+	LastSeen     time.Time
+}
+
+// Has returns true if the worker reported all of the given capabilities
+func (w *Worker) Has(caps ...Capability) bool {
+	for _, c := range caps {
+		found := false
+		for _, have := range w.Capabilities {
+			if have == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Online returns true if the worker sent a heartbeat within timeout of now
+func (w *Worker) Online(now time.Time, timeout time.Duration) bool {
+	return now.Sub(w.LastSeen) <= timeout
+}
@@ -19,6 +19,20 @@ type ValidationResult struct {
 // Validator validates that media has been organized correctly
 type Validator struct{}
 
+// Validate checks a movie's rip output, or a TV season directory and the disc
+// directories ripped into it
+func (v *Validator) Validate(isTV bool, path string, discPaths []string) ValidationResult {
+	if !isTV {
+		return v.ValidateMovie(path)
+	}
+	// For TV seasons, use multi-disc validation if we have disc paths
+	if len(discPaths) > 0 {
+		return v.ValidateTVSeason(discPaths)
+	}
+	// Single disc or legacy - validate season directory directly
+	return v.ValidateTV(path)
+}
+
 // ValidateMovie validates that a movie directory is properly organized
 func (v *Validator) ValidateMovie(outputDir string) ValidationResult {
 	result := ValidationResult{Valid: true}
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cuivienor/media-pipeline/internal/logging"
 	"github.com/cuivienor/media-pipeline/internal/model"
 	"github.com/cuivienor/media-pipeline/internal/testutil"
 	"github.com/cuivienor/media-pipeline/internal/transcode"
@@ -29,6 +30,10 @@ func (l *testLogger) Error(format string, args ...interface{}) {
 	l.t.Logf("[ERROR] "+format, args...)
 }
 
+func (l *testLogger) Event(level logging.Level, msg string) {
+	l.t.Logf("[%s] %s", level, msg)
+}
+
 func TestTranscodeContract_MovieHappyPath(t *testing.T) {
 	// Skip if ffmpeg/ffprobe not available
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
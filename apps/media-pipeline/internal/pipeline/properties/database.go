@@ -12,23 +12,14 @@ import (
 // AssertJobStatusConsistency verifies all jobs have consistent status and timestamps
 // Returns an error if any invariant is violated
 func AssertJobStatusConsistency(ctx context.Context, repo *db.SQLiteRepository) error {
-	// Get all jobs - we need a method for this
-	// For now, check jobs for all media items
-	items, err := repo.ListActiveItems(ctx)
+	page, err := repo.ListJobs(ctx, db.JobFilter{})
 	if err != nil {
-		return fmt.Errorf("failed to list items: %w", err)
+		return fmt.Errorf("failed to list jobs: %w", err)
 	}
 
-	for _, item := range items {
-		jobs, err := repo.ListJobsForMedia(ctx, item.ID)
-		if err != nil {
-			return fmt.Errorf("failed to list jobs for item %d: %w", item.ID, err)
-		}
-
-		for _, job := range jobs {
-			if err := validateJobConsistency(&job); err != nil {
-				return fmt.Errorf("job %d: %w", job.ID, err)
-			}
+	for _, job := range page.Jobs {
+		if err := validateJobConsistency(&job); err != nil {
+			return fmt.Errorf("job %d: %w", job.ID, err)
 		}
 	}
 
@@ -60,7 +51,7 @@ func validateJobConsistency(job *model.Job) error {
 
 // AssertNoOrphanedJobs verifies all jobs reference valid media items
 func AssertNoOrphanedJobs(ctx context.Context, repo *db.SQLiteRepository) error {
-	items, err := repo.ListActiveItems(ctx)
+	items, err := repo.ListMediaItems(ctx, db.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list items: %w", err)
 	}
@@ -72,16 +63,14 @@ func AssertNoOrphanedJobs(ctx context.Context, repo *db.SQLiteRepository) error
 	}
 
 	// Check all jobs reference valid items
-	for _, item := range items {
-		jobs, err := repo.ListJobsForMedia(ctx, item.ID)
-		if err != nil {
-			return fmt.Errorf("failed to list jobs: %w", err)
-		}
+	page, err := repo.ListJobs(ctx, db.JobFilter{})
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
 
-		for _, job := range jobs {
-			if !validIDs[job.MediaItemID] {
-				return fmt.Errorf("job %d references non-existent media item %d", job.ID, job.MediaItemID)
-			}
+	for _, job := range page.Jobs {
+		if !validIDs[job.MediaItemID] {
+			return fmt.Errorf("job %d references non-existent media item %d", job.ID, job.MediaItemID)
 		}
 	}
 
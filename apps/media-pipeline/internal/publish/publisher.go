@@ -16,14 +16,14 @@ import (
 
 // FilebotRunner executes FileBot commands
 type FilebotRunner interface {
-	Run(args []string) (string, error)
+	Run(ctx context.Context, args []string) (string, error)
 }
 
 // defaultFilebotRunner runs the real FileBot command
 type defaultFilebotRunner struct{}
 
-func (r *defaultFilebotRunner) Run(args []string) (string, error) {
-	cmd := exec.Command("filebot", args...)
+func (r *defaultFilebotRunner) Run(ctx context.Context, args []string) (string, error) {
+	cmd := exec.CommandContext(ctx, "filebot", args...)
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
@@ -59,6 +59,9 @@ type Publisher struct {
 	logger  *logging.Logger
 	opts    PublishOptions
 	filebot FilebotRunner // Injectable for testing
+
+	// toolLogs opens the log FileBot's full output is written to
+	toolLogs logging.ToolLogOpener
 }
 
 // NewPublisher creates a new Publisher
@@ -76,6 +79,12 @@ func (p *Publisher) SetFilebotRunner(runner FilebotRunner) {
 	p.filebot = runner
 }
 
+// SetToolLogs writes every FileBot run and its output to the "filebot" tool
+// log
+func (p *Publisher) SetToolLogs(open logging.ToolLogOpener) {
+	p.toolLogs = open
+}
+
 // buildFilebotArgs constructs FileBot CLI arguments
 func (p *Publisher) buildFilebotArgs(inputDir string, mediaType string, dbID int) []string {
 	var db, output, format string
@@ -124,9 +133,21 @@ func (p *Publisher) findExtras(inputDir string) []ExtraDir {
 	return extras
 }
 
-// runFilebot executes FileBot and returns the output
-func (p *Publisher) runFilebot(args []string) (string, error) {
-	return p.filebot.Run(args)
+// runFilebot executes FileBot and returns the output, which is also written
+// to the filebot tool log
+func (p *Publisher) runFilebot(ctx context.Context, args []string) (string, error) {
+	toolLog, err := p.toolLogs.Open("filebot")
+	if err != nil {
+		return "", err
+	}
+	defer toolLog.Close()
+
+	output, err := p.filebot.Run(ctx, args)
+	fmt.Fprintf(toolLog, "$ filebot %s\n%s", strings.Join(args, " "), output)
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		fmt.Fprintln(toolLog)
+	}
+	return output, err
 }
 
 // parseFilebotDestination extracts the library destination from FileBot output
@@ -231,7 +252,7 @@ func (p *Publisher) Publish(ctx context.Context, item *model.MediaItem, inputDir
 		p.logger.Info("Running FileBot: filebot %s", strings.Join(args, " "))
 	}
 
-	output, err := p.runFilebot(args)
+	output, err := p.runFilebot(ctx, args)
 	if err != nil {
 		return nil, fmt.Errorf("filebot failed: %w\nOutput: %s", err, output)
 	}
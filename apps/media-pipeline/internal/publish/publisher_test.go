@@ -3,6 +3,7 @@ package publish
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -105,6 +106,30 @@ func TestPublisher_RunFilebot_ParsesOutput(t *testing.T) {
 	}
 }
 
+func TestPublisher_RunFilebot_WritesToolLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "filebot.log")
+	p := NewPublisher(nil, nil, PublishOptions{})
+	p.SetFilebotRunner(&stubFilebotRunner{output: "[COPY] from [a.mkv] to [b.mkv]", err: fmt.Errorf("exit status 1")})
+	p.SetToolLogs(func(tool string) (io.WriteCloser, error) {
+		return os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.runFilebot(context.Background(), []string{"-rename", "/input"}); err == nil {
+			t.Fatal("runFilebot() error = nil, want FileBot's error")
+		}
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	run := "$ filebot -rename /input\n[COPY] from [a.mkv] to [b.mkv]\n"
+	if string(data) != run+run {
+		t.Errorf("filebot.log = %q, want both runs", data)
+	}
+}
+
 func TestPublisher_CopyExtras(t *testing.T) {
 	srcDir := t.TempDir()
 	dstDir := t.TempDir()
@@ -187,13 +212,23 @@ func containsAt(s, substr string) bool {
 	return false
 }
 
+// stubFilebotRunner returns fixed output
+type stubFilebotRunner struct {
+	output string
+	err    error
+}
+
+func (s *stubFilebotRunner) Run(ctx context.Context, args []string) (string, error) {
+	return s.output, s.err
+}
+
 // mockFilebotRunner simulates FileBot for tests
 type mockFilebotRunner struct {
 	copyFunc func(inputDir, outputDir string) error
 	destDir  string
 }
 
-func (m *mockFilebotRunner) Run(args []string) (string, error) {
+func (m *mockFilebotRunner) Run(ctx context.Context, args []string) (string, error) {
 	// Parse args to find input and output
 	var inputDir, outputDir string
 	for i, arg := range args {
@@ -238,7 +273,7 @@ type mockTVFilebotRunner struct {
 	destDir string
 }
 
-func (m *mockTVFilebotRunner) Run(args []string) (string, error) {
+func (m *mockTVFilebotRunner) Run(ctx context.Context, args []string) (string, error) {
 	// Parse args to find input and output
 	var inputDir, outputDir string
 	for i, arg := range args {
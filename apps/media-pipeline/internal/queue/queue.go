@@ -0,0 +1,90 @@
+// Package queue decides which pending jobs the daemon starts next: highest
+// priority first, oldest first within a priority, subject to per-host
+// concurrency limits and the daily window of each stage.
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// Limits maps a stage to the number of its jobs allowed to run at once on a
+// single worker host. Stages without an entry, or with a limit of 0, are unlimited.
+type Limits map[model.Stage]int
+
+// LimitsFromConfig builds limits from the concurrency section of the config
+func LimitsFromConfig(cfg *config.Config) Limits {
+	limits := make(Limits)
+	for _, stage := range []model.Stage{model.StageRip, model.StageRemux, model.StageTranscode, model.StagePublish} {
+		if limit := cfg.ConcurrencyLimit(stage.String()); limit > 0 {
+			limits[stage] = limit
+		}
+	}
+	return limits
+}
+
+// Windows maps a stage to the daily window its jobs may start in.
+// Stages without an entry may start at any time.
+type Windows map[model.Stage]config.Window
+
+// WindowsFromConfig builds windows from the windows section of the config
+func WindowsFromConfig(cfg *config.Config) Windows {
+	windows := make(Windows)
+	for _, stage := range []model.Stage{model.StageRip, model.StageRemux, model.StageTranscode, model.StagePublish} {
+		if w, ok := cfg.StageWindow(stage.String()); ok {
+			windows[stage] = w
+		}
+	}
+	return windows
+}
+
+// Queue selects pending jobs that have a free slot
+type Queue struct {
+	repo    db.Repository
+	limits  Limits
+	windows Windows
+	now     func() time.Time
+}
+
+// New creates a queue over the jobs table
+func New(repo db.Repository, limits Limits, windows Windows) *Queue {
+	return &Queue{repo: repo, limits: limits, windows: windows, now: time.Now}
+}
+
+// Ready returns the pending jobs that may start now, in dispatch order.
+// Retries still waiting out their backoff, and jobs whose stage is outside
+// its window, are not ready. Whether a job has a
+// free slot depends on the host it is routed to, and is checked by Claim.
+func (q *Queue) Ready(ctx context.Context) ([]model.Job, error) {
+	pending, err := q.repo.ListJobsByStatus(ctx, model.JobStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending jobs: %w", err)
+	}
+
+	now := q.now()
+	var ready []model.Job
+	for _, job := range pending {
+		if job.NotBefore != nil && job.NotBefore.After(now) {
+			continue
+		}
+		if w, ok := q.windows[job.Stage]; ok && !w.Contains(now) {
+			continue
+		}
+		ready = append(ready, job)
+	}
+
+	return ready, nil
+}
+
+// Claim starts a ready job on a worker if the worker has a free slot for the
+// job's stage. Jobs already in progress there count against the limit.
+// Returns false if the slot is taken or the job was claimed by someone else.
+func (q *Queue) Claim(ctx context.Context, job *model.Job, workerID string) (bool, error) {
+	// No PID until the stage binary starts and records its own
+	return q.repo.ClaimJob(ctx, job.ID, workerID, 0, q.limits[job.Stage])
+}
@@ -0,0 +1,202 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func newTestRepo(t *testing.T) *db.SQLiteRepository {
+	t.Helper()
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return db.NewSQLiteRepository(database)
+}
+
+// createJob creates a movie with a single job in the given stage and status
+func createJob(t *testing.T, repo db.Repository, stage model.Stage, status model.JobStatus, priority int) *model.Job {
+	t.Helper()
+	ctx := context.Background()
+	item := &model.MediaItem{
+		Type:     model.MediaTypeMovie,
+		Name:     "Movie",
+		SafeName: "Movie",
+	}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	job := &model.Job{
+		MediaItemID: item.ID,
+		Stage:       stage,
+		Status:      status,
+		Priority:    priority,
+	}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	return job
+}
+
+func jobIDs(jobs []model.Job) []int64 {
+	ids := make([]int64, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	return ids
+}
+
+func TestQueue_Ready_PriorityThenFIFO(t *testing.T) {
+	repo := newTestRepo(t)
+	first := createJob(t, repo, model.StageRemux, model.JobStatusPending, 0)
+	urgent := createJob(t, repo, model.StageRemux, model.JobStatusPending, 10)
+	second := createJob(t, repo, model.StageRemux, model.JobStatusPending, 0)
+
+	ready, err := New(repo, nil, nil).Ready(context.Background())
+	if err != nil {
+		t.Fatalf("Ready() error = %v", err)
+	}
+
+	got := jobIDs(ready)
+	want := []int64{urgent.ID, first.ID, second.ID}
+	if len(got) != len(want) {
+		t.Fatalf("Ready() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Ready() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestQueue_Claim_PerHostLimits(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	running := createJob(t, repo, model.StageTranscode, model.JobStatusPending, 0)
+	q := New(repo, Limits{model.StageTranscode: 1}, nil)
+	if claimed, err := q.Claim(ctx, running, "analyzer"); err != nil || !claimed {
+		t.Fatalf("Claim() = %v, %v; want the free slot", claimed, err)
+	}
+
+	tests := []struct {
+		name     string
+		stage    model.Stage
+		workerID string
+		want     bool
+	}{
+		{"slot taken on the same host", model.StageTranscode, "analyzer", false},
+		{"free slot on another host", model.StageTranscode, "media@spare", true},
+		{"other stages have their own limit", model.StageRemux, "analyzer", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := createJob(t, repo, tt.stage, model.JobStatusPending, 0)
+			claimed, err := q.Claim(ctx, job, tt.workerID)
+			if err != nil {
+				t.Fatalf("Claim() error = %v", err)
+			}
+			if claimed != tt.want {
+				t.Errorf("Claim() = %v, want %v", claimed, tt.want)
+			}
+			got, _ := repo.GetJob(ctx, job.ID)
+			if wantStatus := map[bool]model.JobStatus{true: model.JobStatusInProgress, false: model.JobStatusPending}[tt.want]; got.Status != wantStatus {
+				t.Errorf("job status = %q, want %q", got.Status, wantStatus)
+			}
+		})
+	}
+}
+
+func TestQueue_Ready_SkipsRetriesInBackoff(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	waiting := createJob(t, repo, model.StageRemux, model.JobStatusPending, 0)
+	later := now.Add(time.Minute)
+	waiting.NotBefore = &later
+	if err := repo.UpdateJob(ctx, waiting); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+	due := createJob(t, repo, model.StageRemux, model.JobStatusPending, 0)
+	earlier := now.Add(-time.Minute)
+	due.NotBefore = &earlier
+	if err := repo.UpdateJob(ctx, due); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+
+	q := New(repo, nil, nil)
+	q.now = func() time.Time { return now }
+	ready, err := q.Ready(ctx)
+	if err != nil {
+		t.Fatalf("Ready() error = %v", err)
+	}
+
+	if got := jobIDs(ready); len(got) != 1 || got[0] != due.ID {
+		t.Errorf("Ready() = %v, want [%d]", got, due.ID)
+	}
+}
+
+func TestQueue_Ready_HoldsJobsOutsideWindow(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	transcode := createJob(t, repo, model.StageTranscode, model.JobStatusPending, 0)
+	remux := createJob(t, repo, model.StageRemux, model.JobStatusPending, 0)
+
+	night, err := config.ParseWindow("23:00-07:00")
+	if err != nil {
+		t.Fatalf("ParseWindow() error = %v", err)
+	}
+	q := New(repo, nil, Windows{model.StageTranscode: night})
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want []int64
+	}{
+		{"daytime", time.Date(2026, 1, 2, 14, 0, 0, 0, time.Local), []int64{remux.ID}},
+		{"after midnight", time.Date(2026, 1, 2, 3, 0, 0, 0, time.Local), []int64{transcode.ID, remux.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q.now = func() time.Time { return tt.now }
+			ready, err := q.Ready(ctx)
+			if err != nil {
+				t.Fatalf("Ready() error = %v", err)
+			}
+			got := jobIDs(ready)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Ready() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Ready() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimitsFromConfig(t *testing.T) {
+	cfg := &config.Config{Concurrency: map[string]int{"remux": 2, "rip": 0}}
+	limits := LimitsFromConfig(cfg)
+
+	want := Limits{model.StageRemux: 2, model.StageTranscode: 1}
+	if len(limits) != len(want) {
+		t.Fatalf("LimitsFromConfig() = %v, want %v", limits, want)
+	}
+	for stage, limit := range want {
+		if limits[stage] != limit {
+			t.Errorf("limit for %s = %d, want %d", stage, limits[stage], limit)
+		}
+	}
+}
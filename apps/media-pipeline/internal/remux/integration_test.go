@@ -32,7 +32,7 @@ func TestRemuxer_Integration(t *testing.T) {
 	}
 
 	// Verify input has expected tracks
-	inputInfo, err := GetTrackInfo(inputPath)
+	inputInfo, err := GetTrackInfo(context.Background(), inputPath)
 	if err != nil {
 		t.Fatalf("GetTrackInfo(input) error: %v", err)
 	}
@@ -55,7 +55,7 @@ func TestRemuxer_Integration(t *testing.T) {
 
 	// Verify output
 	outputPath := filepath.Join(outputDir, "_main", "movie.mkv")
-	outputInfo, err := GetTrackInfo(outputPath)
+	outputInfo, err := GetTrackInfo(context.Background(), outputPath)
 	if err != nil {
 		t.Fatalf("GetTrackInfo(output) error: %v", err)
 	}
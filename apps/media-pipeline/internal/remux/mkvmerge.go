@@ -1,8 +1,11 @@
 package remux
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 )
@@ -44,8 +47,8 @@ type mkvmergeJSON struct {
 }
 
 // GetTrackInfo runs mkvmerge -J on a file and returns parsed track info
-func GetTrackInfo(path string) (*TrackInfo, error) {
-	cmd := exec.Command("mkvmerge", "-J", path)
+func GetTrackInfo(ctx context.Context, path string) (*TrackInfo, error) {
+	cmd := exec.CommandContext(ctx, "mkvmerge", "-J", path)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("mkvmerge -J failed: %w", err)
@@ -155,12 +158,17 @@ func BuildMkvmergeArgs(inputPath, outputPath string, tracks *TrackInfo) []string
 	return args
 }
 
-// RunMkvmerge executes mkvmerge with the given arguments
-func RunMkvmerge(args []string) error {
-	cmd := exec.Command("mkvmerge", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("mkvmerge failed: %w\nOutput: %s", err, string(output))
+// RunMkvmerge executes mkvmerge with the given arguments, writing the command
+// and all its output to toolLog. Cancelling ctx kills mkvmerge.
+func RunMkvmerge(ctx context.Context, args []string, toolLog io.Writer) error {
+	fmt.Fprintf(toolLog, "$ mkvmerge %s\n", strings.Join(args, " "))
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "mkvmerge", args...)
+	cmd.Stdout = io.MultiWriter(&output, toolLog)
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("mkvmerge failed: %w\nOutput: %s", err, output.String())
 	}
 	return nil
 }
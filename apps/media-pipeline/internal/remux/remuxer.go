@@ -6,11 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/cuivienor/media-pipeline/internal/logging"
 )
 
 // Remuxer handles MKV file remuxing with track filtering
 type Remuxer struct {
 	languages []string
+	toolLogs  logging.ToolLogOpener
 }
 
 // NewRemuxer creates a new Remuxer with the specified language filters
@@ -18,6 +21,12 @@ func NewRemuxer(languages []string) *Remuxer {
 	return &Remuxer{languages: languages}
 }
 
+// SetToolLogs writes mkvmerge's full output to the "mkvmerge" tool log.
+// Without it the output is only kept in the error of a failed run.
+func (r *Remuxer) SetToolLogs(open logging.ToolLogOpener) {
+	r.toolLogs = open
+}
+
 // RemuxResult contains statistics about a remux operation
 type RemuxResult struct {
 	InputPath     string
@@ -37,7 +46,7 @@ type TrackCounts struct {
 // RemuxFile remuxes a single MKV file, filtering tracks by language
 func (r *Remuxer) RemuxFile(ctx context.Context, inputPath, outputPath string) (*RemuxResult, error) {
 	// Get track info from input
-	inputInfo, err := GetTrackInfo(inputPath)
+	inputInfo, err := GetTrackInfo(ctx, inputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze %s: %w", inputPath, err)
 	}
@@ -51,8 +60,13 @@ func (r *Remuxer) RemuxFile(ctx context.Context, inputPath, outputPath string) (
 	}
 
 	// Build and run mkvmerge
+	toolLog, err := r.toolLogs.Open("mkvmerge")
+	if err != nil {
+		return nil, err
+	}
+	defer toolLog.Close()
 	args := BuildMkvmergeArgs(inputPath, outputPath, filteredInfo)
-	if err := RunMkvmerge(args); err != nil {
+	if err := RunMkvmerge(ctx, args, toolLog); err != nil {
 		return nil, err
 	}
 
@@ -98,6 +112,9 @@ func (r *Remuxer) RemuxDirectory(ctx context.Context, inputDir, outputDir string
 	}
 
 	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
 		if entry.IsDir() {
 			continue
 		}
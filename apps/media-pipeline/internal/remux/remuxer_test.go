@@ -2,9 +2,12 @@ package remux
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -41,12 +44,21 @@ func TestRemuxer_RemuxFile(t *testing.T) {
 	}
 
 	remuxer := NewRemuxer([]string{"eng", "bul"})
+	logPath := filepath.Join(tmpDir, "mkvmerge.log")
+	remuxer.SetToolLogs(func(tool string) (io.WriteCloser, error) {
+		return os.Create(logPath)
+	})
 
 	result, err := remuxer.RemuxFile(context.Background(), inputPath, outputPath)
 	if err != nil {
 		t.Fatalf("RemuxFile() error = %v", err)
 	}
 
+	// Verify mkvmerge's output went to its tool log
+	if data, err := os.ReadFile(logPath); err != nil || !strings.HasPrefix(string(data), "$ mkvmerge ") {
+		t.Errorf("mkvmerge.log = %q, %v; want the command and its output", data, err)
+	}
+
 	// Verify result fields
 	if result.InputPath != inputPath {
 		t.Errorf("InputPath = %q, want %q", result.InputPath, inputPath)
@@ -293,3 +305,26 @@ func generateSimpleTestMKV(outputPath string) error {
 	_ = output // Ignore output for now
 	return nil
 }
+
+func TestRemuxer_RemuxDirectory_Cancelled(t *testing.T) {
+	inputDir := t.TempDir()
+	mainDir := filepath.Join(inputDir, "_main")
+	if err := os.MkdirAll(mainDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mainDir, "movie.mkv"), []byte("not really mkv"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	remuxer := NewRemuxer([]string{"eng"})
+	results, err := remuxer.RemuxDirectory(ctx, inputDir, t.TempDir(), false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RemuxDirectory() error = %v, want context.Canceled", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("RemuxDirectory() processed %d files after cancellation", len(results))
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+
+	"github.com/cuivienor/media-pipeline/internal/logging"
 )
 
 // DefaultMakeMKVRunner executes makemkvcon commands
@@ -14,6 +16,8 @@ type DefaultMakeMKVRunner struct {
 	makemkvconPath string
 	// execCommand allows injection of command execution for testing
 	execCommand func(ctx context.Context, name string, args ...string) *exec.Cmd
+	// toolLogs opens the log makemkvcon's full output is written to
+	toolLogs logging.ToolLogOpener
 }
 
 // NewMakeMKVRunner creates a new MakeMKV runner
@@ -28,12 +32,25 @@ func NewMakeMKVRunner(makemkvconPath string) *DefaultMakeMKVRunner {
 	}
 }
 
+// SetToolLogs writes makemkvcon's full output to the "makemkv" tool log.
+// Without it the output is only parsed.
+func (r *DefaultMakeMKVRunner) SetToolLogs(open logging.ToolLogOpener) {
+	r.toolLogs = open
+}
+
 // GetDiscInfo retrieves information about a disc
 func (r *DefaultMakeMKVRunner) GetDiscInfo(ctx context.Context, discPath string) (*DiscInfo, error) {
 	args := r.buildInfoArgs(discPath)
 
 	cmd := r.execCommand(ctx, r.makemkvconPath, args...)
 
+	toolLog, err := r.toolLogs.Open("makemkv")
+	if err != nil {
+		return nil, err
+	}
+	defer toolLog.Close()
+	cmd.Stderr = toolLog
+
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
@@ -46,6 +63,7 @@ func (r *DefaultMakeMKVRunner) GetDiscInfo(ctx context.Context, discPath string)
 	parser := NewMakeMKVParser()
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
+		fmt.Fprintln(toolLog, scanner.Text())
 		parser.ParseLine(scanner.Text())
 	}
 
@@ -79,6 +97,13 @@ func (r *DefaultMakeMKVRunner) ripTitle(ctx context.Context, discPath, outputDir
 
 	cmd := r.execCommand(ctx, r.makemkvconPath, args...)
 
+	toolLog, err := r.toolLogs.Open("makemkv")
+	if err != nil {
+		return err
+	}
+	defer toolLog.Close()
+	cmd.Stderr = toolLog
+
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
@@ -91,6 +116,7 @@ func (r *DefaultMakeMKVRunner) ripTitle(ctx context.Context, discPath, outputDir
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
 		line := scanner.Text()
+		fmt.Fprintln(toolLog, line)
 		if onLine != nil {
 			onLine(line)
 		}
@@ -110,6 +136,13 @@ func (r *DefaultMakeMKVRunner) ripAllTitles(ctx context.Context, discPath, outpu
 
 	cmd := r.execCommand(ctx, r.makemkvconPath, args...)
 
+	toolLog, err := r.toolLogs.Open("makemkv")
+	if err != nil {
+		return err
+	}
+	defer toolLog.Close()
+	cmd.Stderr = toolLog
+
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
@@ -122,6 +155,7 @@ func (r *DefaultMakeMKVRunner) ripAllTitles(ctx context.Context, discPath, outpu
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
 		line := scanner.Text()
+		fmt.Fprintln(toolLog, line)
 		if onLine != nil {
 			onLine(line)
 		}
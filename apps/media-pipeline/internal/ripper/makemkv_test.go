@@ -2,6 +2,7 @@ package ripper
 
 import (
 	"context"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -148,6 +149,39 @@ func TestNewMakeMKVRunner_CustomPath(t *testing.T) {
 	}
 }
 
+func TestDefaultMakeMKVRunner_RipTitles_WritesToolLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "makemkv.log")
+
+	runner := &DefaultMakeMKVRunner{
+		execCommand: func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			return exec.CommandContext(ctx, "sh", "-c", `echo 'MSG:5011,0,0,"Operation successfully completed"'; echo 'read error' >&2`)
+		},
+	}
+	var tools []string
+	runner.SetToolLogs(func(tool string) (io.WriteCloser, error) {
+		tools = append(tools, tool)
+		return os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	})
+
+	if err := runner.RipTitles(context.Background(), "disc:0", tmpDir, nil, nil, nil); err != nil {
+		t.Fatalf("RipTitles failed: %v", err)
+	}
+
+	if len(tools) != 1 || tools[0] != "makemkv" {
+		t.Errorf("opened tool logs %v, want [makemkv]", tools)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{"Operation successfully completed", "read error"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("makemkv.log = %q, missing %q", data, want)
+		}
+	}
+}
+
 // Integration test - only runs if mock-makemkv is available
 func TestDefaultMakeMKVRunner_Integration_WithMock(t *testing.T) {
 	// Look for mock-makemkv in bin/ or PATH
@@ -4,12 +4,15 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
 )
 
 // TranscodeOptions configures the transcoding operation
@@ -19,6 +22,7 @@ type TranscodeOptions struct {
 	Preset      string // libx265 preset
 	HWPreset    string // QSV preset
 	DurationSec float64
+	Window      *config.Window // Daily window to transcode in, paused between files outside it; nil means any time
 }
 
 // ProgressCallback is called with progress updates (0-100)
@@ -27,8 +31,9 @@ type ProgressCallback func(percent int)
 // timeRegex matches ffmpeg's time= output
 var timeRegex = regexp.MustCompile(`time=(\d{2}):(\d{2}):(\d{2})\.(\d{2})`)
 
-// TranscodeFile transcodes a single file using ffmpeg
-func TranscodeFile(ctx context.Context, inputPath, outputPath string, opts TranscodeOptions, onProgress ProgressCallback) error {
+// TranscodeFile transcodes a single file using ffmpeg, writing the command and
+// all of ffmpeg's output to toolLog
+func TranscodeFile(ctx context.Context, inputPath, outputPath string, opts TranscodeOptions, toolLog io.Writer, onProgress ProgressCallback) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
@@ -37,6 +42,8 @@ func TranscodeFile(ctx context.Context, inputPath, outputPath string, opts Trans
 	args := buildFFmpegArgs(inputPath, outputPath, opts)
 
 	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	fmt.Fprintf(toolLog, "$ ffmpeg %s\n", strings.Join(args, " "))
+	cmd.Stdout = toolLog
 
 	// ffmpeg writes progress to stderr
 	stderr, err := cmd.StderrPipe()
@@ -50,7 +57,7 @@ func TranscodeFile(ctx context.Context, inputPath, outputPath string, opts Trans
 
 	// Parse progress from stderr
 	lastPercent := 0
-	scanner := bufio.NewScanner(stderr)
+	scanner := bufio.NewScanner(io.TeeReader(stderr, toolLog))
 	scanner.Split(scanFFmpegLines)
 
 	for scanner.Scan() {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/logging"
 	"github.com/cuivienor/media-pipeline/internal/model"
 )
 
@@ -25,6 +26,10 @@ func (l *testLogger) Error(format string, args ...interface{}) {
 	l.t.Logf("[ERROR] "+format, args...)
 }
 
+func (l *testLogger) Event(level logging.Level, msg string) {
+	l.t.Logf("[%s] %s", level, msg)
+}
+
 func TestTranscoder_Integration(t *testing.T) {
 	// Skip if ffmpeg not available
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/logging"
 	"github.com/cuivienor/media-pipeline/internal/model"
 )
 
@@ -15,13 +17,16 @@ import (
 type Logger interface {
 	Info(format string, args ...interface{})
 	Error(format string, args ...interface{})
+	Event(level logging.Level, msg string)
 }
 
 // Transcoder handles video transcoding operations
 type Transcoder struct {
-	repo   db.Repository
-	logger Logger
-	opts   TranscodeOptions
+	repo     db.Repository
+	logger   Logger
+	opts     TranscodeOptions
+	now      func() time.Time
+	toolLogs logging.ToolLogOpener
 }
 
 // NewTranscoder creates a new Transcoder
@@ -30,9 +35,16 @@ func NewTranscoder(repo db.Repository, logger Logger, opts TranscodeOptions) *Tr
 		repo:   repo,
 		logger: logger,
 		opts:   opts,
+		now:    time.Now,
 	}
 }
 
+// SetToolLogs writes each file's ffmpeg output to its own "ffmpeg-<file>"
+// tool log. Without it the output is only scanned for progress.
+func (t *Transcoder) SetToolLogs(open logging.ToolLogOpener) {
+	t.toolLogs = open
+}
+
 // TranscodeJob processes all files for a transcode job
 func (t *Transcoder) TranscodeJob(ctx context.Context, job *model.Job, inputDir, outputDir string, isTV bool) error {
 	// Build queue of files to process
@@ -74,20 +86,29 @@ func (t *Transcoder) TranscodeJob(ctx context.Context, job *model.Job, inputDir,
 			continue
 		}
 
+		if err := t.waitForWindow(ctx); err != nil {
+			t.logger.Info("Cancelled while paused")
+			return err
+		}
+
 		inputPath := filepath.Join(inputDir, file.RelativePath)
 		outputPath := filepath.Join(outputDir, file.RelativePath)
 
 		t.logger.Info("[%d/%d] Transcoding: %s", i+1, len(files), file.RelativePath)
 
 		if err := t.transcodeFile(ctx, &file, inputPath, outputPath); err != nil {
+			if ctx.Err() != nil {
+				t.logger.Info("Cancelled: %s", file.RelativePath)
+				return ctx.Err()
+			}
 			t.logger.Error("Failed: %s - %v", file.RelativePath, err)
 			lastErr = err
 			// Continue with other files
 		} else {
 			ratio := file.CompressionRatio()
 			savedMB := file.SizeSaved() / (1024 * 1024)
-			t.logger.Info("Completed: %s (%.1f%% of original, saved %dMB)",
-				file.RelativePath, ratio*100, savedMB)
+			t.logger.Event(logging.LevelInfo, fmt.Sprintf("Completed: %s (%.1f%% of original, saved %dMB)",
+				file.RelativePath, ratio*100, savedMB))
 		}
 	}
 
@@ -97,6 +118,31 @@ func (t *Transcoder) TranscodeJob(ctx context.Context, job *model.Job, inputDir,
 	return lastErr
 }
 
+// waitForWindow blocks while the transcode window is closed, so a job that
+// runs past the end of its window pauses between files until it reopens
+func (t *Transcoder) waitForWindow(ctx context.Context) error {
+	w := t.opts.Window
+	if w == nil {
+		return nil
+	}
+	now := t.now()
+	if w.Contains(now) {
+		return nil
+	}
+
+	open := w.NextOpen(now)
+	t.logger.Info("Outside transcode window %s, pausing until %s", w, open.Format("Mon 15:04"))
+	timer := time.NewTimer(open.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+	t.logger.Info("Transcode window open, resuming")
+	return nil
+}
+
 // buildQueue discovers files and creates/updates database records
 func (t *Transcoder) buildQueue(ctx context.Context, jobID int64, inputDir string, isTV bool) ([]model.TranscodeFile, error) {
 	// Check for existing files in database (resume case)
@@ -173,6 +219,12 @@ func (t *Transcoder) transcodeFile(ctx context.Context, file *model.TranscodeFil
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	toolLog, err := t.toolLogs.Open("ffmpeg-" + strings.TrimSuffix(file.RelativePath, filepath.Ext(file.RelativePath)))
+	if err != nil {
+		return err
+	}
+	defer toolLog.Close()
+
 	// Mark as in progress
 	if err := t.repo.UpdateTranscodeFileStatus(ctx, file.ID, model.TranscodeFileStatusInProgress, ""); err != nil {
 		return err
@@ -186,15 +238,22 @@ func (t *Transcoder) transcodeFile(ctx context.Context, file *model.TranscodeFil
 	lastProgress := 0
 
 	// Run ffmpeg with progress callback
-	err := TranscodeFile(ctx, inputPath, outputPath, opts, func(percent int) {
+	err = TranscodeFile(ctx, inputPath, outputPath, opts, toolLog, func(percent int) {
 		// Only update on 1% increments
 		if percent > lastProgress {
 			lastProgress = percent
-			t.repo.UpdateTranscodeFileProgress(ctx, file.ID, percent)
+			if err := t.repo.UpdateTranscodeFileProgress(ctx, file.ID, percent); err != nil {
+				t.logger.Error("Failed to record progress: %v", err)
+			}
 		}
 	})
 
 	if err != nil {
+		if ctx.Err() != nil {
+			// Cancelled: leave the file to be picked up again on the next run
+			t.repo.UpdateTranscodeFileStatus(context.WithoutCancel(ctx), file.ID, model.TranscodeFileStatusPending, "")
+			return ctx.Err()
+		}
 		t.repo.UpdateTranscodeFileStatus(ctx, file.ID, model.TranscodeFileStatusFailed, err.Error())
 		return err
 	}
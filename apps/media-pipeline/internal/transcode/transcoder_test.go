@@ -1,7 +1,12 @@
 package transcode
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
 )
 
 func TestTranscoder_BuildQueue(t *testing.T) {
@@ -9,3 +14,31 @@ func TestTranscoder_BuildQueue(t *testing.T) {
 	// For now, just verify the package compiles
 	t.Log("Transcoder package compiles correctly")
 }
+
+func TestTranscoder_WaitForWindow(t *testing.T) {
+	night := config.Window{Start: 23 * time.Hour, End: 7 * time.Hour}
+
+	tests := []struct {
+		name    string
+		window  *config.Window
+		now     time.Time
+		wantErr error
+	}{
+		{"no window", nil, time.Date(2026, 1, 2, 14, 0, 0, 0, time.Local), nil},
+		{"inside window", &night, time.Date(2026, 1, 2, 2, 0, 0, 0, time.Local), nil},
+		{"outside window pauses until cancelled", &night, time.Date(2026, 1, 2, 14, 0, 0, 0, time.Local), context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTranscoder(nil, &testLogger{t}, TranscodeOptions{Window: tt.window})
+			tr.now = func() time.Time { return tt.now }
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if err := tr.waitForWindow(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("waitForWindow() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
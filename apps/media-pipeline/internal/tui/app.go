@@ -6,6 +6,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cuivienor/media-pipeline/internal/config"
 	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
 	"github.com/cuivienor/media-pipeline/internal/model"
 )
 
@@ -18,6 +19,9 @@ const (
 	ViewSeasonDetail             // Season detail for TV
 	ViewOrganize                 // File organization view
 	ViewNewItem                  // Create new item form
+	ViewWorkers                  // Worker registry
+	ViewSearch                   // Search prompt and results
+	ViewJobLog                   // A job's log events and log file
 )
 
 // App is the main application model
@@ -27,6 +31,9 @@ type App struct {
 	state  *AppState
 	err    error
 
+	// Retry policies from the config, for "attempt N of M" labels
+	retryPolicies jobs.RetryPolicies
+
 	// Navigation state
 	currentView    View
 	selectedItem   *model.MediaItem
@@ -42,15 +49,25 @@ type App struct {
 
 	// Organize view state
 	organizeView *OrganizeView
+
+	// Search view state
+	search *SearchView
+
+	// Job log view state
+	jobLog *JobLogView
 }
 
 // NewApp creates a new application instance
 func NewApp(cfg *config.Config, repo db.Repository) *App {
-	return &App{
+	app := &App{
 		config:      cfg,
 		repo:        repo,
 		currentView: ViewItemList,
 	}
+	if cfg != nil {
+		app.retryPolicies = jobs.RetryPoliciesFromConfig(cfg)
+	}
+	return app
 }
 
 // Init implements tea.Model
@@ -164,6 +181,43 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// Stay on current view but refresh state
 		return a, a.loadState
+
+	case autopilotToggledMsg:
+		if msg.err != nil {
+			a.err = msg.err
+			return a, nil
+		}
+		return a, a.loadState
+
+	case jobPriorityChangedMsg:
+		if msg.err != nil {
+			a.err = msg.err
+			return a, nil
+		}
+		return a, a.loadState
+
+	case jobCancelledMsg:
+		if msg.err != nil {
+			a.err = msg.err
+			return a, nil
+		}
+		// Running jobs record the cancellation once their process exits
+		return a, a.loadState
+
+	case searchResultsMsg:
+		// Results of a query since edited are dropped
+		if a.search != nil && a.search.Query == msg.query {
+			a.search.Results = msg.results
+			a.search.err = msg.err
+			a.search.cursor = 0
+		}
+		return a, nil
+
+	case jobLogLoadedMsg:
+		return a, a.handleJobLogLoaded(msg)
+
+	case jobLogTickMsg:
+		return a, a.handleJobLogTick(msg)
 	}
 
 	return a, nil
@@ -181,6 +235,16 @@ func (a *App) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return a.handleOrganizeKey(msg)
 	}
 
+	// Route to search handler if in Search view
+	if a.currentView == ViewSearch && a.search != nil {
+		return a.handleSearchKey(msg)
+	}
+
+	// Route to job log handler if in JobLog view
+	if a.currentView == ViewJobLog && a.jobLog != nil {
+		return a.handleJobLogKey(msg)
+	}
+
 	switch msg.String() {
 	case "q", "ctrl+c":
 		return a, tea.Quit
@@ -189,6 +253,21 @@ func (a *App) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Refresh state
 		return a, a.loadState
 
+	case "w":
+		// Workers panel (only from item list view)
+		if a.currentView == ViewItemList {
+			a.currentView = ViewWorkers
+			return a, a.loadState
+		}
+
+	case "/":
+		// Search (only from item list view)
+		if a.currentView == ViewItemList {
+			a.currentView = ViewSearch
+			a.search = &SearchView{}
+			return a, nil
+		}
+
 	case "n":
 		// New item (only from item list view)
 		if a.currentView == ViewItemList {
@@ -232,6 +311,38 @@ func (a *App) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case "x":
+		// Cancel the active job - works for movies (item detail) and TV seasons (season detail)
+		if job := a.selectedActiveJob(); job != nil {
+			return a, a.cancelJob(job)
+		}
+
+	case "+", "=", "-":
+		// Raise or lower the priority of the queued job
+		if job := a.selectedActiveJob(); job != nil && job.Status == model.JobStatusPending {
+			delta := 1
+			if msg.String() == "-" {
+				delta = -1
+			}
+			return a, a.changeJobPriority(job, delta)
+		}
+
+	case "l":
+		// Job log - the active or latest job of a movie (item detail) or TV season (season detail)
+		if job := a.selectedLogJob(); job != nil {
+			return a, a.openJobLog(job)
+		}
+
+	case "p":
+		// Toggle autopilot - movie or whole TV show (item detail), single season (season detail).
+		// A season can't be toggled while the whole show is on autopilot.
+		if a.currentView == ViewItemDetail && a.selectedItem != nil {
+			return a, a.toggleItemAutopilot(a.selectedItem)
+		}
+		if a.currentView == ViewSeasonDetail && a.selectedSeason != nil && a.selectedItem != nil && !a.selectedItem.Autopilot {
+			return a, a.toggleSeasonAutopilot(a.selectedSeason)
+		}
+
 	case "o":
 		// Organize - works for movies (item detail) and TV seasons (season detail)
 		if a.currentView == ViewItemDetail && a.selectedItem != nil {
@@ -275,7 +386,7 @@ func (a *App) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.currentView = ViewItemDetail
 			a.selectedSeason = nil
 			a.cursor = 0
-		case ViewNewItem:
+		case ViewNewItem, ViewWorkers:
 			a.currentView = ViewItemList
 			a.cursor = 0
 		case ViewOrganize:
@@ -415,6 +526,12 @@ func (a *App) View() string {
 		return a.renderNewItemForm()
 	case ViewOrganize:
 		return a.renderOrganizeView()
+	case ViewWorkers:
+		return a.renderWorkers()
+	case ViewSearch:
+		return a.renderSearch()
+	case ViewJobLog:
+		return a.renderJobLog()
 	default:
 		return "Unknown view"
 	}
@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// attemptLogLines is how many log events are shown under a failed attempt
+const attemptLogLines = 3
+
+// maxAttempts returns how many attempts the retry policy allows for a stage
+func (a *App) maxAttempts(stage model.Stage) int {
+	if policy, ok := a.retryPolicies[stage]; ok {
+		return policy.MaxAttempts
+	}
+	return 1
+}
+
+// attemptLabel describes a job's attempt for the history list, e.g.
+// " · attempt 3 of 3 failed: exit status 1". Stages that are never retried
+// only show the failure.
+func attemptLabel(job *model.Job, maxAttempts int, now time.Time) string {
+	var parts []string
+	if total := max(maxAttempts, job.Attempt); total > 1 {
+		parts = append(parts, fmt.Sprintf("attempt %d of %d", job.Attempt, total))
+	}
+
+	switch {
+	case job.Status == model.JobStatusFailed:
+		parts = append(parts, "failed: "+job.ErrorMessage)
+	case job.Status == model.JobStatusPending && job.NotBefore != nil && job.NotBefore.After(now):
+		parts = append(parts, "retrying at "+job.NotBefore.Local().Format("15:04"))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return " · " + strings.Join(parts, " ")
+}
+
+// renderAttemptLog renders the last log events of a failed attempt
+func (a *App) renderAttemptLog(job *model.Job) string {
+	if job.Status != model.JobStatusFailed || a.state == nil {
+		return ""
+	}
+
+	events := a.state.AttemptLogs[job.ID]
+
+	var b strings.Builder
+	// Events come newest first
+	for i := len(events) - 1; i >= 0; i-- {
+		b.WriteString(mutedItemStyle.Render(fmt.Sprintf("      %s %s", events[i].Level, events[i].Message)))
+		b.WriteString("\n")
+	}
+	if job.LogPath != "" {
+		b.WriteString(mutedItemStyle.Render("      log: " + job.LogPath))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestAttemptLabel(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	later := time.Date(2026, 1, 2, 3, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name        string
+		job         model.Job
+		maxAttempts int
+		want        string
+	}{
+		{
+			name:        "completed single attempt",
+			job:         model.Job{Status: model.JobStatusCompleted, Attempt: 1},
+			maxAttempts: 1,
+			want:        "",
+		},
+		{
+			name:        "failed stage without retries",
+			job:         model.Job{Status: model.JobStatusFailed, Attempt: 1, ErrorMessage: "no disc"},
+			maxAttempts: 1,
+			want:        " · failed: no disc",
+		},
+		{
+			name:        "last attempt failed",
+			job:         model.Job{Status: model.JobStatusFailed, Attempt: 3, ErrorMessage: "exit status 1"},
+			maxAttempts: 3,
+			want:        " · attempt 3 of 3 failed: exit status 1",
+		},
+		{
+			name:        "retry waiting out backoff",
+			job:         model.Job{Status: model.JobStatusPending, Attempt: 2, NotBefore: &later},
+			maxAttempts: 3,
+			want:        " · attempt 2 of 3 retrying at 03:30",
+		},
+		{
+			name:        "more attempts than the current policy allows",
+			job:         model.Job{Status: model.JobStatusCompleted, Attempt: 4},
+			maxAttempts: 2,
+			want:        " · attempt 4 of 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attemptLabel(&tt.job, tt.maxAttempts, now); got != tt.want {
+				t.Errorf("attemptLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// autopilotToggledMsg is sent when autopilot has been switched on or off
+type autopilotToggledMsg struct {
+	err error
+}
+
+// toggleItemAutopilot switches autopilot for a movie, or for every season of a TV show
+func (a *App) toggleItemAutopilot(item *model.MediaItem) tea.Cmd {
+	id, enabled := item.ID, !item.Autopilot
+	return func() tea.Msg {
+		err := a.repo.SetMediaItemAutopilot(context.Background(), id, enabled)
+		return autopilotToggledMsg{err: err}
+	}
+}
+
+// toggleSeasonAutopilot switches autopilot for a single season
+func (a *App) toggleSeasonAutopilot(season *model.Season) tea.Cmd {
+	id, enabled := season.ID, !season.Autopilot
+	return func() tea.Msg {
+		err := a.repo.SetSeasonAutopilot(context.Background(), id, enabled)
+		return autopilotToggledMsg{err: err}
+	}
+}
+
+// autopilotLabel describes the autopilot setting for display
+func autopilotLabel(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// jobCancelledMsg is sent when a cancel request has been handled
+type jobCancelledMsg struct {
+	err error
+}
+
+// activeJob returns the most recent pending or running job, or nil if there is none
+func activeJob(jobList []model.Job) *model.Job {
+	for i := len(jobList) - 1; i >= 0; i-- {
+		if jobList[i].IsActive() {
+			return &jobList[i]
+		}
+	}
+	return nil
+}
+
+// selectedActiveJob returns the active job of the movie or season being viewed
+func (a *App) selectedActiveJob() *model.Job {
+	if a.state == nil {
+		return nil
+	}
+	if a.currentView == ViewItemDetail && a.selectedItem != nil && a.selectedItem.Type == model.MediaTypeMovie {
+		return activeJob(a.state.MovieJobs[a.selectedItem.ID])
+	}
+	if a.currentView == ViewSeasonDetail && a.selectedSeason != nil {
+		return activeJob(a.state.SeasonJobs[a.selectedSeason.ID])
+	}
+	return nil
+}
+
+// cancelJob cancels a queued job, or signals the process running it
+func (a *App) cancelJob(job *model.Job) tea.Cmd {
+	// Copy: the state the job points into is replaced on refresh
+	j := *job
+	return func() tea.Msg {
+		err := jobs.Cancel(context.Background(), a.repo, a.config, &j)
+		return jobCancelledMsg{err: err}
+	}
+}
+
+// jobStatusIcon returns the history icon for a job status
+func jobStatusIcon(status model.JobStatus) string {
+	switch status {
+	case model.JobStatusCompleted:
+		return "✓"
+	case model.JobStatusInProgress:
+		return "◐"
+	case model.JobStatusFailed:
+		return "✗"
+	case model.JobStatusCancelled:
+		return "⊘"
+	default:
+		return "○"
+	}
+}
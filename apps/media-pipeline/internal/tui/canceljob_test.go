@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestActiveJob(t *testing.T) {
+	tests := []struct {
+		name   string
+		jobs   []model.Job
+		wantID int64
+	}{
+		{
+			name:   "no jobs",
+			jobs:   nil,
+			wantID: 0,
+		},
+		{
+			name: "only finished jobs",
+			jobs: []model.Job{
+				{ID: 1, Status: model.JobStatusCompleted},
+				{ID: 2, Status: model.JobStatusCancelled},
+			},
+			wantID: 0,
+		},
+		{
+			name: "most recent active job wins",
+			jobs: []model.Job{
+				{ID: 1, Status: model.JobStatusInProgress},
+				{ID: 2, Status: model.JobStatusFailed},
+				{ID: 3, Status: model.JobStatusPending},
+			},
+			wantID: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := activeJob(tt.jobs)
+			var gotID int64
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("activeJob() = %d, want %d", gotID, tt.wantID)
+			}
+		})
+	}
+}
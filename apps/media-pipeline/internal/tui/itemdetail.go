@@ -1,10 +1,10 @@
 package tui
 
 import (
-	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cuivienor/media-pipeline/internal/model"
@@ -50,6 +50,7 @@ func (a *App) renderMovieDetail(item *model.MediaItem) string {
 
 	b.WriteString(fmt.Sprintf("  Stage: %s\n", item.CurrentStage.DisplayName()))
 	b.WriteString(fmt.Sprintf("  Status: %s\n", stageStyle.Render(string(item.StageStatus))))
+	b.WriteString(fmt.Sprintf("  Autopilot: %s\n", autopilotLabel(item.Autopilot)))
 	b.WriteString("\n")
 
 	// Next Action
@@ -82,17 +83,12 @@ func (a *App) renderMovieDetail(item *model.MediaItem) string {
 	if len(jobs) > 0 {
 		b.WriteString(sectionHeaderStyle.Render("HISTORY"))
 		b.WriteString("\n")
+		now := time.Now()
 		for _, job := range jobs {
-			statusIcon := "○"
-			switch job.Status {
-			case model.JobStatusCompleted:
-				statusIcon = "✓"
-			case model.JobStatusInProgress:
-				statusIcon = "◐"
-			case model.JobStatusFailed:
-				statusIcon = "✗"
-			}
-			b.WriteString(fmt.Sprintf("  %s %s\n", statusIcon, job.Stage.DisplayName()))
+			statusIcon := jobStatusIcon(job.Status)
+			b.WriteString(fmt.Sprintf("  %s %s%s%s\n", statusIcon, job.Stage.DisplayName(),
+				priorityLabel(job.Priority), attemptLabel(&job, a.maxAttempts(job.Stage), now)))
+			b.WriteString(a.renderAttemptLog(&job))
 
 			// Add transcode progress if applicable
 			b.WriteString(a.renderTranscodeProgress(&job))
@@ -102,18 +98,25 @@ func (a *App) renderMovieDetail(item *model.MediaItem) string {
 
 	// Help
 	var helpText string
-	if item.StageStatus == model.StatusInProgress {
-		helpText = "[r] Refresh  [Esc] Back  [q] Quit"
+	if job := activeJob(jobs); job != nil && job.Status == model.JobStatusPending {
+		helpText = "[x] Cancel  [+/-] Priority  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
+	} else if job != nil {
+		helpText = "[x] Cancel  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
+	} else if item.StageStatus == model.StatusInProgress {
+		helpText = "[p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
 	} else if item.CurrentStage == model.StageRip && item.StageStatus == model.StatusCompleted {
-		helpText = "[o] Organize  [r] Refresh  [Esc] Back  [q] Quit"
+		helpText = "[o] Organize  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
 	} else if item.StageStatus == model.StatusCompleted && item.CurrentStage != model.StagePublish {
 		// Ready for next stage (remux, transcode, or publish)
 		nextStage := item.CurrentStage.NextStage()
-		helpText = fmt.Sprintf("[s] Start %s  [r] Refresh  [Esc] Back  [q] Quit", nextStage.String())
+		helpText = fmt.Sprintf("[s] Start %s  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit", nextStage.String())
 	} else if item.StageStatus == model.StatusPending || item.StageStatus == model.StatusFailed {
-		helpText = fmt.Sprintf("[s] Start %s  [r] Refresh  [Esc] Back  [q] Quit", item.CurrentStage.String())
+		helpText = fmt.Sprintf("[s] Start %s  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit", item.CurrentStage.String())
 	} else {
-		helpText = "[r] Refresh  [Esc] Back  [q] Quit"
+		helpText = "[p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
+	}
+	if len(jobs) > 0 {
+		helpText = strings.Replace(helpText, "[r] Refresh", "[l] Log  [r] Refresh", 1)
 	}
 	b.WriteString(helpStyle.Render(helpText))
 
@@ -128,7 +131,9 @@ func (a *App) renderTVShowDetail(item *model.MediaItem) string {
 	b.WriteString(titleStyle.Render(item.Name))
 	b.WriteString("\n")
 	b.WriteString(mutedItemStyle.Render("TV Show"))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("Autopilot: %s (all seasons)\n", autopilotLabel(item.Autopilot)))
+	b.WriteString("\n")
 
 	// Seasons list
 	b.WriteString(sectionHeaderStyle.Render("SEASONS"))
@@ -205,7 +210,7 @@ func (a *App) renderTVShowDetail(item *model.MediaItem) string {
 	b.WriteString("\n")
 
 	// Help
-	b.WriteString(helpStyle.Render("[Enter] View Season  [a] Add Season  [r] Refresh  [Esc] Back  [q] Quit"))
+	b.WriteString(helpStyle.Render("[Enter] View Season  [a] Add Season  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"))
 
 	return b.String()
 }
@@ -217,35 +222,22 @@ func (a *App) renderTranscodeProgress(job *model.Job) string {
 		return ""
 	}
 
-	ctx := context.Background()
-	files, err := a.repo.ListTranscodeFiles(ctx, job.ID)
-	if err != nil || len(files) == 0 {
+	summary, ok := a.state.Transcodes[job.ID]
+	if !ok {
 		return ""
 	}
 
 	var b strings.Builder
 	b.WriteString("\n")
 
-	// Count completed files and find current file
-	completed := 0
-	var currentFile *model.TranscodeFile
-	for i := range files {
-		switch files[i].Status {
-		case model.TranscodeFileStatusCompleted:
-			completed++
-		case model.TranscodeFileStatusInProgress:
-			currentFile = &files[i]
-		}
-	}
-
 	// Overall file progress
-	b.WriteString(fmt.Sprintf("    Files: %d/%d completed\n", completed, len(files)))
+	b.WriteString(fmt.Sprintf("    Files: %d/%d completed\n", summary.Completed, summary.Files))
 
 	// Current file name and percentage
-	if currentFile != nil {
+	if summary.CurrentFile != "" {
 		b.WriteString(fmt.Sprintf("    Current: %s (%d%%)\n",
-			filepath.Base(currentFile.RelativePath),
-			currentFile.Progress))
+			filepath.Base(summary.CurrentFile),
+			summary.CurrentProgress))
 	}
 
 	return b.String()
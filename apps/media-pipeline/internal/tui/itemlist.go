@@ -21,7 +21,7 @@ func (a *App) renderItemList() string {
 	if a.state == nil || len(a.state.Items) == 0 {
 		b.WriteString(mutedItemStyle.Render("No active items. Press [n] to add one."))
 		b.WriteString("\n\n")
-		b.WriteString(helpStyle.Render("[n] New Item  [h] History  [q] Quit"))
+		b.WriteString(helpStyle.Render("[n] New Item  [/] Search  [w] Workers  [h] History  [q] Quit"))
 		return b.String()
 	}
 
@@ -99,7 +99,7 @@ func (a *App) renderItemList() string {
 		b.WriteString("\n")
 	}
 
-	b.WriteString(helpStyle.Render("[Enter] View  [n] New Item  [r] Refresh  [q] Quit"))
+	b.WriteString(helpStyle.Render("[Enter] View  [/] Search  [n] New Item  [w] Workers  [r] Refresh  [q] Quit"))
 
 	return b.String()
 }
@@ -0,0 +1,378 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+const (
+	// jobLogEvents is how many of a job's log events the log view shows
+	jobLogEvents = 10
+
+	// jobLogPageLines is how many lines of the log file a page shows when
+	// the window size is not known
+	jobLogPageLines = 20
+
+	// jobLogPollInterval is how often a followed log file is read again
+	jobLogPollInterval = 2 * time.Second
+)
+
+// JobLogView holds the state of a job's log view
+type JobLogView struct {
+	Job      model.Job
+	Path     string
+	Events   []model.LogEvent // Newest first
+	ToolLogs []model.ToolLog
+	Lines    []string
+
+	// file is the file shown: 0 for the job log, i for ToolLogs[i-1]
+	file int
+
+	// offset is the first line shown. Following keeps it on the last page.
+	offset int
+	follow bool
+
+	// followSeq tells the ticks of the current follow apart from those of
+	// one since stopped
+	followSeq int
+
+	back View
+	err  error
+}
+
+// jobLogLoadedMsg is sent when a job's log has been read
+type jobLogLoadedMsg struct {
+	job      *model.Job
+	events   []model.LogEvent
+	toolLogs []model.ToolLog
+	file     int
+	lines    []string
+	seq      int
+	err      error
+}
+
+// jobLogTickMsg asks a followed log to be read again
+type jobLogTickMsg struct {
+	jobID int64
+	seq   int
+}
+
+// selectedLogJob returns the job whose log 'l' opens for the movie or season
+// being viewed: its active job, or else its latest one
+func (a *App) selectedLogJob() *model.Job {
+	if job := a.selectedActiveJob(); job != nil {
+		return job
+	}
+	if a.state == nil {
+		return nil
+	}
+
+	var jobList []model.Job
+	if a.currentView == ViewItemDetail && a.selectedItem != nil && a.selectedItem.Type == model.MediaTypeMovie {
+		jobList = a.state.MovieJobs[a.selectedItem.ID]
+	}
+	if a.currentView == ViewSeasonDetail && a.selectedSeason != nil {
+		jobList = a.state.SeasonJobs[a.selectedSeason.ID]
+	}
+	if len(jobList) == 0 {
+		return nil
+	}
+	return &jobList[len(jobList)-1]
+}
+
+// openJobLog shows a job's log, following it while the job is active
+func (a *App) openJobLog(job *model.Job) tea.Cmd {
+	path := job.LogPath
+	if path == "" && a.config != nil {
+		path = a.config.JobLogPath(job.ID)
+	}
+
+	a.jobLog = &JobLogView{Job: *job, Path: path, back: a.currentView, follow: job.IsActive()}
+	a.currentView = ViewJobLog
+	if a.jobLog.follow {
+		a.jobLog.followSeq++
+	}
+	return a.loadJobLog(a.jobLog.followSeq)
+}
+
+// filePath returns the path of the file shown
+func (v *JobLogView) filePath() string {
+	if v.file == 0 {
+		return v.Path
+	}
+	return v.ToolLogs[v.file-1].Path
+}
+
+// loadJobLog reads the job, its log events and tool logs, and the file
+// shown. seq is the follow the read belongs to, 0 for none.
+func (a *App) loadJobLog(seq int) tea.Cmd {
+	jobID, file, path := a.jobLog.Job.ID, a.jobLog.file, a.jobLog.filePath()
+	if !a.jobLog.follow {
+		seq = 0
+	}
+	return func() tea.Msg {
+		ctx := context.Background()
+		msg := jobLogLoadedMsg{file: file, seq: seq}
+
+		msg.job, msg.err = a.repo.GetJob(ctx, jobID)
+		if msg.err != nil {
+			return msg
+		}
+		msg.events, msg.err = a.repo.ListLogEvents(ctx, jobID, jobLogEvents)
+		if msg.err != nil {
+			return msg
+		}
+		msg.toolLogs, msg.err = a.repo.ListToolLogs(ctx, jobID)
+		if msg.err != nil {
+			return msg
+		}
+		msg.lines, msg.err = readLogLines(path)
+		return msg
+	}
+}
+
+// readLogLines returns the lines of a log file. A file not written yet has
+// none.
+func readLogLines(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job log: %w", err)
+	}
+	text := strings.TrimRight(string(data), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// handleJobLogLoaded shows a read log and, while following, schedules the
+// next read. Following stops once the job has finished and its last lines
+// are read.
+func (a *App) handleJobLogLoaded(msg jobLogLoadedMsg) tea.Cmd {
+	view := a.jobLog
+	if view == nil {
+		return nil
+	}
+	view.err = msg.err
+	if msg.err != nil {
+		return nil
+	}
+	if msg.job != nil {
+		view.Job = *msg.job
+	}
+	view.Events = msg.events
+	view.ToolLogs = msg.toolLogs
+	// Lines read before switching to another file are dropped
+	if msg.file != view.file {
+		return nil
+	}
+	view.Lines = msg.lines
+
+	if !view.follow {
+		view.offset = min(view.offset, a.lastJobLogPage())
+		return nil
+	}
+	view.offset = a.lastJobLogPage()
+	if msg.seq != view.followSeq {
+		return nil
+	}
+	if msg.job == nil || !msg.job.IsActive() {
+		view.follow = false
+		return nil
+	}
+
+	jobID, seq := view.Job.ID, view.followSeq
+	return tea.Tick(jobLogPollInterval, func(time.Time) tea.Msg {
+		return jobLogTickMsg{jobID: jobID, seq: seq}
+	})
+}
+
+// handleJobLogTick reads a followed log again, unless the follow has since
+// stopped
+func (a *App) handleJobLogTick(msg jobLogTickMsg) tea.Cmd {
+	view := a.jobLog
+	if view == nil || !view.follow || view.Job.ID != msg.jobID || view.followSeq != msg.seq {
+		return nil
+	}
+	return a.loadJobLog(msg.seq)
+}
+
+// handleJobLogKey handles keyboard input in the log view. Scrolling stops
+// following; 'f' starts it again from the end of the log.
+func (a *App) handleJobLogKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	view := a.jobLog
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return a, tea.Quit
+
+	case "esc":
+		a.currentView = view.back
+		a.jobLog = nil
+		return a, nil
+
+	case "r":
+		return a, a.loadJobLog(0)
+
+	case "t":
+		// Next file: the job log, then each tool log
+		if len(view.ToolLogs) == 0 {
+			return a, nil
+		}
+		view.file = (view.file + 1) % (len(view.ToolLogs) + 1)
+		view.Lines, view.offset = nil, 0
+		if view.follow {
+			// Follow the new file instead
+			view.followSeq++
+		}
+		return a, a.loadJobLog(view.followSeq)
+
+	case "f":
+		view.follow = !view.follow
+		if !view.follow {
+			return a, nil
+		}
+		view.followSeq++
+		view.offset = a.lastJobLogPage()
+		return a, a.loadJobLog(view.followSeq)
+
+	case "up", "k":
+		a.scrollJobLog(-1)
+	case "down", "j":
+		a.scrollJobLog(1)
+	case "pgup", "b":
+		a.scrollJobLog(-a.jobLogPageLines())
+	case "pgdown", " ":
+		a.scrollJobLog(a.jobLogPageLines())
+	case "home", "g":
+		a.scrollJobLog(-len(view.Lines))
+	case "end", "G":
+		a.scrollJobLog(len(view.Lines))
+	}
+	return a, nil
+}
+
+// scrollJobLog moves the log view by n lines and stops following it
+func (a *App) scrollJobLog(n int) {
+	view := a.jobLog
+	view.follow = false
+	view.offset = max(0, min(view.offset+n, a.lastJobLogPage()))
+}
+
+// jobLogPageLines returns how many lines of the log file fit on screen
+func (a *App) jobLogPageLines() int {
+	if a.height == 0 {
+		return jobLogPageLines
+	}
+	// Title, job, the events and their header, the log header, the files
+	// and help
+	used := 11 + len(a.jobLog.Events)
+	return max(5, a.height-used)
+}
+
+// lastJobLogPage returns the offset that shows the end of the log
+func (a *App) lastJobLogPage() int {
+	return max(0, len(a.jobLog.Lines)-a.jobLogPageLines())
+}
+
+// renderJobLog renders a job's log events and a page of its log file
+func (a *App) renderJobLog() string {
+	var b strings.Builder
+	view := a.jobLog
+	job := view.Job
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Job %d Log", job.ID)))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("  %s %s · %s\n\n", jobStatusIcon(job.Status), job.Stage.DisplayName(), job.Status))
+
+	if view.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("  %v", view.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(sectionHeaderStyle.Render("EVENTS"))
+	b.WriteString("\n")
+	if len(view.Events) == 0 {
+		b.WriteString(mutedItemStyle.Render("  No events recorded"))
+		b.WriteString("\n")
+	}
+	// Events come newest first
+	for i := len(view.Events) - 1; i >= 0; i-- {
+		event := view.Events[i]
+		line := fmt.Sprintf("  %s %-5s %s", event.Timestamp.Local().Format("15:04:05"), event.Level, event.Message)
+		if event.Level == "error" {
+			line = errorStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(sectionHeaderStyle.Render("LOG"))
+	b.WriteString("\n")
+	if len(view.ToolLogs) > 0 {
+		b.WriteString(a.renderJobLogFiles())
+	}
+	path := view.filePath()
+	switch {
+	case path == "":
+		b.WriteString(mutedItemStyle.Render("  No log file"))
+		b.WriteString("\n")
+	case len(view.Lines) == 0:
+		b.WriteString(mutedItemStyle.Render("  " + path + " (empty)"))
+		b.WriteString("\n")
+	default:
+		end := min(view.offset+a.jobLogPageLines(), len(view.Lines))
+		position := fmt.Sprintf("  %s (lines %d-%d of %d)", path, view.offset+1, end, len(view.Lines))
+		if view.follow {
+			position += " · following"
+		}
+		b.WriteString(mutedItemStyle.Render(position))
+		b.WriteString("\n")
+		for _, line := range view.Lines[view.offset:end] {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	helpText := "[↑/↓] Scroll  [PgUp/PgDn] Page  [g/G] Top/End  [f] Follow  [r] Refresh  [Esc] Back  [q] Quit"
+	if len(view.ToolLogs) > 0 {
+		helpText = "[t] Next File  " + helpText
+	}
+	b.WriteString(helpStyle.Render(helpText))
+	return b.String()
+}
+
+// renderJobLogFiles renders the files of a job's log that 't' switches
+// between, marking the one shown
+func (a *App) renderJobLogFiles() string {
+	view := a.jobLog
+	names := []string{"job.log"}
+	for _, toolLog := range view.ToolLogs {
+		names = append(names, filepath.Base(toolLog.Path))
+	}
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		if i == view.file {
+			parts[i] = selectedItemStyle.Render("[" + name + "]")
+		} else {
+			parts[i] = mutedItemStyle.Render(name)
+		}
+	}
+	return "  " + strings.Join(parts, "  ") + "\n"
+}
@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestJobLog_PagesAndFollows(t *testing.T) {
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := db.NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	movie := &model.MediaItem{
+		Type:         model.MediaTypeMovie,
+		Name:         "Test Movie",
+		SafeName:     "Test_Movie",
+		CurrentStage: model.StageTranscode,
+		StageStatus:  model.StatusInProgress,
+	}
+	if err := repo.CreateMediaItem(ctx, movie); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+
+	logPath := filepath.Join(t.TempDir(), "job.log")
+	var lines []string
+	for i := 1; i <= 50; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	if err := os.WriteFile(logPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	job := &model.Job{MediaItemID: movie.ID, Stage: model.StageTranscode, Status: model.JobStatusInProgress, LogPath: logPath}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	event := &model.LogEvent{JobID: job.ID, Level: "info", Message: "Completed: movie.mkv"}
+	if err := repo.CreateLogEvent(ctx, event); err != nil {
+		t.Fatalf("CreateLogEvent() error = %v", err)
+	}
+
+	app := NewApp(nil, repo)
+	app.Update(app.loadState())
+	app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// Runs the commands a key returns, but not the ticks a follow waits on
+	press := func(msg tea.KeyMsg) tea.Cmd {
+		_, cmd := app.Update(msg)
+		if cmd == nil {
+			return nil
+		}
+		next := cmd()
+		if _, ok := next.(jobLogLoadedMsg); !ok {
+			return nil
+		}
+		_, tick := app.Update(next)
+		return tick
+	}
+
+	tick := press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if app.currentView != ViewJobLog || app.jobLog == nil {
+		t.Fatalf("currentView = %v after l, want the job log", app.currentView)
+	}
+	if !app.jobLog.follow || tick == nil {
+		t.Fatal("log of a running job is not followed")
+	}
+
+	view := app.renderJobLog()
+	if !strings.Contains(view, "Completed: movie.mkv") {
+		t.Errorf("job log view missing the recorded event:\n%s", view)
+	}
+	if !strings.Contains(view, "line 50") || strings.Contains(view, "line 30\n") {
+		t.Errorf("followed log does not show the last page:\n%s", view)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyPgUp})
+	if app.jobLog.follow {
+		t.Error("still following after paging up")
+	}
+	if app.jobLog.offset != 10 {
+		t.Errorf("offset after page up = %d, want 10", app.jobLog.offset)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	if view := app.renderJobLog(); !strings.Contains(view, "line 1\n") || strings.Contains(view, "line 50") {
+		t.Errorf("log does not show the first page after g:\n%s", view)
+	}
+
+	// A tick of the stopped follow is dropped
+	if cmd := app.handleJobLogTick(jobLogTickMsg{jobID: job.ID, seq: 1}); cmd != nil {
+		t.Error("tick of a stopped follow reads the log again")
+	}
+
+	// The job finishing ends the follow started again with f
+	if err := repo.UpdateJobStatus(ctx, job.ID, model.JobStatusCompleted, ""); err != nil {
+		t.Fatalf("UpdateJobStatus() error = %v", err)
+	}
+	if tick := press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")}); tick != nil || app.jobLog.follow {
+		t.Error("still following a finished job")
+	}
+	if app.jobLog.offset != 30 || app.jobLog.Job.Status != model.JobStatusCompleted {
+		t.Errorf("after f: offset %d, status %s; want 30, completed", app.jobLog.offset, app.jobLog.Job.Status)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if app.currentView != ViewItemDetail || app.jobLog != nil {
+		t.Errorf("currentView = %v after esc, want item detail", app.currentView)
+	}
+}
+
+func TestJobLog_SwitchesToToolLogs(t *testing.T) {
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := db.NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	movie := &model.MediaItem{
+		Type:         model.MediaTypeMovie,
+		Name:         "Test Movie",
+		SafeName:     "Test_Movie",
+		CurrentStage: model.StageRemux,
+		StageStatus:  model.StatusFailed,
+	}
+	if err := repo.CreateMediaItem(ctx, movie); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	jobLogPath := filepath.Join(dir, "job.log")
+	toolLogPath := filepath.Join(dir, "mkvmerge.log")
+	os.WriteFile(jobLogPath, []byte("Starting remux\n"), 0644)
+	os.WriteFile(toolLogPath, []byte("$ mkvmerge -o out.mkv in.mkv\nError: out of disk space\n"), 0644)
+
+	job := &model.Job{MediaItemID: movie.ID, Stage: model.StageRemux, Status: model.JobStatusFailed, LogPath: jobLogPath}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if err := repo.AddToolLog(ctx, &model.ToolLog{JobID: job.ID, Tool: "mkvmerge", Path: toolLogPath}); err != nil {
+		t.Fatalf("AddToolLog() error = %v", err)
+	}
+
+	app := NewApp(nil, repo)
+	app.Update(app.loadState())
+	app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	press := func(key string) {
+		_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		if cmd != nil {
+			app.Update(cmd())
+		}
+	}
+
+	press("l")
+	if app.jobLog == nil || app.jobLog.follow {
+		t.Fatalf("jobLog = %+v after l, want the failed job's log, not followed", app.jobLog)
+	}
+	if view := app.renderJobLog(); !strings.Contains(view, "Starting remux") || !strings.Contains(view, "mkvmerge.log") {
+		t.Errorf("job log view missing job.log or the tool log:\n%s", view)
+	}
+
+	press("t")
+	if view := app.renderJobLog(); !strings.Contains(view, "Error: out of disk space") || strings.Contains(view, "Starting remux") {
+		t.Errorf("view after t does not show mkvmerge.log:\n%s", view)
+	}
+
+	press("t")
+	if view := app.renderJobLog(); !strings.Contains(view, "Starting remux") {
+		t.Errorf("view after second t does not show job.log again:\n%s", view)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/items"
 	"github.com/cuivienor/media-pipeline/internal/model"
 )
 
@@ -37,81 +38,13 @@ func (f *NewItemForm) Validate() string {
 		return "Seasons is required for TV shows (e.g., '1-5' or '1,2,3')"
 	}
 	if f.Type == "tv" {
-		if _, err := parseSeasons(f.Seasons); err != nil {
+		if _, err := items.ParseSeasons(f.Seasons); err != nil {
 			return err.Error()
 		}
 	}
 	return ""
 }
 
-// parseSeasons parses a season string like "1-5" or "1,2,3" into a slice of ints
-func parseSeasons(s string) ([]int, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return nil, fmt.Errorf("seasons cannot be empty")
-	}
-
-	seen := make(map[int]bool)
-
-	// Handle range: "1-5"
-	if strings.Contains(s, "-") {
-		parts := strings.Split(s, "-")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid range format, use '1-5'")
-		}
-		start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
-		if err != nil {
-			return nil, fmt.Errorf("invalid start number")
-		}
-		end, err := strconv.Atoi(strings.TrimSpace(parts[1]))
-		if err != nil {
-			return nil, fmt.Errorf("invalid end number")
-		}
-		if start < 1 {
-			return nil, fmt.Errorf("season numbers must be positive (1 or greater)")
-		}
-		if start > end {
-			return nil, fmt.Errorf("start must be less than or equal to end")
-		}
-		var seasons []int
-		for i := start; i <= end; i++ {
-			seasons = append(seasons, i)
-		}
-		return seasons, nil
-	}
-
-	// Handle comma-separated: "1,2,3"
-	if strings.Contains(s, ",") {
-		parts := strings.Split(s, ",")
-		var seasons []int
-		for _, p := range parts {
-			n, err := strconv.Atoi(strings.TrimSpace(p))
-			if err != nil {
-				return nil, fmt.Errorf("invalid season number: %s", p)
-			}
-			if n < 1 {
-				return nil, fmt.Errorf("season numbers must be positive (1 or greater)")
-			}
-			if seen[n] {
-				return nil, fmt.Errorf("duplicate season number: %d", n)
-			}
-			seasons = append(seasons, n)
-			seen[n] = true
-		}
-		return seasons, nil
-	}
-
-	// Single number
-	n, err := strconv.Atoi(s)
-	if err != nil {
-		return nil, fmt.Errorf("invalid season number")
-	}
-	if n < 1 {
-		return nil, fmt.Errorf("season numbers must be positive (1 or greater)")
-	}
-	return []int{n}, nil
-}
-
 // renderNewItemForm renders the new item form view
 func (a *App) renderNewItemForm() string {
 	var b strings.Builder
@@ -256,52 +189,19 @@ func (a *App) createNewItem() tea.Cmd {
 		form := a.newItemForm
 		ctx := context.Background()
 
-		safeName := strings.ReplaceAll(form.Name, " ", "_")
-
-		item := &model.MediaItem{
-			Type:       model.MediaType(form.Type),
-			Name:       form.Name,
-			SafeName:   safeName,
-			ItemStatus: model.ItemStatusNotStarted,
-		}
-
-		// Set database ID if provided
-		if form.DatabaseID != "" {
-			if dbID, err := strconv.Atoi(form.DatabaseID); err == nil {
-				if form.Type == "movie" {
-					item.TmdbID = &dbID
-				} else {
-					item.TvdbID = &dbID
-				}
-			}
-		}
+		// An unparseable database ID is left unset, to be filled in later
+		dbID, _ := strconv.Atoi(form.DatabaseID)
 
-		// For movies, set initial stage
-		if form.Type == "movie" {
-			item.CurrentStage = model.StageRip
-			item.StageStatus = model.StatusPending
+		var seasons []int
+		if form.Type == "tv" {
+			seasons, _ = items.ParseSeasons(form.Seasons)
 		}
 
-		if err := a.repo.CreateMediaItem(ctx, item); err != nil {
+		item, err := items.Create(ctx, a.repo, model.MediaType(form.Type), form.Name, dbID, seasons)
+		if err != nil {
 			return itemCreatedMsg{err: err}
 		}
 
-		// For TV shows, create seasons
-		if form.Type == "tv" {
-			seasons, _ := parseSeasons(form.Seasons)
-			for _, num := range seasons {
-				season := &model.Season{
-					ItemID:       item.ID,
-					Number:       num,
-					CurrentStage: model.StageRip,
-					StageStatus:  model.StatusPending,
-				}
-				if err := a.repo.CreateSeason(ctx, season); err != nil {
-					return itemCreatedMsg{err: fmt.Errorf("failed to create season %d: %w", num, err)}
-				}
-			}
-		}
-
 		return itemCreatedMsg{item: item}
 	}
 }
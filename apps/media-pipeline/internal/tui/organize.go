@@ -7,10 +7,10 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
 	"github.com/cuivienor/media-pipeline/internal/model"
 	"github.com/cuivienor/media-pipeline/internal/organize"
 )
@@ -221,9 +221,9 @@ type organizeLoadedMsg struct {
 func (a *App) loadOrganizeView(item *model.MediaItem) tea.Cmd {
 	return func() tea.Msg {
 		// Find the rip output directory
-		path := a.findItemPath(item, model.StageRip)
-		if path == "" {
-			return organizeLoadedMsg{err: fmt.Errorf("could not find rip output for %s", item.Name)}
+		path, _, err := jobs.RipOutput(context.Background(), a.repo, item, nil)
+		if err != nil {
+			return organizeLoadedMsg{err: err}
 		}
 
 		files, err := listDirectory(path)
@@ -244,36 +244,19 @@ func (a *App) loadOrganizeViewForSeason(item *model.MediaItem, season *model.Sea
 	return func() tea.Msg {
 		ctx := context.Background()
 
-		// Find all completed rip jobs for this season
-		jobs, err := a.repo.ListJobsForMedia(ctx, item.ID)
+		// Find the discs ripped for this season
+		seasonPath, discPaths, err := jobs.RipOutput(ctx, a.repo, item, season)
 		if err != nil {
-			return organizeLoadedMsg{err: fmt.Errorf("failed to list jobs: %w", err)}
+			return organizeLoadedMsg{err: err}
 		}
 
-		// Collect disc paths from completed rip jobs
-		var discPaths []string
 		discFiles := make(map[string][]fileInfo)
-
-		for _, job := range jobs {
-			if job.Stage == model.StageRip && job.Status == model.JobStatusCompleted {
-				if job.SeasonID != nil && *job.SeasonID == season.ID && job.OutputDir != "" {
-					discPaths = append(discPaths, job.OutputDir)
-					files, err := listDirectory(job.OutputDir)
-					if err == nil {
-						discName := filepath.Base(job.OutputDir)
-						discFiles[discName] = files
-					}
-				}
+		for _, discPath := range discPaths {
+			if files, err := listDirectory(discPath); err == nil {
+				discFiles[filepath.Base(discPath)] = files
 			}
 		}
 
-		if len(discPaths) == 0 {
-			return organizeLoadedMsg{err: fmt.Errorf("no completed rip jobs found for %s Season %d", item.Name, season.Number)}
-		}
-
-		// Season base path is the parent of the disc directories
-		seasonPath := filepath.Dir(discPaths[0])
-
 		// Also list the season directory itself (for _episodes, _extras that user creates)
 		seasonFiles, _ := listDirectory(seasonPath)
 
@@ -300,20 +283,9 @@ func (a *App) validateOrganization() tea.Cmd {
 			return validateMsg{err: fmt.Errorf("no item selected")}
 		}
 
+		ov := a.organizeView
 		validator := &organize.Validator{}
-		var result organize.ValidationResult
-
-		if a.organizeView.item.Type == model.MediaTypeMovie {
-			result = validator.ValidateMovie(a.organizeView.path)
-		} else {
-			// For TV seasons, use multi-disc validation if we have disc paths
-			if len(a.organizeView.discPaths) > 0 {
-				result = validator.ValidateTVSeason(a.organizeView.discPaths)
-			} else {
-				// Single disc or legacy - validate season directory directly
-				result = validator.ValidateTV(a.organizeView.path)
-			}
-		}
+		result := validator.Validate(ov.item.Type == model.MediaTypeTV, ov.path, ov.discPaths)
 
 		return validateMsg{result: &result}
 	}
@@ -333,39 +305,11 @@ func (a *App) markOrganizeComplete() tea.Cmd {
 		ctx := context.Background()
 		ov := a.organizeView
 
-		// Create completed organize job
-		now := time.Now()
-		job := &model.Job{
-			MediaItemID: ov.item.ID,
-			Stage:       model.StageOrganize,
-			Status:      model.JobStatusCompleted,
-			OutputDir:   ov.path,
-			StartedAt:   &now,
-			CompletedAt: &now,
-		}
-
-		// Set SeasonID for TV seasons
-		if ov.season != nil {
-			job.SeasonID = &ov.season.ID
-		}
-
-		if err := a.repo.CreateJob(ctx, job); err != nil {
+		// With autopilot on, remux is queued straight away
+		if _, err := jobs.CompleteOrganize(ctx, a.repo, ov.item, ov.season, ov.path); err != nil {
 			return organizeCompleteMsg{err: err}
 		}
 
-		// Update stage to organize completed
-		if ov.season != nil {
-			// TV season - update season stage
-			if err := a.repo.UpdateSeasonStage(ctx, ov.season.ID, model.StageOrganize, model.StatusCompleted); err != nil {
-				return organizeCompleteMsg{err: fmt.Errorf("failed to update season stage: %w", err)}
-			}
-		} else {
-			// Movie - update item stage
-			if err := a.repo.UpdateMediaItemStage(ctx, ov.item.ID, model.StageOrganize, model.StatusCompleted); err != nil {
-				return organizeCompleteMsg{err: fmt.Errorf("failed to update item stage: %w", err)}
-			}
-		}
-
 		return organizeCompleteMsg{}
 	}
 }
@@ -411,16 +355,3 @@ func formatSize(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
-
-// findItemPath finds the output path for an item at a given stage
-func (a *App) findItemPath(item *model.MediaItem, stage model.Stage) string {
-	ctx := context.Background()
-	jobs, _ := a.repo.ListJobsForMedia(ctx, item.ID)
-
-	for _, job := range jobs {
-		if job.Stage == stage && job.Status == model.JobStatusCompleted {
-			return job.OutputDir
-		}
-	}
-	return ""
-}
@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// jobPriorityChangedMsg is sent when a queued job's priority has been updated
+type jobPriorityChangedMsg struct {
+	err error
+}
+
+// changeJobPriority bumps a queued job's priority up or down by delta
+func (a *App) changeJobPriority(job *model.Job, delta int) tea.Cmd {
+	id, priority := job.ID, job.Priority+delta
+	return func() tea.Msg {
+		err := a.repo.SetJobPriority(context.Background(), id, priority)
+		return jobPriorityChangedMsg{err: err}
+	}
+}
+
+// priorityLabel formats a non-default job priority for the history list
+func priorityLabel(priority int) string {
+	if priority == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (priority %+d)", priority)
+}
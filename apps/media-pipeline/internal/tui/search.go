@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// SearchView holds the search prompt state
+type SearchView struct {
+	Query   string
+	Results []model.SearchResult
+	cursor  int
+	err     error
+}
+
+// searchResultsMsg is sent when a search completes
+type searchResultsMsg struct {
+	query   string
+	results []model.SearchResult
+	err     error
+}
+
+// runSearch searches item names, season labels, job errors and log messages
+func (a *App) runSearch(query string) tea.Cmd {
+	return func() tea.Msg {
+		results, err := a.repo.Search(context.Background(), query)
+		return searchResultsMsg{query: query, results: results, err: err}
+	}
+}
+
+// handleSearchKey handles keyboard input in the search prompt. Typing
+// searches as you go; Enter jumps to the selected result.
+func (a *App) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	search := a.search
+
+	switch msg.String() {
+	case "ctrl+c":
+		return a, tea.Quit
+
+	case "esc":
+		a.currentView = ViewItemList
+		a.search = nil
+		a.cursor = 0
+		return a, nil
+
+	case "up":
+		if search.cursor > 0 {
+			search.cursor--
+		}
+		return a, nil
+
+	case "down":
+		if search.cursor < len(search.Results)-1 {
+			search.cursor++
+		}
+		return a, nil
+
+	case "enter":
+		if search.cursor < len(search.Results) {
+			a.jumpToResult(search.Results[search.cursor])
+		}
+		return a, nil
+
+	case "backspace":
+		if len(search.Query) > 0 {
+			search.Query = search.Query[:len(search.Query)-1]
+			return a, a.runSearch(search.Query)
+		}
+		return a, nil
+
+	default:
+		if len(msg.String()) == 1 {
+			search.Query += msg.String()
+			return a, a.runSearch(search.Query)
+		}
+		return a, nil
+	}
+}
+
+// jumpToResult opens the item or season a result belongs to, where a job's
+// errors and logs are shown in its history. Items no longer in the list,
+// such as published ones, are left alone.
+func (a *App) jumpToResult(result model.SearchResult) {
+	item := a.findStateItem(result.MediaItemID)
+	if item == nil {
+		return
+	}
+
+	a.search = nil
+	a.selectedItem = item
+	a.selectedSeason = nil
+	a.currentView = ViewItemDetail
+	a.cursor = 0
+
+	if result.SeasonID == nil {
+		return
+	}
+	for i := range item.Seasons {
+		if item.Seasons[i].ID == *result.SeasonID {
+			a.selectedSeason = &item.Seasons[i]
+			a.currentView = ViewSeasonDetail
+			return
+		}
+	}
+}
+
+// findStateItem returns the loaded item with an ID, nil if it isn't loaded
+func (a *App) findStateItem(id int64) *model.MediaItem {
+	if a.state == nil {
+		return nil
+	}
+	for i := range a.state.Items {
+		if a.state.Items[i].ID == id {
+			return &a.state.Items[i]
+		}
+	}
+	return nil
+}
+
+// renderSearch renders the search prompt and its results
+func (a *App) renderSearch() string {
+	var b strings.Builder
+	search := a.search
+
+	b.WriteString(titleStyle.Render("Search"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("/ %s_\n\n", search.Query))
+
+	switch {
+	case search.err != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Search failed: %v", search.err)))
+		b.WriteString("\n\n")
+	case strings.TrimSpace(search.Query) == "":
+		b.WriteString(mutedItemStyle.Render("Search item names, seasons, job errors and logs"))
+		b.WriteString("\n\n")
+	case len(search.Results) == 0:
+		b.WriteString(mutedItemStyle.Render("No matches"))
+		b.WriteString("\n\n")
+	default:
+		for i, result := range search.Results {
+			b.WriteString(a.renderSearchResult(result, i == search.cursor))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("[Enter] Jump  [↑/↓] Select  [Esc] Back"))
+	return b.String()
+}
+
+// renderSearchResult renders a result as the item it belongs to and the
+// matched text
+func (a *App) renderSearchResult(result model.SearchResult, selected bool) string {
+	prefix := "  "
+	if selected {
+		prefix = "> "
+	}
+
+	var where string
+	item := a.findStateItem(result.MediaItemID)
+	if item != nil {
+		where = item.Name
+	} else {
+		where = fmt.Sprintf("item %d (not in list)", result.MediaItemID)
+	}
+	if result.JobID != nil {
+		where += fmt.Sprintf(" · job %d", *result.JobID)
+	}
+
+	line := fmt.Sprintf("%s[%s] %s", prefix, result.Kind, where)
+	if result.Kind == model.SearchJob || result.Kind == model.SearchLog {
+		line += ": " + result.Snippet
+	}
+	if item == nil {
+		return mutedItemStyle.Render(line)
+	}
+	return line
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func TestSearch_JumpsToSeason(t *testing.T) {
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := db.NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	show := &model.MediaItem{Type: model.MediaTypeTV, Name: "Test Show", SafeName: "Test_Show"}
+	if err := repo.CreateMediaItem(ctx, show); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	season := &model.Season{ItemID: show.ID, Number: 1, CurrentStage: model.StageRip, StageStatus: model.StatusInProgress}
+	if err := repo.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason() error = %v", err)
+	}
+	job := &model.Job{MediaItemID: show.ID, SeasonID: &season.ID, Stage: model.StageRip, Status: model.JobStatusInProgress}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if _, err := repo.FailJob(ctx, job.ID, model.FailureKindCrash, "read error on disc 2"); err != nil {
+		t.Fatalf("FailJob() error = %v", err)
+	}
+
+	app := NewApp(nil, repo)
+	app.Update(app.loadState())
+
+	press := func(keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "backspace":
+				msg = tea.KeyMsg{Type: tea.KeyBackspace}
+			}
+			_, cmd := app.Update(msg)
+			if cmd != nil {
+				app.Update(cmd())
+			}
+		}
+	}
+
+	press("/")
+	if app.currentView != ViewSearch {
+		t.Fatalf("currentView = %v after /, want search", app.currentView)
+	}
+
+	press("r", "e", "a", "d", "x", "backspace")
+	if app.search.Query != "read" || len(app.search.Results) != 1 || app.search.Results[0].Kind != model.SearchJob {
+		t.Fatalf("search = %+v, want the failed job for %q", app.search, "read")
+	}
+
+	press("enter")
+	if app.currentView != ViewSeasonDetail || app.selectedItem == nil || app.selectedItem.ID != show.ID ||
+		app.selectedSeason == nil || app.selectedSeason.ID != season.ID {
+		t.Errorf("after enter: view %v, item %v, season %v; want the failed job's season", app.currentView, app.selectedItem, app.selectedSeason)
+	}
+	if app.search != nil {
+		t.Error("search still open after jumping")
+	}
+}
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cuivienor/media-pipeline/internal/model"
@@ -40,6 +41,12 @@ func (a *App) renderSeasonDetail() string {
 
 	b.WriteString(fmt.Sprintf("  Stage: %s\n", season.CurrentStage.DisplayName()))
 	b.WriteString(fmt.Sprintf("  Status: %s\n", stageStyle.Render(string(season.StageStatus))))
+	if item.Autopilot {
+		// The season's own flag has no effect while the show's is on
+		b.WriteString("  Autopilot: on (whole show - change it on the show)\n")
+	} else {
+		b.WriteString(fmt.Sprintf("  Autopilot: %s\n", autopilotLabel(season.Autopilot)))
+	}
 	b.WriteString("\n")
 
 	// Next Action
@@ -64,25 +71,20 @@ func (a *App) renderSeasonDetail() string {
 
 	// Rip Jobs (for TV seasons, multiple discs)
 	jobs := a.state.SeasonJobs[season.ID]
+	now := time.Now()
 	ripJobs := filterJobsByStage(jobs, model.StageRip)
 	if len(ripJobs) > 0 {
 		b.WriteString(sectionHeaderStyle.Render("DISC RIPS"))
 		b.WriteString("\n")
 		for _, job := range ripJobs {
-			statusIcon := "○"
-			switch job.Status {
-			case model.JobStatusCompleted:
-				statusIcon = "✓"
-			case model.JobStatusInProgress:
-				statusIcon = "◐"
-			case model.JobStatusFailed:
-				statusIcon = "✗"
-			}
+			statusIcon := jobStatusIcon(job.Status)
 			discLabel := "Disc"
 			if job.Disc != nil {
 				discLabel = fmt.Sprintf("Disc %d", *job.Disc)
 			}
-			b.WriteString(fmt.Sprintf("  %s %s\n", statusIcon, discLabel))
+			b.WriteString(fmt.Sprintf("  %s %s%s%s\n", statusIcon, discLabel,
+				priorityLabel(job.Priority), attemptLabel(&job, a.maxAttempts(job.Stage), now)))
+			b.WriteString(a.renderAttemptLog(&job))
 		}
 		b.WriteString("\n")
 	}
@@ -93,16 +95,10 @@ func (a *App) renderSeasonDetail() string {
 		b.WriteString(sectionHeaderStyle.Render("HISTORY"))
 		b.WriteString("\n")
 		for _, job := range otherJobs {
-			statusIcon := "○"
-			switch job.Status {
-			case model.JobStatusCompleted:
-				statusIcon = "✓"
-			case model.JobStatusInProgress:
-				statusIcon = "◐"
-			case model.JobStatusFailed:
-				statusIcon = "✗"
-			}
-			b.WriteString(fmt.Sprintf("  %s %s\n", statusIcon, job.Stage.DisplayName()))
+			statusIcon := jobStatusIcon(job.Status)
+			b.WriteString(fmt.Sprintf("  %s %s%s%s\n", statusIcon, job.Stage.DisplayName(),
+				priorityLabel(job.Priority), attemptLabel(&job, a.maxAttempts(job.Stage), now)))
+			b.WriteString(a.renderAttemptLog(&job))
 		}
 		b.WriteString("\n")
 	}
@@ -110,12 +106,24 @@ func (a *App) renderSeasonDetail() string {
 	// Help - show different options based on state
 	var helpText string
 	if season.CurrentStage == model.StageRip && season.StageStatus == model.StatusCompleted {
-		helpText = "[o] Organize  [s] Rip Another Disc  [r] Refresh  [Esc] Back  [q] Quit"
+		helpText = "[o] Organize  [s] Rip Another Disc  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
 	} else if season.CurrentStage == model.StageRip && len(ripJobs) > 0 {
 		// Has rip jobs, can mark done or add more
-		helpText = "[s] Rip Disc  [d] Done Ripping  [r] Refresh  [Esc] Back  [q] Quit"
+		helpText = "[s] Rip Disc  [d] Done Ripping  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
 	} else {
-		helpText = "[s] Start Rip  [r] Refresh  [Esc] Back  [q] Quit"
+		helpText = "[s] Start Rip  [p] Autopilot  [r] Refresh  [Esc] Back  [q] Quit"
+	}
+	if item.Autopilot {
+		helpText = strings.Replace(helpText, "[p] Autopilot  ", "", 1)
+	}
+	if job := activeJob(jobs); job != nil {
+		if job.Status == model.JobStatusPending {
+			helpText = "[+/-] Priority  " + helpText
+		}
+		helpText = "[x] Cancel  " + helpText
+	}
+	if len(jobs) > 0 {
+		helpText = strings.Replace(helpText, "[r] Refresh", "[l] Log  [r] Refresh", 1)
 	}
 	b.WriteString(helpStyle.Render(helpText))
 
@@ -10,45 +10,48 @@ import (
 
 // AppState holds the current application state
 type AppState struct {
-	Items      []model.MediaItem
-	MovieJobs  map[int64][]model.Job  // itemID -> jobs (for movies)
-	SeasonJobs map[int64][]model.Job  // seasonID -> jobs (for TV seasons)
+	Items       []model.MediaItem
+	MovieJobs   map[int64][]model.Job            // itemID -> jobs (for movies)
+	SeasonJobs  map[int64][]model.Job            // seasonID -> jobs (for TV seasons)
+	Transcodes  map[int64]model.TranscodeSummary // jobID -> file progress (in-progress transcodes only)
+	Workers     []model.Worker
+	AttemptLogs map[int64][]model.LogEvent // jobID -> last log events (failed jobs only)
 }
 
-// LoadState loads application state from the database
+// LoadState loads application state from the database, in the same number
+// of queries however many items are active
 func LoadState(repo db.Repository) (*AppState, error) {
 	ctx := context.Background()
 
-	items, err := repo.ListActiveItems(ctx)
+	active, err := repo.LoadActiveItems(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list active items: %w", err)
+		return nil, fmt.Errorf("failed to load active items: %w", err)
+	}
+
+	workers, err := repo.ListWorkers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workers: %w", err)
 	}
 
 	state := &AppState{
-		Items:      items,
-		MovieJobs:  make(map[int64][]model.Job),
-		SeasonJobs: make(map[int64][]model.Job),
+		Items:       active.Items,
+		MovieJobs:   make(map[int64][]model.Job),
+		SeasonJobs:  make(map[int64][]model.Job),
+		Transcodes:  active.Transcodes,
+		Workers:     workers,
+		AttemptLogs: make(map[int64][]model.LogEvent),
+	}
+	if err := state.loadAttemptLogs(ctx, repo, active.Jobs); err != nil {
+		return nil, err
 	}
 
-	// Load seasons for TV shows, jobs for all
-	for i := range items {
-		item := &items[i]
+	// Assign jobs to movies and seasons
+	for i := range state.Items {
+		item := &state.Items[i]
+		jobs := active.Jobs[item.ID]
 
 		if item.Type == model.MediaTypeTV {
-			seasons, err := repo.ListSeasonsForItem(ctx, item.ID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list seasons for %s: %w", item.Name, err)
-			}
-			item.Seasons = seasons
-
-			// Load jobs once for the entire TV show
-			jobs, err := repo.ListJobsForMedia(ctx, item.ID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list jobs for %s: %w", item.Name, err)
-			}
-
-			// Assign jobs to each season
-			for _, season := range seasons {
+			for _, season := range item.Seasons {
 				// Filter to jobs for this season
 				var seasonJobs []model.Job
 				for _, job := range jobs {
@@ -59,11 +62,6 @@ func LoadState(repo db.Repository) (*AppState, error) {
 				state.SeasonJobs[season.ID] = seasonJobs
 			}
 		} else {
-			// Movie - load jobs directly
-			jobs, err := repo.ListJobsForMedia(ctx, item.ID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list jobs for %s: %w", item.Name, err)
-			}
 			state.MovieJobs[item.ID] = jobs
 
 			// Update movie's current stage from jobs
@@ -78,6 +76,26 @@ func LoadState(repo db.Repository) (*AppState, error) {
 	return state, nil
 }
 
+// loadAttemptLogs loads the last log events of each failed job, shown under
+// the attempt in the job history
+func (s *AppState) loadAttemptLogs(ctx context.Context, repo db.Repository, itemJobs map[int64][]model.Job) error {
+	var failed []int64
+	for _, jobs := range itemJobs {
+		for _, job := range jobs {
+			if job.Status == model.JobStatusFailed {
+				failed = append(failed, job.ID)
+			}
+		}
+	}
+
+	events, err := repo.ListRecentLogEvents(ctx, failed, attemptLogLines)
+	if err != nil {
+		return fmt.Errorf("failed to list log events: %w", err)
+	}
+	s.AttemptLogs = events
+	return nil
+}
+
 // ItemsNeedingAction returns movies that need user action.
 // Note: Currently only handles movies. TV show seasons are handled in the display logic
 // (Task 5 itemlist.go) by checking season.StageStatus directly.
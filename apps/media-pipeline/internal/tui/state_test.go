@@ -316,3 +316,42 @@ func TestAppState_ItemsFailed(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadState_AttemptLogs(t *testing.T) {
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	defer database.Close()
+
+	repo := db.NewSQLiteRepository(database)
+	ctx := context.Background()
+
+	movie := &model.MediaItem{
+		Type:     model.MediaTypeMovie,
+		Name:     "Test Movie",
+		SafeName: "Test_Movie",
+	}
+	if err := repo.CreateMediaItem(ctx, movie); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+
+	failed := &model.Job{MediaItemID: movie.ID, Stage: model.StageRemux, Status: model.JobStatusFailed}
+	if err := repo.CreateJob(ctx, failed); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	event := &model.LogEvent{JobID: failed.ID, Level: "error", Message: "mkvmerge failed"}
+	if err := repo.CreateLogEvent(ctx, event); err != nil {
+		t.Fatalf("CreateLogEvent() error = %v", err)
+	}
+
+	state, err := LoadState(repo)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+
+	events := state.AttemptLogs[failed.ID]
+	if len(events) != 1 || events[0].Message != "mkvmerge failed" {
+		t.Errorf("AttemptLogs[%d] = %v, want the failed job's log event", failed.ID, events)
+	}
+}
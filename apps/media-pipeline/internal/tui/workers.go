@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/model"
+	"github.com/cuivienor/media-pipeline/internal/workers"
+)
+
+// renderWorkers renders the worker registry: which hosts are up, what they
+// can do and what they are running
+func (a *App) renderWorkers() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Workers"))
+	b.WriteString("\n\n")
+
+	if len(a.state.Workers) == 0 {
+		b.WriteString(mutedItemStyle.Render("No worker has sent a heartbeat yet. Run `media-pipeline worker` on each host."))
+		b.WriteString("\n\n")
+	} else {
+		now := time.Now()
+		for _, w := range a.state.Workers {
+			b.WriteString(renderWorkerRow(&w, now))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("[r] Refresh  [Esc] Back  [q] Quit"))
+	return b.String()
+}
+
+// renderWorkerRow renders a worker's status, capabilities and running jobs
+func renderWorkerRow(w *model.Worker, now time.Time) string {
+	status := statusCompleted.String() + " online "
+	if !w.Online(now, workers.OfflineAfter) {
+		status = statusFailed.String() + " offline"
+	}
+
+	caps := "no special capabilities"
+	if len(w.Capabilities) > 0 {
+		names := make([]string, len(w.Capabilities))
+		for i, c := range w.Capabilities {
+			names[i] = string(c)
+		}
+		caps = strings.Join(names, ", ")
+	}
+
+	job := "idle"
+	switch {
+	case w.RunningJobs == 1:
+		job = "1 job"
+	case w.RunningJobs > 1:
+		job = fmt.Sprintf("%d jobs", w.RunningJobs)
+	}
+
+	return fmt.Sprintf("  %s  %-16s %-8s %s\n      %s",
+		status, w.Hostname, job,
+		mutedItemStyle.Render("last seen "+formatAge(now.Sub(w.LastSeen))),
+		mutedItemStyle.Render(caps))
+}
+
+// formatAge formats how long ago something happened, e.g. "12s ago"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,105 @@
+// Package workers keeps the registry of hosts that run stage binaries: each
+// host heartbeats its capabilities, and the daemon routes stages to hosts
+// that are online and able to run them.
+package workers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// HeartbeatInterval is how often a worker reports that it is alive
+const HeartbeatInterval = 30 * time.Second
+
+// OfflineAfter is how long after its last heartbeat a worker counts as offline
+const OfflineAfter = 3 * HeartbeatInterval
+
+// DetectCapabilities probes this host for optional hardware and tools
+func DetectCapabilities() []model.Capability {
+	var caps []model.Capability
+	if matches, _ := filepath.Glob("/dev/sr[0-9]*"); len(matches) > 0 {
+		caps = append(caps, model.CapabilityOpticalDrive)
+	}
+	// QSV is exposed through the DRM render node of the Intel GPU
+	if matches, _ := filepath.Glob("/dev/dri/renderD*"); len(matches) > 0 {
+		caps = append(caps, model.CapabilityQSV)
+	}
+	if _, err := exec.LookPath("filebot"); err == nil {
+		caps = append(caps, model.CapabilityFilebot)
+	}
+	return caps
+}
+
+// Heartbeat reports this host to the worker registry
+type Heartbeat struct {
+	repo     db.Repository
+	hostname string
+	target   string
+	caps     []model.Capability
+}
+
+// NewHeartbeat creates a heartbeat for this host. target is how the daemon
+// dispatches to this host, or "" to keep whatever was registered before.
+func NewHeartbeat(repo db.Repository, target string) *Heartbeat {
+	return &Heartbeat{
+		repo:     repo,
+		hostname: jobs.LocalWorkerID(),
+		target:   target,
+		caps:     DetectCapabilities(),
+	}
+}
+
+// Capabilities returns what this host reports it can do
+func (h *Heartbeat) Capabilities() []model.Capability {
+	return h.caps
+}
+
+// Beat records a single heartbeat
+func (h *Heartbeat) Beat(ctx context.Context) error {
+	return h.repo.UpsertWorker(ctx, &model.Worker{
+		Hostname:     h.hostname,
+		Target:       h.target,
+		Capabilities: h.caps,
+		LastSeen:     time.Now(),
+	})
+}
+
+// Start beats once, then every HeartbeatInterval in the background.
+// The returned function stops the heartbeat.
+func (h *Heartbeat) Start(ctx context.Context) (stop func()) {
+	if err := h.Beat(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Heartbeat failed: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := h.Beat(ctx); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Heartbeat failed: %v\n", err)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
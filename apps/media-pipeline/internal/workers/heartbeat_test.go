@@ -0,0 +1,37 @@
+package workers
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHeartbeat_StartStop(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	h := NewHeartbeat(repo, "media@analyzer")
+	h.hostname = "analyzer"
+
+	stop := h.Start(ctx)
+
+	// Start beats before returning
+	got, err := repo.GetWorker(ctx, "analyzer")
+	if err != nil || got == nil {
+		t.Fatalf("GetWorker() = %v, %v, want the heartbeat's record", got, err)
+	}
+	if got.Target != "media@analyzer" {
+		t.Errorf("Target = %q, want media@analyzer", got.Target)
+	}
+
+	stop()
+
+	// A heartbeat from a process that doesn't know the target keeps it
+	other := NewHeartbeat(repo, "")
+	other.hostname = "analyzer"
+	if err := other.Beat(ctx); err != nil {
+		t.Fatalf("Beat() error = %v", err)
+	}
+	if got, _ = repo.GetWorker(ctx, "analyzer"); got.Target != "media@analyzer" {
+		t.Errorf("Target = %q after targetless beat, want media@analyzer", got.Target)
+	}
+}
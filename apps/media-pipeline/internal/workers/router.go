@@ -0,0 +1,146 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/jobs"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+// ErrNoWorker is returned when no online worker can run a stage
+var ErrNoWorker = errors.New("no worker available")
+
+// RequiredCapabilities returns what a host needs to run a stage
+func RequiredCapabilities(cfg *config.Config, stage model.Stage) []model.Capability {
+	switch stage {
+	case model.StageRip:
+		return []model.Capability{model.CapabilityOpticalDrive}
+	case model.StageTranscode:
+		if cfg.TranscodeMode() == "hardware" {
+			return []model.Capability{model.CapabilityQSV}
+		}
+	case model.StagePublish:
+		return []model.Capability{model.CapabilityFilebot}
+	}
+	return nil
+}
+
+// Router picks the host a stage runs on
+type Router struct {
+	repo  db.Repository
+	cfg   *config.Config
+	local string
+	now   func() time.Time
+}
+
+// NewRouter creates a router for the dispatch targets in cfg
+func NewRouter(repo db.Repository, cfg *config.Config) *Router {
+	return &Router{repo: repo, cfg: cfg, local: jobs.LocalWorkerID(), now: time.Now}
+}
+
+// Route returns the SSH target to run a stage on, or "" for this host.
+//
+// The stage's dispatch target is used while its worker is online and has the
+// capabilities the stage needs. Otherwise another such worker is picked,
+// preferring the least busy; only this host and hosts that registered a
+// target with `media-pipeline worker -target` can be rerouted to. Rip is
+// never rerouted since the disc is in a specific drive. Hosts that have never
+// sent a heartbeat are not managed by the registry and are dispatched to as
+// configured. Returns ErrNoWorker when no worker can run the stage.
+func (r *Router) Route(ctx context.Context, stage model.Stage) (string, error) {
+	configured := r.cfg.DispatchTarget(stage.String())
+
+	workers, err := r.repo.ListWorkers(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	required := RequiredCapabilities(r.cfg, stage)
+	now := r.now()
+
+	var preferred *model.Worker
+	for i := range workers {
+		if r.reachedBy(&workers[i], configured) {
+			preferred = &workers[i]
+		}
+	}
+	if preferred == nil {
+		return configured, nil
+	}
+
+	var reason string
+	switch {
+	case !preferred.Online(now, OfflineAfter):
+		reason = fmt.Sprintf("%s is offline (last seen %s)", preferred.Hostname, preferred.LastSeen.Local().Format(time.DateTime))
+	case !preferred.Has(required...):
+		reason = fmt.Sprintf("%s lacks %s", preferred.Hostname, joinCapabilities(required))
+	default:
+		return configured, nil
+	}
+
+	if stage == model.StageRip {
+		return "", fmt.Errorf("%w for %s: %s", ErrNoWorker, stage, reason)
+	}
+
+	var fallback *model.Worker
+	for i := range workers {
+		w := &workers[i]
+		if w == preferred || !w.Online(now, OfflineAfter) || !w.Has(required...) {
+			continue
+		}
+		if w.Target == "" && w.Hostname != r.local {
+			// No known way to dispatch to it
+			continue
+		}
+		if fallback == nil || w.RunningJobs < fallback.RunningJobs {
+			fallback = w
+		}
+	}
+	if fallback == nil {
+		return "", fmt.Errorf("%w for %s: %s", ErrNoWorker, stage, reason)
+	}
+	if fallback.Hostname == r.local {
+		return "", nil
+	}
+	return fallback.Target, nil
+}
+
+// reachedBy reports whether a dispatch target ("" for this host) leads to w
+func (r *Router) reachedBy(w *model.Worker, target string) bool {
+	if target == "" {
+		return w.Hostname == r.local
+	}
+	return ReachedBy(w, target)
+}
+
+// ReachedBy reports whether an SSH or agent target leads to w: either the
+// target w registered, or its hostname
+func ReachedBy(w *model.Worker, target string) bool {
+	return w.Target == target || w.Hostname == hostOf(target)
+}
+
+// hostOf strips the user from an SSH target and the port from an agent target
+func hostOf(target string) string {
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		target = target[i+1:]
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		return host
+	}
+	return target
+}
+
+func joinCapabilities(caps []model.Capability) string {
+	names := make([]string, len(caps))
+	for i, c := range caps {
+		names[i] = string(c)
+	}
+	return strings.Join(names, ", ")
+}
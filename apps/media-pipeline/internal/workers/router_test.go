@@ -0,0 +1,199 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cuivienor/media-pipeline/internal/config"
+	"github.com/cuivienor/media-pipeline/internal/db"
+	"github.com/cuivienor/media-pipeline/internal/model"
+)
+
+func newTestRepo(t *testing.T) *db.SQLiteRepository {
+	t.Helper()
+	database, err := db.OpenInMemory()
+	if err != nil {
+		t.Fatalf("OpenInMemory() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return db.NewSQLiteRepository(database)
+}
+
+func TestRouter_Route(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fresh := now.Add(-time.Minute)
+	stale := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		workers  []model.Worker
+		running  []string // worker IDs of in-progress jobs
+		dispatch map[string]string
+		stage    model.Stage
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "target never registered is dispatched as configured",
+			dispatch: map[string]string{"remux": "analyzer"},
+			stage:    model.StageRemux,
+			want:     "analyzer",
+		},
+		{
+			name:     "online target",
+			workers:  []model.Worker{{Hostname: "analyzer", LastSeen: fresh}},
+			dispatch: map[string]string{"remux": "media@analyzer"},
+			stage:    model.StageRemux,
+			want:     "media@analyzer",
+		},
+		{
+			name: "offline target reroutes to the least busy worker's target",
+			workers: []model.Worker{
+				{Hostname: "analyzer", LastSeen: stale},
+				{Hostname: "busy", Target: "media@busy", LastSeen: fresh},
+				{Hostname: "spare", Target: "media@spare", LastSeen: fresh},
+			},
+			running:  []string{"media@busy"},
+			dispatch: map[string]string{"remux": "analyzer"},
+			stage:    model.StageRemux,
+			want:     "media@spare",
+		},
+		{
+			name: "target matched by the address its worker registered",
+			workers: []model.Worker{
+				{Hostname: "analyzer", Target: "media@10.0.0.5", LastSeen: stale},
+				{Hostname: "spare", Target: "media@spare", LastSeen: fresh},
+			},
+			dispatch: map[string]string{"remux": "media@10.0.0.5"},
+			stage:    model.StageRemux,
+			want:     "media@spare",
+		},
+		{
+			name: "workers without a registered target are not rerouted to",
+			workers: []model.Worker{
+				{Hostname: "analyzer", LastSeen: stale},
+				{Hostname: "unreachable", LastSeen: fresh},
+			},
+			dispatch: map[string]string{"remux": "analyzer"},
+			stage:    model.StageRemux,
+			wantErr:  true,
+		},
+		{
+			name: "reroute to this host runs locally",
+			workers: []model.Worker{
+				{Hostname: "analyzer", LastSeen: stale},
+				{Hostname: "daemon-host", LastSeen: fresh},
+			},
+			dispatch: map[string]string{"remux": "analyzer"},
+			stage:    model.StageRemux,
+			want:     "",
+		},
+		{
+			name: "reroute needs the stage's capabilities",
+			workers: []model.Worker{
+				{Hostname: "analyzer", LastSeen: stale, Capabilities: []model.Capability{model.CapabilityFilebot}},
+				{Hostname: "spare", Target: "media@spare", LastSeen: fresh},
+			},
+			dispatch: map[string]string{"publish": "analyzer"},
+			stage:    model.StagePublish,
+			wantErr:  true,
+		},
+		{
+			name: "online target without the capability",
+			workers: []model.Worker{
+				{Hostname: "analyzer", LastSeen: fresh},
+				{Hostname: "spare", Target: "media@spare", LastSeen: fresh, Capabilities: []model.Capability{model.CapabilityFilebot}},
+			},
+			dispatch: map[string]string{"publish": "analyzer"},
+			stage:    model.StagePublish,
+			want:     "media@spare",
+		},
+		{
+			name: "rip is never rerouted",
+			workers: []model.Worker{
+				{Hostname: "ripper", LastSeen: stale, Capabilities: []model.Capability{model.CapabilityOpticalDrive}},
+				{Hostname: "spare", Target: "media@spare", LastSeen: fresh, Capabilities: []model.Capability{model.CapabilityOpticalDrive}},
+			},
+			dispatch: map[string]string{"rip": "ripper"},
+			stage:    model.StageRip,
+			wantErr:  true,
+		},
+		{
+			name:    "local stage on this host",
+			workers: []model.Worker{{Hostname: "daemon-host", LastSeen: fresh}},
+			stage:   model.StageTranscode,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			ctx := context.Background()
+			for i := range tt.workers {
+				if err := repo.UpsertWorker(ctx, &tt.workers[i]); err != nil {
+					t.Fatalf("UpsertWorker() error = %v", err)
+				}
+			}
+			createRunningJobs(t, repo, tt.running)
+
+			r := NewRouter(repo, &config.Config{Dispatch: tt.dispatch})
+			r.local = "daemon-host"
+			r.now = func() time.Time { return now }
+
+			got, err := r.Route(ctx, tt.stage)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoWorker) {
+					t.Errorf("Route() error = %v, want ErrNoWorker", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Route() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Route() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// createRunningJobs creates an in-progress job for each worker ID
+func createRunningJobs(t *testing.T, repo db.Repository, workerIDs []string) {
+	t.Helper()
+	if len(workerIDs) == 0 {
+		return
+	}
+	ctx := context.Background()
+	item := &model.MediaItem{Type: model.MediaTypeMovie, Name: "The Matrix", SafeName: "The_Matrix"}
+	if err := repo.CreateMediaItem(ctx, item); err != nil {
+		t.Fatalf("CreateMediaItem() error = %v", err)
+	}
+	for i, workerID := range workerIDs {
+		disc := i + 1
+		job := &model.Job{MediaItemID: item.ID, Stage: model.StageRip, Status: model.JobStatusInProgress, Disc: &disc, WorkerID: workerID}
+		if err := repo.CreateJob(ctx, job); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+	}
+}
+
+func TestRequiredCapabilities(t *testing.T) {
+	software := &config.Config{}
+	hardware := &config.Config{Transcode: config.TranscodeConfig{Mode: "hardware"}}
+
+	if caps := RequiredCapabilities(software, model.StageRip); len(caps) != 1 || caps[0] != model.CapabilityOpticalDrive {
+		t.Errorf("rip = %v, want optical_drive", caps)
+	}
+	if caps := RequiredCapabilities(software, model.StageTranscode); len(caps) != 0 {
+		t.Errorf("software transcode = %v, want none", caps)
+	}
+	if caps := RequiredCapabilities(hardware, model.StageTranscode); len(caps) != 1 || caps[0] != model.CapabilityQSV {
+		t.Errorf("hardware transcode = %v, want qsv", caps)
+	}
+	if caps := RequiredCapabilities(software, model.StagePublish); len(caps) != 1 || caps[0] != model.CapabilityFilebot {
+		t.Errorf("publish = %v, want filebot", caps)
+	}
+}